@@ -1,14 +1,22 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"slices"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/mark3labs/codebench-mcp/internal/logger"
 	"github.com/mark3labs/codebench-mcp/server"
+	"github.com/mark3labs/codebench-mcp/server/modules/cache"
+	"github.com/mark3labs/codebench-mcp/server/modules/fetch"
+	"github.com/mark3labs/codebench-mcp/server/modules/kv"
+	"github.com/mark3labs/codebench-mcp/server/quota"
 	mcpserver "github.com/mark3labs/mcp-go/server"
 	"github.com/spf13/cobra"
 )
@@ -18,6 +26,30 @@ var (
 	disabledModules []string
 	debugMode       bool
 	executionTimeout int
+	kvFile          string
+	cacheDir        string
+	cacheRedisURL   string
+	sharedCache     bool
+	fetchCache      bool
+	teeConsole      bool
+	fetchGetOnly    bool
+	maxFetchCalls   int
+	maxTimers       int
+	maxOutputSize   int
+	consoleResult   bool
+	persistGlobals  bool
+	sessionIdle     int
+	maxCacheEntries int
+	maxExecutions   int
+	cpuBudget       int
+	deterministic   bool
+	seed            int64
+	fetchMocks      string
+	fetchMaxSize    int64
+	httpPort        int
+	httpHostname    string
+	httpLoopback    bool
+	idleTimeout     int
 )
 
 // Available modules
@@ -31,14 +63,25 @@ var availableModules = []string{
 	"encoding",
 	"url",
 	"cache",
+	"chart",
+	"pdf",
+	"ndjson",
+	"worker",
+	"html",
+	"wasm",
 	// TODO: Add these as they're implemented
 	// "dom",
 	// "ext",
-	// "html",
 	// "signal",
 	// "stream",
 }
 
+// Modules that are only enabled when listed in --enabled-modules
+var optInModules = []string{
+	"html",
+	"wasm",
+}
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "codebench-mcp",
@@ -74,13 +117,13 @@ with a modern runtime including http, fetch, timers, buffer, crypto, and other m
 				}
 			}
 			for _, module := range availableModules {
-				if !slices.Contains(disabledModules, module) {
+				if !slices.Contains(disabledModules, module) && !slices.Contains(optInModules, module) {
 					modulesToEnable = append(modulesToEnable, module)
 				}
 			}
 		} else {
 			// Enable default modules (same as NewJSHandler default)
-			modulesToEnable = []string{"http", "fetch", "timers", "buffer", "kv", "crypto", "encoding", "url", "cache"}
+			modulesToEnable = []string{"http", "fetch", "timers", "buffer", "kv", "crypto", "encoding", "url", "cache", "chart", "pdf", "ndjson", "worker"}
 		}
 
 		logger.Debug("Module configuration", "enabled", modulesToEnable)
@@ -89,17 +132,87 @@ with a modern runtime including http, fetch, timers, buffer, crypto, and other m
 		config := server.ModuleConfig{
 			EnabledModules: modulesToEnable,
 			ExecutionTimeout: time.Duration(executionTimeout) * time.Second,
+			FetchCache: fetchCache,
+			SharedCache: sharedCache,
+			TeeConsole: teeConsole,
+			FetchSafeMethodsOnly: fetchGetOnly,
+			FetchMaxResponseSize: fetchMaxSize,
+			MaxTimers: maxTimers,
+			MaxOutputSize: maxOutputSize,
+			ConsoleAsResult: consoleResult,
+			PersistentGlobals: persistGlobals,
+			SessionIdleTimeout: time.Duration(sessionIdle) * time.Second,
+			MaxConcurrentExecutions: maxExecutions,
+			CPUBudget: time.Duration(cpuBudget) * time.Millisecond,
+			IdleTimeout: time.Duration(idleTimeout) * time.Millisecond,
+			HTTPDefaultPort: httpPort,
+			HTTPDefaultHostname: httpHostname,
+			HTTPLoopbackOnly: httpLoopback,
+			Deterministic: deterministic,
+			DeterministicSeed: seed,
+			Quotas: quota.Limits{
+				FetchCalls:   maxFetchCalls,
+				CacheEntries: maxCacheEntries,
+			},
+		}
+
+		// Serve fetch from canned responses instead of the network
+		if fetchMocks != "" {
+			mocks, err := fetch.LoadMocks(fetchMocks)
+			if err != nil {
+				logger.Fatal("Failed to load fetch mocks", "path", fetchMocks, "error", err)
+			}
+			config.FetchMocks = mocks
+			logger.Debug("Using fetch mocks", "path", fetchMocks, "count", len(mocks))
+		}
+
+		// Persist kv values to disk if requested
+		if kvFile != "" {
+			store, err := kv.NewFileStore(kvFile)
+			if err != nil {
+				logger.Fatal("Failed to open kv file", "path", kvFile, "error", err)
+			}
+			config.KVStore = store
+			logger.Debug("Using file-backed kv store", "path", kvFile)
 		}
 
-		jss, err := server.NewJSServerWithConfig(config)
+		// Persist cache items to disk if requested
+		if cacheDir != "" {
+			backend, err := cache.NewDirCache(cacheDir)
+			if err != nil {
+				logger.Fatal("Failed to open cache directory", "path", cacheDir, "error", err)
+			}
+			config.CacheBackend = backend
+			logger.Debug("Using disk-backed cache", "path", cacheDir)
+		}
+
+		// Share cache items across processes through Redis if requested
+		if cacheRedisURL != "" {
+			backend, err := cache.NewRedisCache(context.Background(), cacheRedisURL)
+			if err != nil {
+				logger.Fatal("Failed to connect to the Redis cache", "error", err)
+			}
+			config.CacheBackend = backend
+			logger.Debug("Using Redis-backed cache")
+		}
+
+		jss, handler, err := server.NewJSServerWithHandler(config)
 		if err != nil {
 			logger.Fatal("Failed to create server", "error", err)
 		}
 
 		logger.Info("Starting MCP server", "modules", modulesToEnable)
 
+		// Stop serving on SIGINT or SIGTERM, then shut down background
+		// servers and sessions gracefully instead of killing them
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
 		// Serve requests
-		if err := mcpserver.ServeStdio(jss); err != nil {
+		err = mcpserver.NewStdioServer(jss).Listen(ctx, os.Stdin, os.Stdout)
+		logger.Info("Shutting down MCP server")
+		handler.Cleanup()
+		if err != nil && !errors.Is(err, context.Canceled) {
 			logger.Fatal("Server error", "error", err)
 		}
 	},
@@ -124,6 +237,55 @@ func init() {
 		"Enable debug logging (outputs to stderr)")
 	rootCmd.Flags().IntVar(&executionTimeout, "execution-timeout", 300,
 		"JavaScript execution timeout in seconds (default: 300 = 5 minutes)")
+	rootCmd.Flags().IntVar(&httpPort, "http-default-port", 0,
+		"Port of http servers started without one (0 = 8000, -1 = any free port, read from server.port)")
+	rootCmd.Flags().StringVar(&httpHostname, "http-default-hostname", "",
+		"Address http servers bind to when the script gives no hostname (default: 127.0.0.1, or all interfaces when only a port is given)")
+	rootCmd.Flags().BoolVar(&httpLoopback, "http-loopback-only", false,
+		"Only let http servers listen on loopback, rejecting other hostnames such as 0.0.0.0")
+	rootCmd.Flags().StringVar(&kvFile, "kv-file", "",
+		"Persist kv values to this JSON file so they survive restarts (default: in-memory)")
+	rootCmd.Flags().StringVar(&cacheDir, "cache-dir", "",
+		"Persist cache module items, with their TTL, to files in this directory so they survive restarts (default: in-memory)")
+	rootCmd.Flags().BoolVar(&sharedCache, "shared-cache", false,
+		"Share the in-memory cache module items across all executions instead of keeping them per VM")
+	rootCmd.Flags().StringVar(&cacheRedisURL, "cache-redis-url", "",
+		"Store cache module items in the Redis server at this URL (e.g. redis://localhost:6379/0) to share them across processes")
+	rootCmd.Flags().BoolVar(&fetchCache, "fetch-cache", false,
+		"Cache fetch GET responses in memory while Cache-Control max-age says they are fresh")
+	rootCmd.Flags().BoolVar(&fetchGetOnly, "fetch-get-only", false,
+		"Restrict fetch to GET and HEAD requests, rejecting mutating methods")
+	rootCmd.Flags().StringVar(&fetchMocks, "fetch-mocks", "",
+		"Serve fetch from the canned responses in this JSON file, keyed by \"URL\" or \"METHOD URL\", without network access")
+	rootCmd.Flags().Int64Var(&fetchMaxSize, "fetch-max-response-size", 0,
+		"Maximum bytes of a fetch response body, larger responses fail (0 = default of 50 MiB)")
+	rootCmd.Flags().IntVar(&maxFetchCalls, "max-fetch-calls", 0,
+		"Maximum fetch calls per session across executions (0 = unlimited)")
+	rootCmd.Flags().IntVar(&maxCacheEntries, "max-cache-entries", 0,
+		"Maximum unexpired cache entries stored per session across executions (0 = unlimited)")
+	rootCmd.Flags().IntVar(&maxTimers, "max-timers", 0,
+		"Maximum timers and intervals active at once per execution (0 = default of 10000)")
+	rootCmd.Flags().IntVar(&maxOutputSize, "max-output-size", 0,
+		"Maximum bytes of console output captured per execution before truncating (0 = default of 1 MiB)")
+	rootCmd.Flags().IntVar(&cpuBudget, "cpu-budget", 0,
+		"Milliseconds an execution may spend running JavaScript, excluding waits for timers and I/O, before it is interrupted (0 = no budget)")
+	rootCmd.Flags().BoolVar(&deterministic, "deterministic", false,
+		"Make executions reproducible: seed Math.random (per call with the seed argument) and freeze Date at 2024-01-01T00:00:00Z")
+	rootCmd.Flags().Int64Var(&seed, "seed", 0,
+		"Default Math.random seed in deterministic mode, for calls without a seed argument")
+	rootCmd.Flags().IntVar(&idleTimeout, "idle-timeout", 0,
+		"Milliseconds an execution may only wait for intervals before they are cleared and it returns (0 = wait for the execution timeout)")
+	rootCmd.Flags().IntVar(&maxExecutions, "max-concurrent-executions", 0,
+		"Maximum executeJS calls running at once; further calls are rejected as busy (0 = unlimited)")
+	rootCmd.Flags().BoolVar(&consoleResult, "console-result", false,
+		"Use the console output as the result of scripts that return no value")
+	rootCmd.Flags().BoolVar(&persistGlobals, "persistent-globals", false,
+		"Keep globals and functions defined by a script for later executions in the same session (default: each execution starts fresh)")
+	rootCmd.Flags().IntVar(&sessionIdle, "session-idle-timeout", 900,
+		"Seconds a session runtime (sessionId or --persistent-globals) is kept without executions (default: 900 = 15 minutes)")
+	rootCmd.Flags().BoolVar(&teeConsole, "tee-console", false,
+		"Also forward console output from executed code to the server log (stderr)")
 
 	rootCmd.MarkFlagsMutuallyExclusive("enabled-modules", "disabled-modules")
+	rootCmd.MarkFlagsMutuallyExclusive("cache-dir", "cache-redis-url")
 }
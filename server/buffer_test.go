@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlob_TextSizeAndSlice(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			(async () => {
+				const bytes = new TextEncoder().encode(" world");
+				const blob = new Blob(["hello", new Uint8Array(bytes), new ArrayBuffer(0)], { type: "Text/Plain" });
+				console.log("size:", blob.size);
+				console.log("type:", blob.type);
+				console.log("instance:", blob instanceof Blob);
+				console.log("text:", await blob.text());
+
+				const part = blob.slice(-5);
+				console.log("slice:", await part.text(), part.size);
+
+				const buf = await blob.arrayBuffer();
+				console.log("arrayBuffer:", buf.byteLength);
+			})();
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "size: 11")
+	assert.Contains(t, text, "type: text/plain")
+	assert.Contains(t, text, "instance: true")
+	assert.Contains(t, text, "text: hello world")
+	assert.Contains(t, text, "slice: world 5")
+	assert.Contains(t, text, "arrayBuffer: 11")
+}
+
+func TestFile_NameSizeAndType(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			(async () => {
+				const file = new File(["hello ", "file"], "name.txt", { type: "text/plain", lastModified: 42 });
+				console.log("name:", file.name);
+				console.log("size:", file.size);
+				console.log("type:", file.type);
+				console.log("lastModified:", file.lastModified);
+				console.log("is blob:", file instanceof Blob && file instanceof File);
+				console.log("text:", await file.text());
+
+				const form = new FormData();
+				form.append("upload", new Blob(["data"], { type: "text/csv" }), "report.csv");
+				const upload = form.get("upload");
+				console.log("form file:", upload.name, upload.type, upload.size);
+			})();
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "name: name.txt")
+	assert.Contains(t, text, "size: 10")
+	assert.Contains(t, text, "type: text/plain")
+	assert.Contains(t, text, "lastModified: 42")
+	assert.Contains(t, text, "is blob: true")
+	assert.Contains(t, text, "text: hello file")
+	assert.Contains(t, text, "form file: report.csv text/csv 4")
+}
+
+func TestBuffer_IsBufferAndToJSON(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const buf = Buffer.from([1, 2, 255]);
+			const checks = [
+				Buffer.isBuffer(buf),
+				Buffer.isBuffer(buf.slice(1)),
+				Buffer.isBuffer(Buffer.alloc(2)),
+				Buffer.isBuffer(new Uint8Array(2)),
+				Buffer.isBuffer({ length: 3 }),
+				Buffer.isBuffer("abc"),
+				Buffer.isBuffer(null),
+			];
+			const json = JSON.stringify(buf);
+			const sliced = JSON.stringify(buf.slice(1).toJSON());
+			const before = buf.length;
+			buf.__data__ = Buffer.from("hello").__data__;
+			const lengths = [
+				Buffer.byteLength("héllo"),
+				Buffer.byteLength("aGVsbG8=", "base64"),
+				Buffer.byteLength("ff00", "hex"),
+				Buffer.byteLength(buf),
+				Buffer.byteLength(new ArrayBuffer(4)),
+			];
+			[checks.join(","), json, sliced, before, buf.length, lengths.join(",")].join("|");
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, `Result: true,true,true,false,false,false,false|{"type":"Buffer","data":[1,2,255]}|{"type":"Buffer","data":[2,255]}|3|5|6,5,2,5,4`)
+}
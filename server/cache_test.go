@@ -0,0 +1,266 @@
+package server
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/codebench-mcp/server/modules/cache"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubCache records calls made by the cache module
+type stubCache struct {
+	mu    sync.Mutex
+	items map[string][]byte
+	ttls  map[string]time.Duration
+}
+
+func newStubCache() *stubCache {
+	return &stubCache{
+		items: make(map[string][]byte),
+		ttls:  make(map[string]time.Duration),
+	}
+}
+
+func (s *stubCache) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.items[key], nil
+}
+
+func (s *stubCache) Set(_ context.Context, key string, value []byte, timeout time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[key] = value
+	s.ttls[key] = timeout
+	return nil
+}
+
+func (s *stubCache) Del(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, key)
+	return nil
+}
+
+func TestCacheModule_CustomBackend(t *testing.T) {
+	backend := newStubCache()
+	backend.items["preloaded"] = []byte("from backend")
+
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"cache"},
+		CacheBackend:   backend,
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const cache = require('cache');
+			cache.set('greeting', 'hello', 1500);
+			console.log("preloaded:", cache.get('preloaded'));
+			cache.get('greeting');
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "preloaded: from backend")
+	assert.Contains(t, text, "Result: hello")
+
+	assert.Equal(t, []byte("hello"), backend.items["greeting"])
+	assert.Equal(t, 1500*time.Millisecond, backend.ttls["greeting"])
+}
+
+func TestCacheModule_DirCachePersistsAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+
+	run := func(code string) string {
+		backend, err := cache.NewDirCache(dir)
+		require.NoError(t, err)
+
+		handler := NewJSHandlerWithConfig(ModuleConfig{
+			EnabledModules: []string{"cache"},
+			CacheBackend:   backend,
+		})
+
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "executeJS"
+		request.Params.Arguments = map[string]any{"code": code}
+
+		result, err := handler.handleExecuteJS(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	run(`
+		const cache = require('cache');
+		cache.set('answer', '42');
+		cache.set('short-lived', 'gone', 1);
+		cache.set('removed', 'x');
+		cache.del('removed');
+	`)
+	time.Sleep(5 * time.Millisecond)
+
+	// A new cache on the same directory simulates a server restart
+	text := run(`
+		const cache = require('cache');
+		[cache.get('answer'), cache.get('short-lived'), cache.get('removed')].join('|');
+	`)
+	assert.Contains(t, text, "Result: 42||")
+}
+
+// TestCacheModule_RedisCache runs against the Redis server in
+// CODEBENCH_TEST_REDIS_URL, e.g. redis://localhost:6379/15, and is skipped
+// without one
+func TestCacheModule_RedisCache(t *testing.T) {
+	url := os.Getenv("CODEBENCH_TEST_REDIS_URL")
+	if url == "" {
+		t.Skip("CODEBENCH_TEST_REDIS_URL is not set")
+	}
+	backend, err := cache.NewRedisCache(context.Background(), url)
+	require.NoError(t, err)
+
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"cache"},
+		CacheBackend:   backend,
+	})
+
+	run := func(code string) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "executeJS"
+		request.Params.Arguments = map[string]any{"code": code}
+
+		result, err := handler.handleExecuteJS(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	text := run(`
+		const cache = require('cache');
+		cache.set('codebench:test:kept', 'stored');
+		cache.set('codebench:test:expiring', 'soon gone', 1000);
+		cache.set('codebench:test:removed', 'x');
+		cache.del('codebench:test:removed');
+		[cache.get('codebench:test:kept'), cache.get('codebench:test:expiring'), cache.get('codebench:test:removed')].join('|');
+	`)
+	assert.Contains(t, text, "Result: stored|soon gone|")
+
+	time.Sleep(1100 * time.Millisecond)
+	text = run(`
+		const cache = require('cache');
+		const values = [cache.get('codebench:test:kept'), cache.get('codebench:test:expiring')].join('|');
+		cache.del('codebench:test:kept');
+		values;
+	`)
+	assert.Contains(t, text, "Result: stored|")
+}
+
+func TestCacheModule_NegativeTTLThrows(t *testing.T) {
+	backend := newStubCache()
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"cache"},
+		CacheBackend:   backend,
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const cache = require('cache');
+			const errors = [];
+			for (const set of [() => cache.set('a', 'x', -1), () => cache.setBytes('b', new Uint8Array([1]), -500), () => cache.set('c', 'x', NaN)]) {
+				try {
+					set();
+				} catch (e) {
+					errors.push(e.name + " " + e.code);
+				}
+			}
+			cache.set('forever', 'x', 0);
+			errors.join(',');
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.False(t, result.IsError, text)
+	assert.Contains(t, text, "Result: TypeError ERR_INVALID_ARGUMENT,TypeError ERR_INVALID_ARGUMENT,TypeError ERR_INVALID_ARGUMENT")
+
+	// Nothing was stored for the rejected TTLs, and 0 means no expiry
+	assert.NotContains(t, backend.items, "a")
+	assert.NotContains(t, backend.items, "b")
+	assert.Equal(t, time.Duration(0), backend.ttls["forever"])
+}
+
+func TestCacheModule_BytesRoundTrip(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"cache"},
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const cache = require('cache');
+			const bytes = new Uint8Array([0, 1, 127, 128, 255]);
+			cache.setBytes('bytes', bytes);
+			bytes[0] = 9;
+
+			// Only the viewed range of a subarray or DataView is stored
+			const backing = new Uint8Array([10, 20, 30, 40]);
+			cache.setBytes('view', backing.subarray(1, 3));
+			cache.setBytes('dataview', new DataView(backing.buffer, 2, 2));
+			cache.setBytes('buffer', backing.buffer);
+
+			const asArray = cache.getBytes('bytes', { asUint8Array: true });
+			asArray[1] = 9;
+			const buffer = cache.getBytes('bytes');
+
+			[
+				asArray instanceof Uint8Array,
+				Array.from(cache.getBytes('bytes', { asUint8Array: true })).join(' '),
+				buffer instanceof ArrayBuffer,
+				Array.from(new Uint8Array(buffer)).join(' '),
+				Array.from(cache.getBytes('view', { asUint8Array: true })).join(' '),
+				Array.from(cache.getBytes('dataview', { asUint8Array: true })).join(' '),
+				Array.from(cache.getBytes('buffer', { asUint8Array: true })).join(' '),
+			].join('|');
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.False(t, result.IsError, text)
+	assert.Contains(t, text, "Result: true|0 1 127 128 255|true|0 1 127 128 255|20 30|30 40|10 20 30 40")
+}
+
+func TestCacheModule_IsolatedPerVMByDefault(t *testing.T) {
+	run := func(handler *JSHandler, code string) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "executeJS"
+		request.Params.Arguments = map[string]any{"code": code}
+		result, err := handler.handleExecuteJS(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	isolated := NewJSHandlerWithConfig(ModuleConfig{EnabledModules: []string{"cache"}})
+	assert.Contains(t, run(isolated, `const c = require('cache'); c.set('k', 'v'); c.get('k')`), "Result: v")
+	assert.Contains(t, run(isolated, `String(require('cache').get('k'))`), "Result: undefined")
+
+	shared := NewJSHandlerWithConfig(ModuleConfig{EnabledModules: []string{"cache"}, SharedCache: true})
+	run(shared, `require('cache').set('k', 'v'); 1`)
+	assert.Contains(t, run(shared, `String(require('cache').get('k'))`), "Result: v")
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChartModule_LineSVG(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const chart = require('chart');
+			const svg = chart.line({
+				title: 'Revenue',
+				series: [
+					{ name: 'Widgets', x: [1, 2, 3], y: [10, 20, 15] },
+					{ name: 'Gadgets', y: [5, 8, 12] },
+				],
+			});
+			const text = new TextDecoder().decode(svg);
+			console.log("starts with svg:", text.startsWith("<svg"));
+			console.log("has widgets:", text.includes("Widgets"));
+			console.log("has gadgets:", text.includes("Gadgets"));
+			"chart test completed";
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "starts with svg: true")
+	assert.Contains(t, text, "has widgets: true")
+	assert.Contains(t, text, "has gadgets: true")
+	assert.Contains(t, text, "Result: chart test completed")
+}
+
+func TestChartModule_BarPNG(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const chart = require('chart');
+			const png = chart.bar({
+				format: 'png',
+				bars: [{ label: 'A', value: 3 }, { label: 'B', value: 5 }],
+			});
+			[png[1], png[2], png[3]].map((c) => String.fromCharCode(c)).join('');
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: PNG")
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/charmbracelet/log"
+	"github.com/mark3labs/codebench-mcp/internal/logger"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logBuffer is a bytes.Buffer safe to write from server goroutines while a test reads it
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// captureLogger replaces the internal logger with one writing to the returned buffer
+func captureLogger(t *testing.T) *logBuffer {
+	buf := &logBuffer{}
+	previous := logger.Logger
+	logger.Logger = log.NewWithOptions(buf, log.Options{Level: log.DebugLevel})
+	t.Cleanup(func() { logger.Logger = previous })
+	return buf
+}
+
+func TestConsole_TeeToLogger(t *testing.T) {
+	logs := captureLogger(t)
+
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"timers"},
+		TeeConsole:     true,
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			console.log("hello from script");
+			console.error("something failed");
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	// Output is still captured for the result
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "hello from script")
+
+	assert.Contains(t, logs.String(), "[js] hello from script")
+	assert.Contains(t, logs.String(), "ERRO [js] something failed")
+}
+
+func TestConsole_NoTeeByDefault(t *testing.T) {
+	logs := captureLogger(t)
+
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"timers"},
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `console.log("hello from script");`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.NotContains(t, logs.String(), "hello from script")
+}
+
+func TestConsole_OutputTruncated(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{},
+		MaxOutputSize:  100,
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			for (let i = 0; i < 1000; i++) {
+				console.log("line " + i);
+			}
+			"done";
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "line 0\n")
+	assert.NotContains(t, text, "line 999")
+	assert.Equal(t, 1, strings.Count(text, "...[output truncated]"))
+	assert.Contains(t, text, "...[output truncated]\nResult: done")
+	assert.Less(t, len(text), 150)
+}
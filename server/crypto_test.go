@@ -0,0 +1,288 @@
+package server
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebCrypto_SubtleDigest(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const data = new TextEncoder().encode("hello");
+			crypto.subtle.digest("SHA-256", new Uint8Array(data)).then(buf => {
+				const hex = Array.from(new Uint8Array(buf))
+					.map(b => b.toString(16).padStart(2, "0"))
+					.join("");
+				console.log("isArrayBuffer:", buf instanceof ArrayBuffer);
+				console.log("digest:", hex);
+			});
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "isArrayBuffer: true")
+	assert.Contains(t, text, "digest: 2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824")
+}
+
+func TestWebCrypto_UnsupportedAlgorithm(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			let name;
+			try {
+				crypto.subtle.digest("MD5", new Uint8Array([1]));
+			} catch (e) {
+				name = e.name;
+			}
+			name;
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: NotSupportedError")
+}
+
+func TestWebCrypto_RandomValues(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const uuid = crypto.randomUUID();
+			const arr = crypto.getRandomValues(new Uint32Array(8));
+			const uuidOK = /^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/.test(uuid);
+			uuidOK && arr.length === 8 && arr.some(v => v !== 0);
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: true")
+}
+
+func TestWebCrypto_RandomValuesRejectsLookalikes(t *testing.T) {
+	handler := NewJSHandler()
+
+	text := runCode(t, handler, `
+		const names = [];
+		for (const arg of [{constructor: 5}, {constructor: Uint8Array, buffer: new ArrayBuffer(4), byteLength: 4}, new Float64Array(2)]) {
+			try {
+				crypto.getRandomValues(arg);
+				names.push("accepted");
+			} catch (e) {
+				names.push(e.name);
+			}
+		}
+		names.join(",");
+	`)
+	assert.Contains(t, text, "Result: TypeError,TypeError,TypeError")
+}
+
+func TestWebCrypto_RequireStillWorks(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const crypto = require('crypto');
+			crypto.sha256("hello").hex();
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: 2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824")
+}
+
+func TestCrypto_CreateHashIncremental(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const crypto = require('crypto');
+			const oneShot = crypto.sha256("hello world").hex();
+			const h = crypto.createHash("sha256");
+			h.update("hello ");
+			h.update(new TextEncoder().encode("world"));
+			const streamed = h.digest("hex");
+
+			const mac = crypto.createHmac("sha256", "key").update("hello ").update("world").digest("base64");
+			const macOneShot = crypto.hmac("sha256", "key", "hello world").base64();
+
+			let reused;
+			try {
+				h.update("more");
+			} catch (e) {
+				reused = e.message;
+			}
+			[oneShot === streamed, mac === macOneShot, reused].join(",");
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: true,true,digest already called")
+}
+
+func TestCrypto_Ed25519SignVerify(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const { ed25519 } = require('crypto');
+			const { publicKey, privateKey } = ed25519.generateKeyPair();
+			const signature = ed25519.sign(privateKey, "token payload");
+
+			const valid = ed25519.verify(publicKey, "token payload", signature);
+			const fromHex = ed25519.verify(publicKey.hex(), "token payload", signature.hex());
+			const otherMessage = ed25519.verify(publicKey, "tampered payload", signature);
+
+			const tampered = signature.bytes();
+			tampered[0] ^= 0xff;
+			const tamperedSignature = ed25519.verify(publicKey, "token payload", tampered);
+
+			[signature.hex().length, valid, fromHex, otherMessage, tamperedSignature].join(",");
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: 128,true,true,false,false")
+}
+
+func TestCrypto_AESCBCRoundTrip(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const crypto = require('crypto');
+			const key = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f";
+			const iv = "0f0e0d0c0b0a09080706050403020100";
+			const ciphertext = crypto.encrypt("aes-256-cbc", key, "legacy secret message", iv);
+			const plaintext = crypto.decrypt("aes-256-cbc", key, ciphertext.hex(), iv);
+
+			let shortIV;
+			try {
+				crypto.encrypt("aes-256-cbc", key, "x", "0001");
+			} catch (e) {
+				shortIV = e.name + ":" + e.message;
+			}
+			[ciphertext.bytes().length, new TextDecoder().decode(new Uint8Array(plaintext.bytes())), shortIV].join("|");
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: 32|legacy secret message|TypeError:iv must be 16 bytes, got 2")
+}
+
+func TestCrypto_AESCBCBadPadding(t *testing.T) {
+	key := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	// A zero block encrypted without padding decrypts to a zero padding byte
+	ciphertext := make([]byte, aes.BlockSize)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, make([]byte, aes.BlockSize))
+
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": fmt.Sprintf(`
+			const crypto = require('crypto');
+			let failure;
+			try {
+				crypto.decrypt("aes-256-cbc", %q, %q, %q);
+			} catch (e) {
+				failure = e.code + ":" + e.message;
+			}
+			failure;
+		`, hex.EncodeToString(key), hex.EncodeToString(ciphertext), hex.EncodeToString(iv)),
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: ERR_OPERATION_FAILED:bad decrypt: invalid PKCS#7 padding")
+}
+
+func TestCrypto_HmacVerify(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const crypto = require('crypto');
+			const mac = crypto.hmac("sha256", "secret", "payload");
+			[
+				crypto.hmacVerify("sha256", "secret", "payload", mac.hex()),
+				crypto.hmacVerify("sha256", "secret", "payload", mac.base64()),
+				crypto.hmacVerify("sha256", "secret", "tampered", mac.hex()),
+				crypto.hmacVerify("sha256", "wrong key", "payload", mac.base64()),
+				crypto.hmacVerify("sha256", "secret", "payload", "not a digest"),
+			].join(",");
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: true,true,false,false,false")
+}
+
+func TestCrypto_Checksums(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const crypto = require('crypto');
+			const crc = crypto.crc32("hello world");
+			const crcc = crypto.crc32("hello world", "castagnoli");
+			const adler = crypto.adler32("hello world");
+			[crc.value, crc.hex(), crc == 222957957, crcc.hex(), crypto.crc32("hello world", { polynomial: "castagnoli" }).value === crcc.value, adler.value, adler.hex()].join(",");
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: 222957957,0d4a1185,true,c99465aa,true,436929629,1a0b045d")
+}
@@ -94,7 +94,7 @@ func TestBuildToolDescription(t *testing.T) {
 
 func TestAllModulesRepresentedInDescription(t *testing.T) {
 	// Get all available modules from the actual modules directory
-	allModules := []string{"http", "fetch", "timers", "buffer", "crypto", "cache", "kv", "encoding", "url"}
+	allModules := []string{"http", "fetch", "timers", "buffer", "crypto", "cache", "kv", "encoding", "url", "chart", "pdf", "ndjson", "worker", "html", "wasm"}
 	
 	// Test with all modules enabled
 	description := buildToolDescription(allModules)
@@ -110,6 +110,12 @@ func TestAllModulesRepresentedInDescription(t *testing.T) {
 		"kv":       "Key-value store per VM instance with get, set, delete, list",
 		"encoding": "TextEncoder/TextDecoder for UTF-8 encoding/decoding",
 		"url":      "URL parsing and URLSearchParams manipulation",
+		"chart":    "Line and bar chart rendering to SVG or PNG bytes",
+		"pdf":      "Minimal PDF document generation with create, text, render",
+		"ndjson":   "Newline-delimited JSON with parse(text) to an array, stringify(values) and parseStream(stream)",
+		"worker":   "Worker(code) runs code in a separate VM",
+		"html":     "HTML parsing into a read-only DOM-like tree",
+		"wasm":     "WebAssembly.instantiate, compile and validate",
 	}
 	
 	for module, expectedDesc := range expectedModuleDescriptions {
@@ -164,7 +170,7 @@ func TestNoMissingModulesInDescription(t *testing.T) {
 	
 	// Get the module descriptions map from the buildToolDescription function
 	// We'll test this by checking that all modules we know exist have descriptions
-	allKnownModules := []string{"http", "fetch", "timers", "buffer", "crypto", "cache", "kv", "encoding", "url"}
+	allKnownModules := []string{"http", "fetch", "timers", "buffer", "crypto", "cache", "kv", "encoding", "url", "chart", "pdf", "ndjson", "worker", "html", "wasm"}
 	
 	// Build description with all modules
 	description := buildToolDescription(allKnownModules)
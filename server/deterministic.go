@@ -0,0 +1,25 @@
+package server
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// DefaultDeterministicTime is the time Date reports in deterministic mode
+// unless ModuleConfig.DeterministicTime is set
+var DefaultDeterministicTime = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// makeDeterministic replaces the sources of Math.random and of the current
+// time used by Date.now() and new Date(), so runs with the same seed give the
+// same output. Timers still fire in real time, and crypto stays random.
+func (h *JSHandler) makeDeterministic(v *vm.VM, seed int64) {
+	now := h.config.DeterministicTime
+	if now.IsZero() {
+		now = DefaultDeterministicTime
+	}
+	random := rand.New(rand.NewSource(seed))
+	v.Runtime().SetRandSource(random.Float64)
+	v.Runtime().SetTimeSource(func() time.Time { return now })
+}
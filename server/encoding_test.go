@@ -0,0 +1,331 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructuredClone_DeepCopy(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const shared = { n: 1 };
+			const original = {
+				name: "root",
+				nested: { list: [1, 2, { deep: true }] },
+				when: new Date(0),
+				bytes: new Uint8Array([1, 2, 3]),
+				map: new Map([["k", { v: 1 }]]),
+				set: new Set([1, 2]),
+				a: shared,
+				b: shared,
+			};
+			const copy = structuredClone(original);
+			copy.nested.list[2].deep = false;
+			copy.bytes[0] = 9;
+			copy.map.get("k").v = 2;
+			copy.set.add(3);
+
+			console.log("original deep:", original.nested.list[2].deep);
+			console.log("original byte:", original.bytes[0]);
+			console.log("original map:", original.map.get("k").v);
+			console.log("original set size:", original.set.size);
+			console.log("date copied:", copy.when instanceof Date && copy.when.getTime() === 0 && copy.when !== original.when);
+			console.log("typed array:", copy.bytes instanceof Uint8Array);
+			console.log("shared kept:", copy.a === copy.b);
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "original deep: true")
+	assert.Contains(t, text, "original byte: 1")
+	assert.Contains(t, text, "original map: 1")
+	assert.Contains(t, text, "original set size: 2")
+	assert.Contains(t, text, "date copied: true")
+	assert.Contains(t, text, "typed array: true")
+	assert.Contains(t, text, "shared kept: true")
+}
+
+func TestStructuredClone_CircularReference(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const a = {};
+			a.self = a;
+			try {
+				structuredClone(a);
+			} catch (e) {
+				console.log("error name:", e.name);
+			}
+			try {
+				structuredClone({ fn() {} });
+			} catch (e) {
+				console.log("function error:", e.name);
+			}
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "error name: DataCloneError")
+	assert.Contains(t, text, "function error: DataCloneError")
+}
+
+func TestStructuredClone_SpoofedTags(t *testing.T) {
+	handler := NewJSHandler()
+
+	text := runCode(t, handler, `
+		const results = [];
+		for (const tag of ["ArrayBuffer", "Boolean", "Number", "String", "Error", "FooArray", "Uint8Array", "Map", "Date", "RegExp", "DataView", "Array"]) {
+			try {
+				structuredClone({ [Symbol.toStringTag]: tag });
+				results.push(tag + " cloned");
+			} catch (e) {
+				results.push(e.name);
+			}
+		}
+		console.log("spoofed:", [...new Set(results)].join(","));
+
+		const real = structuredClone([new Boolean(false), new String("s"), /a/g, new RangeError("r"),
+			new DataView(new ArrayBuffer(4), 1, 2), new Float64Array([1.5])]);
+		console.log("real:", real[0].valueOf(), real[1].valueOf(), real[2].flags, real[3].name,
+			real[4].byteLength, real[5][0]);
+	`)
+	assert.Contains(t, text, "spoofed: DataCloneError\n")
+	assert.Contains(t, text, "real: false s g RangeError 2 1.5")
+}
+
+func TestEncoding_Base64AndHexRoundTrip(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const encoding = require('encoding');
+			const bytes = new Uint8Array([0, 1, 127, 128, 250, 255]);
+
+			const b64 = encoding.toBase64(bytes);
+			const fromB64 = encoding.fromBase64(b64);
+			const hex = encoding.toHex(bytes.buffer);
+			const fromHex = encoding.fromHex(hex);
+
+			let invalid;
+			try {
+				encoding.fromHex("zz");
+			} catch (e) {
+				invalid = e.name;
+			}
+			[b64, hex, fromB64 instanceof Uint8Array, Array.from(fromB64).join(" "), Array.from(fromHex).join(" "), invalid].join("|");
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: AAF/gPr/|00017f80faff|true|0 1 127 128 250 255|0 1 127 128 250 255|TypeError")
+}
+
+func TestEncoding_Base64Url(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const encoding = require('encoding');
+			const crypto = require('crypto');
+			const bytes = new Uint8Array([251, 255, 254, 62]);
+
+			const url = encoding.toBase64Url(bytes);
+			const fromUrl = Array.from(encoding.fromBase64Url(url)).join(" ");
+			const fromPadded = Array.from(encoding.fromBase64Url(url + "==")).join(" ");
+
+			const buf = Buffer.from(url, 'base64url');
+			const bufUrl = buf.toString('base64url');
+
+			const digest = crypto.createHash('sha256').update('abc').digest('base64url');
+			const encoded = crypto.createHash('sha256').update('abc').digest().base64url();
+			[url, fromUrl, fromPadded, bufUrl, digest === encoded, digest.length, /[+\/=]/.test(digest)].join("|");
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: -__-Pg|251 255 254 62|251 255 254 62|-__-Pg|true|43|false")
+}
+
+func TestEncoding_JSON5Parse(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const value = JSON5.parse(` + "`" + `{
+				// the tool name
+				name: 'codebench',
+				tags: ["a", "b",],
+				/* hex and leading-dot numbers */
+				count: 0x10,
+				ratio: .5,
+				nested: { ok: true, none: null, },
+			}` + "`" + `);
+
+			let strict;
+			try {
+				JSON.parse("{a: 1,}");
+			} catch (e) {
+				strict = e.name;
+			}
+
+			let invalid;
+			try {
+				JSON5.parse("{a: }");
+			} catch (e) {
+				invalid = e.name + " " + e.code;
+			}
+			[JSON.stringify(value), strict, invalid].join("|");
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.False(t, result.IsError, text)
+	assert.Contains(t, text, `Result: {"count":16,"name":"codebench","nested":{"none":null,"ok":true},"ratio":0.5,"tags":["a","b"]}|SyntaxError|SyntaxError ERR_INVALID_ARGUMENT`)
+}
+
+func TestEncoding_IntlFormat(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const usd = new Intl.NumberFormat("en-US", { style: "currency", currency: "USD" });
+			const eur = new Intl.NumberFormat("de-DE", { style: "currency", currency: "EUR" });
+			const yen = new Intl.NumberFormat("en-US", { style: "currency", currency: "JPY" });
+			const digits = new Intl.NumberFormat("en-US", { minimumFractionDigits: 1, maximumFractionDigits: 2 });
+			const percent = new Intl.NumberFormat("en-US", { style: "percent" });
+			const plain = new Intl.NumberFormat(undefined, { useGrouping: false });
+
+			const date = new Date(Date.UTC(2024, 0, 5, 15, 4, 9));
+			const us = new Intl.DateTimeFormat("en-US", { timeZone: "UTC" });
+			const gb = new Intl.DateTimeFormat("en-GB", { timeZone: "UTC", dateStyle: "long" });
+			const full = new Intl.DateTimeFormat("en-US", {
+				timeZone: "UTC", weekday: "long", year: "numeric", month: "long", day: "numeric",
+				hour: "numeric", minute: "2-digit",
+			});
+			const time = new Intl.DateTimeFormat("de", { timeZone: "UTC", hour: "2-digit", minute: "2-digit", second: "2-digit" });
+
+			let invalid;
+			try {
+				new Intl.NumberFormat("en-US", { style: "currency" });
+			} catch (e) {
+				invalid = e.name + " " + e.code;
+			}
+
+			[
+				usd.format(1234567.891), usd.format(-0.5), eur.format(1234.5), yen.format(1234.5),
+				digits.format(3), digits.format(3.14159), percent.format(0.256), plain.format(12345.6789),
+				us.format(date), gb.format(date), full.format(date), time.format(date),
+				us.resolvedOptions().timeZone, invalid,
+			].join("|");
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.False(t, result.IsError, text)
+	assert.Contains(t, text, "Result: $1,234,567.89|-$0.50|1.234,50\u00a0€|¥1,235|3.0|3.14|26%|12345.679|"+
+		"1/5/2024|5 January 2024|Friday, January 5, 2024, 3:04 PM|15:04:09|UTC|TypeError ERR_INVALID_ARGUMENT")
+}
+
+func TestEncoding_TextDecoderStreamSplitCharacter(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const bytes = new TextEncoderStream().transform("héllo €uro 👋");
+			// Split inside "é", "€" and "👋"
+			const chunks = [bytes.slice(0, 2), bytes.slice(2, 9), bytes.slice(9, 16), bytes.slice(16)];
+
+			const decoder = new TextDecoderStream();
+			const parts = chunks.map((chunk) => decoder.transform(chunk));
+			const text = parts.join("") + decoder.flush();
+
+			const truncated = new TextDecoderStream();
+			const cut = truncated.transform(new Uint8Array([0x61, 0xe2, 0x82])) + truncated.flush();
+
+			[text, parts[0], cut === "a�"].join("|");
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.False(t, result.IsError, text)
+	assert.Contains(t, text, "Result: héllo €uro 👋|h|true")
+}
+
+func TestEncoding_TextStreamsReadableAndWritable(t *testing.T) {
+	handler := NewJSHandler()
+
+	text := runCode(t, handler, `
+		(async () => {
+			const encoder = new TextEncoderStream();
+			const bytes = encoder.readable.getReader();
+			const input = encoder.writable.getWriter();
+			input.write("h€");
+			input.close();
+			const { value } = await bytes.read();
+
+			// Split inside "€", then piped through a decoder
+			const source = new TextDecoderStream();
+			const writer = source.writable.getWriter();
+			writer.write(value.slice(0, 2));
+			writer.write(value.slice(2));
+			writer.close();
+			const encoded = source.readable.pipeThrough(new TextEncoderStream());
+			const reader = encoded.pipeThrough(new TextDecoderStream()).getReader();
+			let out = "";
+			while (true) {
+				const { value, done } = await reader.read();
+				if (done) break;
+				out += value;
+			}
+			console.log("piped:", out, (await bytes.read()).done, encoded.locked);
+
+			const lines = new TextEncoderStream();
+			const lineWriter = lines.writable.getWriter();
+			lineWriter.write('{"a":1}\n{"a":');
+			lineWriter.write('2}\n');
+			lineWriter.close();
+			const values = require('ndjson').parseStream(lines.readable.pipeThrough(new TextDecoderStream())).getReader();
+			const sum = (await values.read()).value.a + (await values.read()).value.a;
+			console.log("ndjson:", sum, (await values.read()).done);
+		})();
+	`)
+	assert.Contains(t, text, "piped: h€ true true")
+	assert.Contains(t, text, "ndjson: 3 true")
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModuleErrors_CodeAndModule(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const hashing = require('crypto');
+			const describe = (fn) => {
+				try {
+					fn();
+				} catch (e) {
+					return [e.name, e.module, e.code].join("/");
+				}
+				return "no error";
+			};
+			console.log("crypto:", describe(() => hashing.hmac("whirlpool", "key", "data")));
+			console.log("subtle:", describe(() => crypto.subtle.digest("MD5", new Uint8Array([1]))));
+			console.log("fetch:", describe(() => fetch()));
+			console.log("onExit:", describe(() => runtime.onExit("later")));
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "crypto: TypeError/crypto/ERR_NOT_SUPPORTED")
+	assert.Contains(t, text, "subtle: NotSupportedError/crypto/ERR_NOT_SUPPORTED")
+	assert.Contains(t, text, "fetch: TypeError/fetch/ERR_INVALID_ARGUMENT")
+	assert.Contains(t, text, "onExit: TypeError/runtime/ERR_INVALID_ARGUMENT")
+}
+
+func TestModuleErrors_ViewOutOfBounds(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"cache", "buffer", "crypto", "fetch", "encoding", "wasm", "ndjson"},
+	})
+
+	text := runCode(t, handler, `
+		const describe = (fn) => {
+			try {
+				fn();
+			} catch (e) {
+				return [e.name, e.module, e.code].join("/");
+			}
+			return "no error";
+		};
+		// A plain object posing as a view of bytes past the end of its buffer
+		const bad = { buffer: new ArrayBuffer(4), byteOffset: 2, byteLength: 100 };
+		console.log("cache:", describe(() => require('cache').setBytes("k", bad)));
+		console.log("buffer:", describe(() => new Blob([bad])));
+		console.log("crypto:", describe(() => require('crypto').md5(bad)));
+		console.log("fetch:", describe(() => fetch("http://127.0.0.1:1", { method: "POST", body: bad })));
+		console.log("encoding:", describe(() => require('encoding').toHex(bad)));
+		console.log("wasm:", describe(() => WebAssembly.validate(bad)));
+		console.log("negative:", describe(() => require('encoding').toHex({ buffer: new ArrayBuffer(4), byteOffset: -1, byteLength: 1 })));
+		const source = { getReader: () => ({ read: () => Promise.resolve({ value: bad, done: false }) }) };
+		require('ndjson').parseStream(source).getReader().read().catch((e) => {
+			console.log("ndjson:", [e.name, e.module, e.code].join("/"));
+		});
+		console.log("valid:", require('encoding').toHex(new Uint8Array([1, 2, 3]).subarray(1)));
+	`)
+	for _, module := range []string{"cache", "buffer", "crypto", "fetch", "encoding", "wasm", "ndjson"} {
+		assert.Contains(t, text, module+": RangeError/"+module+"/ERR_INVALID_ARGUMENT")
+	}
+	assert.Contains(t, text, "negative: RangeError/encoding/ERR_INVALID_ARGUMENT")
+	assert.Contains(t, text, "valid: 0203")
+}
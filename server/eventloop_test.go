@@ -0,0 +1,217 @@
+package server
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/codebench-mcp/internal/logger"
+	"github.com/mark3labs/codebench-mcp/server/modules/timers"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventLoopDiagnostics_PendingCount(t *testing.T) {
+	logger.DebugEnabled = true
+	t.Cleanup(func() { logger.DebugEnabled = false })
+
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			setTimeout(() => {}, 10);
+			console.log("enqueue:", __eventloop.enqueue);
+			__eventloop.pending;
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "enqueue: 1")
+	assert.Contains(t, text, "Result: 1")
+}
+
+func TestEventLoopDiagnostics_DisabledWithoutDebug(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `typeof __eventloop;`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: undefined")
+}
+
+func TestEventLoop_CancelWithPendingTimers(t *testing.T) {
+	manager := vm.NewVMManager([]string{"timers"})
+	manager.RegisterModule(timers.NewTimersModule())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	instance, err := manager.CreateVM(ctx)
+	require.NoError(t, err)
+	defer instance.Close()
+
+	errChan := make(chan error, 1)
+	go func() {
+		_, err := instance.RunString(`
+			setTimeout(() => {}, 60000);
+			setInterval(() => {}, 10);
+		`)
+		errChan <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errChan:
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(2 * time.Second):
+		t.Fatal("event loop did not terminate after context cancellation")
+	}
+}
+
+func TestEventLoop_CancelBeforeRun(t *testing.T) {
+	manager := vm.NewVMManager([]string{"timers"})
+	manager.RegisterModule(timers.NewTimersModule())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	instance, err := manager.CreateVM(ctx)
+	require.NoError(t, err)
+	defer instance.Close()
+
+	errChan := make(chan error, 1)
+	go func() {
+		_, err := instance.RunString(`setTimeout(() => {}, 60000);`)
+		errChan <- err
+	}()
+
+	select {
+	case err := <-errChan:
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(2 * time.Second):
+		t.Fatal("event loop did not terminate for an already cancelled context")
+	}
+}
+
+func TestVM_TimingBreakdown(t *testing.T) {
+	manager := vm.NewVMManager([]string{"timers"})
+	manager.RegisterModule(timers.NewTimersModule())
+
+	instance, err := manager.CreateVM(context.Background())
+	require.NoError(t, err)
+	defer instance.Close()
+
+	_, err = instance.RunString(`
+		let sum = 0;
+		for (let i = 0; i < 1000; i++) sum += i;
+		setTimeout(() => {}, 20);
+	`)
+	require.NoError(t, err)
+
+	timings := instance.Timings()
+	assert.GreaterOrEqual(t, timings.Compile, time.Duration(0))
+	assert.GreaterOrEqual(t, timings.Run, time.Duration(0))
+	assert.GreaterOrEqual(t, timings.Drain, 20*time.Millisecond)
+}
+
+func TestVM_TimingBreakdownReportedInDebugMode(t *testing.T) {
+	logger.DebugEnabled = true
+	t.Cleanup(func() { logger.DebugEnabled = false })
+
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `1 + 1;`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Regexp(t, `Timings: compile=\S+ run=\S+ drain=\S+`, text)
+}
+
+func TestVM_CloseStopsPendingTimers(t *testing.T) {
+	manager := vm.NewVMManager([]string{"timers"})
+	manager.RegisterModule(timers.NewTimersModule())
+
+	before := runtime.NumGoroutine()
+
+	instance, err := manager.CreateVM(context.Background())
+	require.NoError(t, err)
+
+	// Create timers without running the event loop, so nothing but Close
+	// stops them
+	_, err = instance.Runtime().RunString(`
+		for (let i = 0; i < 20; i++) {
+			setTimeout(() => {}, 60000);
+			setInterval(() => {}, 60000);
+		}
+	`)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, runtime.NumGoroutine(), before+40)
+
+	require.NoError(t, instance.Close())
+
+	// Poll without assert.Eventually, whose condition runs in a goroutine of
+	// its own that would be counted too
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
+
+func TestVM_ExitHooksRunOnClose(t *testing.T) {
+	manager := vm.NewVMManager([]string{"timers"})
+	manager.RegisterModule(timers.NewTimersModule())
+
+	instance, err := manager.CreateVM(context.Background())
+	require.NoError(t, err)
+
+	// The interval keeps the event loop running, like a background server
+	done := make(chan error, 1)
+	go func() {
+		_, err := instance.RunString(`
+			globalThis.calls = [];
+			const id = setInterval(() => {}, 10);
+			runtime.onExit(() => { calls.push("first"); clearInterval(id); });
+			runtime.onExit(() => calls.push("second"));
+		`)
+		done <- err
+	}()
+
+	// Exit hooks wait for the VM to close, not for the script to finish
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("the event loop finished before Close")
+	default:
+	}
+
+	require.NoError(t, instance.Close())
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, vm.ErrClosed)
+	case <-time.After(2 * time.Second):
+		t.Fatal("the event loop didn't stop on Close")
+	}
+	assert.Equal(t, []any{"first", "second"}, instance.Runtime().Get("calls").Export())
+}
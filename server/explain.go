@@ -0,0 +1,196 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/grafana/sobek/ast"
+	"github.com/grafana/sobek/parser"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// globalModules maps the globals defined by modules to the module defining them
+var globalModules = map[string]string{
+	"fetch":             "fetch",
+	"Request":           "fetch",
+	"Response":          "fetch",
+	"Headers":           "fetch",
+	"FormData":          "fetch",
+	"AbortController":   "fetch",
+	"AbortSignal":       "fetch",
+	"sendBeacon":        "fetch",
+	"setTimeout":        "timers",
+	"setInterval":       "timers",
+	"clearTimeout":      "timers",
+	"clearInterval":     "timers",
+	"performance":       "timers",
+	"process":           "timers",
+	"Buffer":            "buffer",
+	"Blob":              "buffer",
+	"File":              "buffer",
+	"kv":                "kv",
+	"crypto":            "crypto",
+	"TextEncoder":       "encoding",
+	"TextDecoder":       "encoding",
+	"TextEncoderStream": "encoding",
+	"TextDecoderStream": "encoding",
+	"structuredClone":   "encoding",
+	"JSON5":             "encoding",
+	"Intl":              "encoding",
+	"URL":               "url",
+	"URLSearchParams":   "url",
+	"Worker":            "worker",
+	"WebAssembly":       "wasm",
+	"console":           "console",
+}
+
+// requireModules maps require() names that differ from the module name
+var requireModules = map[string]string{
+	"http/server": "http",
+}
+
+// scriptUsage is what explainJS reports about a script
+type scriptUsage struct {
+	Modules  []string `json:"modules"`  // Modules the script references
+	Globals  []string `json:"globals"`  // Module globals the script references
+	Requires []string `json:"requires"` // Names passed to require() as string literals
+	Disabled []string `json:"disabled"` // Referenced modules that are not enabled
+}
+
+// explainScript parses code and collects the modules it references through
+// require() calls with a literal name and module globals, without running it.
+// The scan is conservative: a local variable shadowing a global still counts.
+func explainScript(code string, enabledModules []string) (scriptUsage, error) {
+	program, err := parser.ParseFile(nil, "", code, 0)
+	if err != nil {
+		return scriptUsage{}, err
+	}
+
+	modules := make(map[string]bool)
+	globals := make(map[string]bool)
+	requires := make(map[string]bool)
+	walkAST(reflect.ValueOf(program), func(node any) {
+		switch n := node.(type) {
+		case *ast.CallExpression:
+			callee, ok := n.Callee.(*ast.Identifier)
+			if !ok || callee.Name != "require" || len(n.ArgumentList) == 0 {
+				return
+			}
+			if name, ok := n.ArgumentList[0].(*ast.StringLiteral); ok {
+				requires[name.Value.String()] = true
+				module := name.Value.String()
+				if alias, ok := requireModules[module]; ok {
+					module = alias
+				}
+				modules[module] = true
+			}
+		case *ast.Identifier:
+			if module, ok := globalModules[n.Name.String()]; ok {
+				globals[n.Name.String()] = true
+				modules[module] = true
+			}
+		}
+	})
+
+	enabled := make(map[string]bool)
+	for _, module := range enabledModules {
+		enabled[module] = true
+	}
+	usage := scriptUsage{
+		Modules:  sortedKeys(modules),
+		Globals:  sortedKeys(globals),
+		Requires: sortedKeys(requires),
+		Disabled: []string{},
+	}
+	for _, module := range usage.Modules {
+		// console is provided to every execution
+		if module != "console" && !enabled[module] {
+			usage.Disabled = append(usage.Disabled, module)
+		}
+	}
+	return usage, nil
+}
+
+// astPackage is the import path of the AST types, the only ones walkAST
+// descends into
+var astPackage = reflect.TypeOf(ast.Program{}).PkgPath()
+
+// walkAST calls visit with every AST node reachable from v. The AST has no
+// visitor, so the walk follows the struct fields, slices and interfaces of
+// the AST types by reflection.
+func walkAST(v reflect.Value, visit func(node any)) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if !v.IsNil() {
+			walkAST(v.Elem(), visit)
+		}
+	case reflect.Pointer:
+		if v.IsNil() || v.Elem().Kind() != reflect.Struct || v.Elem().Type().PkgPath() != astPackage {
+			return
+		}
+		visit(v.Interface())
+		walkAST(v.Elem(), visit)
+	case reflect.Struct:
+		if v.Type().PkgPath() != astPackage {
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				walkAST(v.Field(i), visit)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			walkAST(v.Index(i), visit)
+		}
+	}
+}
+
+// sortedKeys returns the keys of set in order, never nil so they encode as []
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (h *JSHandler) handleExplainJS(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	code, err := request.RequireString("code")
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := explainScript(code, h.getAvailableModules())
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Syntax error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	data, err := json.MarshalIndent(usage, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: string(data),
+			},
+		},
+		StructuredContent: usage,
+	}, nil
+}
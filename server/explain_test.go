@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainJS_ReportsReferencedModules(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{EnabledModules: []string{"fetch"}})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "explainJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const crypto = require('crypto');
+			async function load(url) {
+				const response = await fetch(url);
+				return crypto.sha256(await response.text()).hex();
+			}
+			// Not called, but still referenced
+			const obj = { setTimeout: 1 };
+			obj.kv;
+		`,
+	}
+
+	result, err := handler.handleExplainJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	usage := result.StructuredContent.(scriptUsage)
+	assert.Equal(t, []string{"crypto", "fetch"}, usage.Modules)
+	assert.Equal(t, []string{"crypto"}, usage.Requires)
+	assert.Equal(t, []string{"crypto"}, usage.Disabled)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, `"fetch"`)
+
+	request.Params.Arguments = map[string]any{"code": "const = 1;"}
+	result, err = handler.handleExplainJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Syntax error")
+}
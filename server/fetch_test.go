@@ -0,0 +1,597 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/codebench-mcp/server/modules/fetch"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetch_AbortSignalTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(5 * time.Second):
+			fmt.Fprint(w, "too slow")
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": fmt.Sprintf(`
+			const signal = AbortSignal.timeout(100);
+			signal.addEventListener('abort', () => console.log("abort event fired"));
+			const start = Date.now();
+			try {
+				fetch(%q, { signal });
+				console.log("not aborted");
+			} catch (e) {
+				console.log("error name:", e.name);
+				console.log("aborted:", signal.aborted);
+				console.log("fast:", Date.now() - start < 2000);
+			}
+			setTimeout(() => {}, 50);
+		`, ts.URL),
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "error name: TimeoutError")
+	assert.Contains(t, text, "aborted: true")
+	assert.Contains(t, text, "fast: true")
+	assert.Contains(t, text, "abort event fired")
+	assert.NotContains(t, text, "not aborted")
+}
+
+func TestFetch_AbortControllerAbortsBeforeRequest(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const controller = new AbortController();
+			controller.signal.onabort = () => console.log("onabort called");
+			controller.abort();
+			try {
+				fetch("http://127.0.0.1:1/", { signal: controller.signal });
+			} catch (e) {
+				console.log("error name:", e.name);
+			}
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "onabort called")
+	assert.Contains(t, text, "error name: AbortError")
+}
+
+func TestFetch_CacheControlMaxAge(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		if r.URL.Path == "/no-store" {
+			w.Header().Set("Cache-Control", "no-store")
+		} else {
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		}
+		fmt.Fprintf(w, "response %d", n)
+	}))
+	defer ts.Close()
+
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"fetch"},
+		FetchCache:     true,
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": fmt.Sprintf(`
+			const first = fetch(%[1]q).text();
+			const second = fetch(%[1]q).text();
+			console.log("first:", first);
+			console.log("second:", second);
+			fetch(%[1]q + "/no-store");
+			fetch(%[1]q + "/no-store");
+		`, ts.URL),
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "first: response 1")
+	assert.Contains(t, text, "second: response 1")
+	// One request for the cached URL and two for the no-store URL
+	assert.Equal(t, int32(3), hits.Load())
+}
+
+func TestFetch_CacheSkipsCredentialsAndPrivate(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		if r.URL.Path == "/private" {
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		} else {
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		fmt.Fprintf(w, "response %d for %q", n, r.Header.Get("Authorization"))
+	}))
+	defer ts.Close()
+
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"fetch", "cache"},
+		FetchCache:     true,
+		SharedCache:    true,
+	})
+
+	// Responses to credentialed requests are neither stored nor served
+	text := runCode(t, handler, fmt.Sprintf(`
+		const auth = (token) => ({ headers: { Authorization: token } });
+		console.log("alice:", fetch(%[1]q, auth("alice")).text());
+		console.log("bob:", fetch(%[1]q, auth("bob")).text());
+		console.log("cookie:", fetch(%[1]q, { headers: { Cookie: "id=1" } }).text());
+		fetch(%[1]q + "/private");
+		fetch(%[1]q + "/private");
+	`, ts.URL))
+	assert.Contains(t, text, `alice: response 1 for "alice"`)
+	assert.Contains(t, text, `bob: response 2 for "bob"`)
+	assert.Contains(t, text, `cookie: response 3`)
+	assert.Equal(t, int32(5), hits.Load())
+
+	// The cache module can't plant responses for fetch to serve
+	text = runCode(t, handler, fmt.Sprintf(`
+		const cache = require('cache');
+		const planted = JSON.stringify({ status: 200, url: %[1]q, header: {}, body: "cGxhbnRlZA==" });
+		cache.set("fetch:GET " + %[1]q, planted, 60);
+		console.log("fetched:", fetch(%[1]q).text());
+	`, ts.URL))
+	assert.Contains(t, text, "fetched: response 6")
+}
+
+func TestFetch_CacheDisabledByDefault(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprint(w, "ok")
+	}))
+	defer ts.Close()
+
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": fmt.Sprintf(`fetch(%[1]q); fetch(%[1]q); "done";`, ts.URL),
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Equal(t, int32(2), hits.Load())
+}
+
+func TestFetch_DefaultHeaders(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s|%s", r.Header.Get("User-Agent"), r.Header.Get("X-Trace-Id"))
+	}))
+	defer ts.Close()
+
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"fetch"},
+		FetchHeaders:   map[string]string{"X-Trace-Id": "trace-123"},
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": fmt.Sprintf(`
+			console.log("default:", fetch(%[1]q).text());
+			console.log("override:", fetch(%[1]q, { headers: { "user-agent": "custom/1.0", "x-trace-id": "mine" } }).text());
+		`, ts.URL),
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "default: codebench-mcp/"+Version+"|trace-123")
+	assert.Contains(t, text, "override: custom/1.0|mine")
+}
+
+func TestFetch_SafeMethodsOnly(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		fmt.Fprint(w, r.Method)
+	}))
+	defer ts.Close()
+
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules:       []string{"fetch"},
+		FetchSafeMethodsOnly: true,
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": fmt.Sprintf(`
+			console.log("get:", fetch(%[1]q).text());
+			try {
+				fetch(%[1]q, { method: "POST", body: "x" });
+				console.log("post allowed");
+			} catch (e) {
+				console.log("post rejected:", e.name);
+			}
+		`, ts.URL),
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "get: GET")
+	assert.Contains(t, text, "post rejected: TypeError")
+	assert.Equal(t, int32(1), hits.Load())
+}
+
+func TestFetch_TimeoutFromModuleOptions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(5 * time.Second):
+			fmt.Fprint(w, "too slow")
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"fetch"},
+		ModuleOptions: map[string]any{
+			"fetch": map[string]any{"timeout": "100ms"},
+		},
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": fmt.Sprintf(`
+			const start = Date.now();
+			try {
+				fetch(%q);
+				console.log("not timed out");
+			} catch (e) {
+				console.log("error code:", e.code);
+				console.log("fast:", Date.now() - start < 2000);
+			}
+		`, ts.URL),
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "error code: ERR_NETWORK")
+	assert.Contains(t, text, "fast: true")
+	assert.NotContains(t, text, "not timed out")
+}
+
+func TestFetch_MockResponses(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		fmt.Fprint(w, "real")
+	}))
+	defer ts.Close()
+
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"fetch"},
+		FetchMocks: map[string]fetch.MockResponse{
+			ts.URL + "/users": {
+				Headers: map[string]string{"Content-Type": "application/json"},
+				Body:    `{"name":"ada"}`,
+			},
+			"POST " + ts.URL + "/users": {Status: 201, Body: "created"},
+		},
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": fmt.Sprintf(`
+			const res = fetch(%[1]q + "/users");
+			console.log("get:", res.status, res.headers.get("content-type"), JSON.parse(res.text()).name);
+			const created = fetch(%[1]q + "/users", { method: "POST", body: "{}" });
+			console.log("post:", created.status, created.text());
+			try {
+				fetch(%[1]q + "/other");
+				console.log("unmocked allowed");
+			} catch (e) {
+				console.log("unmocked:", e.code);
+			}
+		`, ts.URL),
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "get: 200 application/json ada")
+	assert.Contains(t, text, "post: 201 created")
+	assert.Contains(t, text, "unmocked: ERR_NETWORK")
+	assert.Equal(t, int32(0), hits.Load())
+}
+
+func TestFetch_RequestObject(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s %s", r.Method, r.Header.Get("Content-Type"), body)
+	}))
+	defer ts.Close()
+
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": fmt.Sprintf(`
+			const req = new Request(%q, {
+				method: "post",
+				headers: { "Content-Type": "application/json" },
+				body: JSON.stringify({ name: "codebench" }),
+			});
+			console.log("method:", req.method);
+			console.log("content-type:", req.headers.get("content-type"));
+			req.json().then(data => console.log("json name:", data.name));
+			req.text().then(text => console.log("text:", text));
+
+			const res = fetch(req);
+			console.log("response:", res.text());
+
+			// Options passed to fetch override the Request
+			console.log("override:", fetch(req, { method: "PUT" }).text());
+		`, ts.URL),
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "method: POST")
+	assert.Contains(t, text, "content-type: application/json")
+	assert.Contains(t, text, "json name: codebench")
+	assert.Contains(t, text, `text: {"name":"codebench"}`)
+	assert.Contains(t, text, `response: POST application/json {"name":"codebench"}`)
+	assert.Contains(t, text, `override: PUT application/json {"name":"codebench"}`)
+}
+
+func TestFetch_RequestRejectsGetWithBody(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			let name;
+			try { new Request("http://example.com", { body: "x" }); } catch (e) { name = e.name; }
+			name;
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: TypeError")
+}
+
+func TestFetch_RepeatedRequestHeaders(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%q", r.Header.Values("Accept"))
+	}))
+	defer ts.Close()
+
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": fmt.Sprintf(`
+			const pairs = [["Accept", "text/html"], ["Accept", "application/json"]];
+			console.log("pairs:", fetch(%[1]q, { headers: pairs }).text());
+
+			// The headers of a Request keep both values when passed on
+			const req = new Request(%[1]q, { headers: pairs });
+			console.log("headers:", fetch(%[1]q, { headers: req.headers }).text());
+			console.log("request:", fetch(req).text());
+		`, ts.URL),
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, `pairs: ["text/html" "application/json"]`)
+	assert.Contains(t, text, `headers: ["text/html" "application/json"]`)
+	assert.Contains(t, text, `request: ["text/html" "application/json"]`)
+}
+
+func TestFetch_ResponseFormData(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/form":
+			w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+			fmt.Fprint(w, "name=code+bench&tag=a&tag=b")
+		case "/multipart":
+			w.Header().Set("Content-Type", "multipart/form-data; boundary=XYZ")
+			fmt.Fprint(w, "--XYZ\r\nContent-Disposition: form-data; name=\"title\"\r\n\r\nreport\r\n"+
+				"--XYZ\r\nContent-Disposition: form-data; name=\"file\"; filename=\"a.txt\"\r\nContent-Type: text/plain\r\n\r\nhello\r\n--XYZ--\r\n")
+		default:
+			fmt.Fprint(w, "plain")
+		}
+	}))
+	defer ts.Close()
+
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": fmt.Sprintf(`
+			(async () => {
+				const form = await fetch(%[1]q + "/form").formData();
+				console.log("form:", form.get("name"), JSON.stringify(form.getAll("tag")));
+
+				const multipart = await fetch(%[1]q + "/multipart").formData();
+				const file = multipart.get("file");
+				console.log("multipart:", multipart.get("title"), file.name, await file.text());
+
+				try {
+					await fetch(%[1]q + "/plain").formData();
+				} catch (e) {
+					console.log("plain:", e.name, e.code);
+				}
+			})();
+		`, ts.URL),
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, `form: code bench ["a","b"]`)
+	assert.Contains(t, text, "multipart: report a.txt hello")
+	assert.Contains(t, text, "plain: TypeError ERR_NOT_SUPPORTED")
+}
+
+func TestFetch_MaxResponseSize(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chunk := make([]byte, 512)
+		switch r.URL.Path {
+		case "/small":
+			w.Write(chunk)
+		case "/sized":
+			w.Header().Set("Content-Length", "4096")
+			for i := 0; i < 8; i++ {
+				w.Write(chunk)
+			}
+		default:
+			// Streamed without a Content-Length
+			for i := 0; i < 8; i++ {
+				w.Write(chunk)
+				w.(http.Flusher).Flush()
+			}
+		}
+	}))
+	defer ts.Close()
+
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules:       []string{"fetch"},
+		FetchMaxResponseSize: 1024,
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": fmt.Sprintf(`
+			console.log("small:", fetch(%[1]q + "/small").text().length);
+			for (const path of ["/sized", "/streamed"]) {
+				try {
+					fetch(%[1]q + path);
+				} catch (e) {
+					console.log(path + ":", e.name, e.code, e.message);
+				}
+			}
+		`, ts.URL),
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "small: 512")
+	assert.Contains(t, text, "/sized: RangeError ERR_QUOTA_EXCEEDED fetch: response too large, the body exceeds 1024 bytes")
+	assert.Contains(t, text, "/streamed: RangeError ERR_QUOTA_EXCEEDED fetch: response too large")
+}
+
+func TestFetch_SendBeacon(t *testing.T) {
+	var received atomic.Value
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Slow enough that the script finishes well before the response
+		time.Sleep(200 * time.Millisecond)
+		body, _ := io.ReadAll(r.Body)
+		received.Store(r.Method + " " + r.Header.Get("Content-Type") + " " + string(body))
+	}))
+	defer ts.Close()
+
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": fmt.Sprintf(`
+			const queued = sendBeacon(%q, JSON.stringify({ event: "done" }));
+			console.log("queued:", queued);
+		`, ts.URL),
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "queued: true")
+	assert.Equal(t, `POST text/plain;charset=UTF-8 {"event":"done"}`, received.Load())
+}
+
+func TestFetch_ResponseCharset(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=ISO-8859-1")
+		w.Write([]byte("caf\xe9"))
+	}))
+	defer ts.Close()
+
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": fmt.Sprintf(`
+			const res = fetch(%q);
+			console.log("text:", res.text());
+			const raw = res.bytes();
+			console.log("bytes:", raw instanceof Uint8Array, raw.length, raw[3]);
+			console.log("decoder:", new TextDecoder("latin1").decode(raw));
+			try {
+				new TextDecoder("no-such-charset");
+			} catch (e) {
+				console.log("unknown:", e.name);
+			}
+		`, ts.URL),
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "text: café")
+	assert.Contains(t, text, "bytes: true 4 233")
+	assert.Contains(t, text, "decoder: café")
+	assert.Contains(t, text, "unknown: RangeError")
+}
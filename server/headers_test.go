@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaders_FetchResponseIteration(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Beta", "b")
+		w.Header().Add("X-Alpha", "1")
+		w.Header().Add("X-Alpha", "2")
+		fmt.Fprint(w, "ok")
+	}))
+	defer ts.Close()
+
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": fmt.Sprintf(`
+			const res = fetch(%q);
+			const entries = [];
+			for (const [k, v] of res.headers) {
+				if (k.startsWith("x-")) entries.push(k + "=" + v);
+			}
+			console.log("entries:", entries.join(";"));
+
+			const seen = [];
+			res.headers.forEach((v, k) => { if (k.startsWith("x-")) seen.push(k); });
+			console.log("forEach:", seen.join(","));
+
+			console.log("keys:", [...res.headers.keys()].filter(k => k.startsWith("x-")).join(","));
+			console.log("values:", [...res.headers.values()].includes("b"));
+			console.log("get:", res.headers.get("x-alpha"), res.headers.get("missing"));
+			console.log("has:", res.headers.has("X-BETA"));
+			console.log("prop:", res.headers["X-Beta"]);
+		`, ts.URL),
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "entries: x-alpha=1, 2;x-beta=b")
+	assert.Contains(t, text, "forEach: x-alpha,x-beta")
+	assert.Contains(t, text, "keys: x-alpha,x-beta")
+	assert.Contains(t, text, "values: true")
+	assert.Contains(t, text, "get: 1, 2 <nil>")
+	assert.Contains(t, text, "has: true")
+	assert.Contains(t, text, "prop: b")
+}
+
+func TestHeaders_ServerRequestIteration(t *testing.T) {
+	url := serveScript(t, `(req) => {
+		const names = [];
+		for (const [k, v] of req.headers.entries()) {
+			if (k.startsWith("x-")) names.push(k + "=" + v);
+		}
+		return { status: 200, body: names.join(";") };
+	}`)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Test", "value")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "x-test=value", string(body))
+}
+
+func TestHeaders_Class(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Set-Cookie", "a=1")
+		w.Header().Add("Set-Cookie", "b=2")
+		fmt.Fprintf(w, "%q %q", r.Header.Values("Accept"), r.Header.Get("X-Token"))
+	}))
+	defer ts.Close()
+
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": fmt.Sprintf(`
+			const h = new Headers({ "Content-Type": "text/plain" });
+			h.append("accept", "text/html");
+			h.append("ACCEPT", "application/json");
+			h.set("X-Token", "secret");
+			console.log("get:", h.get("Accept"), h.has("content-type"));
+			h.delete("content-type");
+			console.log("deleted:", h.has("Content-Type"), h.get("content-type"));
+			console.log("entries:", JSON.stringify([...h.entries()]));
+			const seen = [];
+			h.forEach((v, k) => seen.push(k));
+			console.log("forEach:", seen.join(","));
+			console.log("copy:", new Headers(h).get("accept"));
+
+			const res = fetch(%q, { headers: h });
+			console.log("sent:", res.text());
+			console.log("instanceof:", res.headers instanceof Headers, h instanceof Headers);
+			console.log("cookies:", JSON.stringify(res.headers.getSetCookie()));
+			console.log("cookie entries:", [...res.headers].filter(([k]) => k === "set-cookie").length);
+			try {
+				res.headers.set("x-other", "1");
+			} catch (e) {
+				console.log("immutable:", e.name, e.code);
+			}
+			try {
+				h.append("bad name", "1");
+			} catch (e) {
+				console.log("invalid:", e.name);
+			}
+		`, ts.URL),
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "get: text/html, application/json true")
+	assert.Contains(t, text, "deleted: false <nil>")
+	assert.Contains(t, text, `entries: [["accept","text/html, application/json"],["x-token","secret"]]`)
+	assert.Contains(t, text, "forEach: accept,x-token")
+	assert.Contains(t, text, "copy: text/html, application/json")
+	assert.Contains(t, text, `sent: ["text/html" "application/json"] "secret"`)
+	assert.Contains(t, text, "instanceof: true true")
+	assert.Contains(t, text, `cookies: ["a=1","b=2"]`)
+	assert.Contains(t, text, "cookie entries: 2")
+	assert.Contains(t, text, "immutable: TypeError ERR_NOT_SUPPORTED")
+	assert.Contains(t, text, "invalid: TypeError")
+}
+
+func TestHeaders_ServerResponseRepeatedSetCookie(t *testing.T) {
+	url := serveScript(t, `(req) => {
+		const h = new Headers();
+		h.append("Set-Cookie", "a=1");
+		h.append("Set-Cookie", "b=2");
+		h.set("X-Single", "one");
+		return new Response("x", { headers: h });
+	}`)
+
+	resp, body := get(t, url)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "x", body)
+	assert.Equal(t, []string{"a=1", "b=2"}, resp.Header.Values("Set-Cookie"))
+	assert.Equal(t, "one", resp.Header.Get("X-Single"))
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLModule_QuerySelector(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"html"},
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const html = require('html');
+			const doc = html.parse(` + "`" + `
+				<title> Listing </title>
+				<ul id="items">
+					<li class="item"><a href="/a">First</a></li>
+					<li class="item sale"><a href="https://example.com/b">Second</a></li>
+				</ul>
+				<p>Footer</p>
+			` + "`" + `);
+			console.log("title:", doc.title);
+			console.log("sale:", doc.querySelector("ul#items > li.sale a").textContent);
+			console.log("external:", doc.querySelector('a[href^="https"]').getAttribute("href"));
+			console.log("links:", doc.getElementsByTagName("a").map((a) => a.textContent).join(","));
+			console.log("items:", doc.querySelectorAll(".item, p").length);
+			console.log("parent:", doc.querySelector("a").parentNode.tagName);
+			console.log("same:", doc.getElementById("items") === doc.querySelector("ul"));
+			console.log("missing:", doc.querySelector("table") === null);
+			try {
+				doc.querySelector("li:first-child");
+			} catch (e) {
+				console.log("error:", e.name, e.code);
+			}
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.False(t, result.IsError, text)
+	assert.Contains(t, text, "title: Listing")
+	assert.Contains(t, text, "sale: Second")
+	assert.Contains(t, text, "external: https://example.com/b")
+	assert.Contains(t, text, "links: First,Second")
+	assert.Contains(t, text, "items: 3")
+	assert.Contains(t, text, "parent: LI")
+	assert.Contains(t, text, "same: true")
+	assert.Contains(t, text, "missing: true")
+	assert.Contains(t, text, "error: SyntaxError ERR_INVALID_ARGUMENT")
+}
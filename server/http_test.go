@@ -0,0 +1,693 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/codebench-mcp/server/modules/encoding"
+	"github.com/mark3labs/codebench-mcp/server/modules/fetch"
+	httpmodule "github.com/mark3labs/codebench-mcp/server/modules/http"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveScript runs an http/server handler in a VM and returns the server URL.
+// The handler source is a function expression taking the request; the
+// server object is the global server.
+func serveScript(t *testing.T, handler string) string {
+	t.Helper()
+	return serveScriptWithOptions(t, "", handler)
+}
+
+// serveScriptWithOptions is serveScript with extra serve options, given as
+// object literal properties
+func serveScriptWithOptions(t *testing.T, options, handler string) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	require.NoError(t, ln.Close())
+
+	manager := vm.NewVMManager([]string{"http", "fetch", "encoding"})
+	manager.RegisterModule(httpmodule.NewHTTPModule())
+	manager.RegisterModule(fetch.NewFetchModule())
+	manager.RegisterModule(encoding.NewEncodingModule())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	instance, err := manager.CreateVM(ctx)
+	require.NoError(t, err)
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		instance.Close()
+	})
+
+	go func() {
+		defer close(done)
+		_, _ = instance.RunString(fmt.Sprintf(`
+			const serve = require('http/server');
+			globalThis.server = serve({ port: %d, hostname: "127.0.0.1", %s }, %s);
+		`, port, options, handler))
+	}()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d", port)
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}, 2*time.Second, 20*time.Millisecond)
+	return url
+}
+
+// get fetches url and returns the response with its body read
+func get(t *testing.T, url string) (*http.Response, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestResponseJSON_FromHandler(t *testing.T) {
+	url := serveScript(t, `(req) => Response.json({ ok: true }, { status: 201, headers: { "X-Extra": "1" } })`)
+
+	resp, body := get(t, url)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "1", resp.Header.Get("X-Extra"))
+	assert.JSONEq(t, `{"ok":true}`, body)
+}
+
+func TestResponse_InitFromHandler(t *testing.T) {
+	url := serveScript(t, `(req) => new Response("missing", { status: 404, statusText: "Not Here", headers: { "X-Reason": "nope" } })`)
+
+	resp, body := get(t, url)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "nope", resp.Header.Get("X-Reason"))
+	assert.Equal(t, "missing", body)
+}
+
+func TestResponse_InitProperties(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const res = new Response("x", { status: 404, statusText: "Not Found", headers: { "content-type": "text/plain" } });
+			const plain = new Response("y");
+			[res.status, res.statusText, res.ok, res.headers.get("Content-Type"), plain.status, plain.ok].join(",");
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: 404,Not Found,false,text/plain,200,true")
+}
+
+func TestRequestFormData_Multipart(t *testing.T) {
+	url := serveScript(t, `async (req) => {
+		const form = await req.formData();
+		const file = form.get("upload");
+		return new Response([form.get("title"), file.name, file.type, file.size, await file.text()].join("|"));
+	}`)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	require.NoError(t, writer.WriteField("title", "report"))
+	part, err := writer.CreateFormFile("upload", "notes.txt")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("file contents"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	resp, err := http.Post(url, writer.FormDataContentType(), &body)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	text, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "report|notes.txt|application/octet-stream|13|file contents", string(text))
+}
+
+func TestRequestFormData_URLEncoded(t *testing.T) {
+	url := serveScript(t, `async (req) => {
+		const form = await req.formData();
+		const names = [];
+		for (const [name, value] of form) names.push(name + "=" + value);
+		return new Response(names.join(";") + "|" + form.getAll("tag").length + "|" + form.has("missing"));
+	}`)
+
+	resp, err := http.Post(url, "application/x-www-form-urlencoded", strings.NewReader("q=a+b&tag=x&tag=y"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	text, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "q=a b;tag=x;tag=y|2|false", string(text))
+}
+
+func TestRequestFormData_UnsupportedContentType(t *testing.T) {
+	url := serveScript(t, `(req) => req.formData().then(
+		() => new Response("parsed"),
+		(e) => new Response(e.name + ":" + e.code),
+	)`)
+
+	resp, err := http.Post(url, "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	text, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "TypeError:ERR_NOT_SUPPORTED", string(text))
+}
+
+func TestServe_Compress(t *testing.T) {
+	url := serveScriptWithOptions(t, "compress: true", `(req) => {
+		const size = req.path === "/small" ? 10 : 4096;
+		return new Response("a".repeat(size), { headers: { "Content-Type": "text/plain" } });
+	}`)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", resp.Header.Get("Vary"))
+
+	reader, err := gzip.NewReader(resp.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("a", 4096), string(body))
+
+	// Small bodies are sent as is
+	req, err = http.NewRequest(http.MethodGet, url+"/small", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Encoding", "gzip")
+	small, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer small.Body.Close()
+	assert.Empty(t, small.Header.Get("Content-Encoding"))
+	body, err = io.ReadAll(small.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "aaaaaaaaaa", string(body))
+}
+
+func TestServe_AccessLog(t *testing.T) {
+	logs := captureLogger(t)
+	url := serveScriptWithOptions(t, "accessLog: true", `(req) => new Response("gone", { status: 410 })`)
+
+	resp, _ := get(t, url+"/old")
+	assert.Equal(t, http.StatusGone, resp.StatusCode)
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(logs.String(), "http request")
+	}, time.Second, 10*time.Millisecond)
+	line := logs.String()
+	assert.Contains(t, line, "method=GET")
+	assert.Contains(t, line, "path=/old")
+	assert.Contains(t, line, "status=410")
+	assert.Contains(t, line, "duration=")
+}
+
+func TestServe_CORSPreflight(t *testing.T) {
+	url := serveScriptWithOptions(t, `cors: { origins: ["https://app.example"], methods: ["get", "post"], headers: ["Content-Type"], credentials: true }`,
+		`(req) => new Response("handler ran")`)
+
+	req, err := http.NewRequest(http.MethodOptions, url, nil)
+	require.NoError(t, err)
+	req.Header.Set("Origin", "https://app.example")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Empty(t, body)
+	assert.Equal(t, "https://app.example", resp.Header.Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST", resp.Header.Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type", resp.Header.Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "true", resp.Header.Get("Access-Control-Allow-Credentials"))
+}
+
+func TestServe_CORSRequest(t *testing.T) {
+	url := serveScriptWithOptions(t, `cors: { origins: ["https://app.example"] }`, `(req) => new Response("ok")`)
+
+	request := func(origin string) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		req.Header.Set("Origin", origin)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	resp, body := request("https://app.example")
+	assert.Equal(t, "ok", body)
+	assert.Equal(t, "https://app.example", resp.Header.Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, resp.Header.Get("Access-Control-Allow-Credentials"))
+
+	resp, body = request("https://other.example")
+	assert.Equal(t, "ok", body)
+	assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+}
+
+func TestServe_RateLimit(t *testing.T) {
+	url := serveScriptWithOptions(t, "rateLimit: { perSecond: 1, burst: 3 }", `(req) => new Response("ok")`)
+
+	statuses := map[int]int{}
+	for i := 0; i < 10; i++ {
+		resp, _ := get(t, url)
+		statuses[resp.StatusCode]++
+		if resp.StatusCode == http.StatusTooManyRequests {
+			assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+		}
+	}
+	assert.GreaterOrEqual(t, statuses[http.StatusOK], 3)
+	assert.Positive(t, statuses[http.StatusTooManyRequests])
+}
+
+func TestServe_TLSSelfSigned(t *testing.T) {
+	url := serveScriptWithOptions(t, "tls: { selfSigned: true }", `(req) => new Response("secure " + req.path)`)
+	url = strings.Replace(url, "http://", "https://", 1)
+
+	// Trust the certificate the server generated
+	conn, err := tls.Dial("tcp", strings.TrimPrefix(url, "https://"), &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, err)
+	certs := conn.ConnectionState().PeerCertificates
+	require.NoError(t, conn.Close())
+	require.NotEmpty(t, certs)
+	pool := x509.NewCertPool()
+	pool.AddCert(certs[0])
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp, err := client.Get(url + "/hello")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "secure /hello", string(body))
+	require.NotNil(t, resp.TLS)
+}
+
+func TestServe_BareStringResult(t *testing.T) {
+	url := serveScript(t, `(req) => "hello " + req.path`)
+
+	resp, body := get(t, url+"/there")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "hello /there", body)
+}
+
+func TestServe_BareNumberResult(t *testing.T) {
+	url := serveScript(t, `async (req) => 42`)
+
+	resp, body := get(t, url)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "42", body)
+}
+
+func TestServe_UnusableResult(t *testing.T) {
+	url := serveScript(t, `(req) => undefined`)
+
+	resp, _ := get(t, url)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
+
+func TestServe_JSONResult(t *testing.T) {
+	url := serveScript(t, `(req) => {
+		if (req.path === "/list") return [1, 2, 3];
+		if (req.path === "/body") return { status: 201, body: { created: true } };
+		if (req.path === "/typed") return { status: 200, body: { ok: 1 }, headers: { "Content-Type": "application/vnd.api+json" } };
+		return { ok: true, path: req.path };
+	}`)
+
+	resp, body := get(t, url+"/item")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.JSONEq(t, `{"ok":true,"path":"/item"}`, body)
+
+	resp, body = get(t, url+"/list")
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "[1,2,3]", body)
+
+	resp, body = get(t, url+"/body")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.JSONEq(t, `{"created":true}`, body)
+
+	resp, body = get(t, url+"/typed")
+	assert.Equal(t, "application/vnd.api+json", resp.Header.Get("Content-Type"))
+	assert.JSONEq(t, `{"ok":1}`, body)
+}
+
+func TestServe_DataWithResponseKeys(t *testing.T) {
+	url := serveScript(t, `(req) => {
+		if (req.path === "/text") return { status: "ok", body: { n: 1 } };
+		if (req.path === "/range") return { status: 0, headers: { a: 1 } };
+		if (req.path === "/error") return Object.assign(new Error("bad"), { status: 42 });
+		return { body: "note", headers: ["x"] };
+	}`)
+
+	resp, body := get(t, url+"/text")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.JSONEq(t, `{"status":"ok","body":{"n":1}}`, body)
+
+	resp, body = get(t, url+"/range")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"status":0,"headers":{"a":1}}`, body)
+
+	resp, body = get(t, url+"/data")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"body":"note","headers":["x"]}`, body)
+
+	// A response that isn't plain data is never written with a bad status
+	resp, _ = get(t, url+"/error")
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
+
+func TestServe_InvalidTimeoutThrows(t *testing.T) {
+	manager := vm.NewVMManager([]string{"http"})
+	manager.RegisterModule(httpmodule.NewHTTPModule())
+
+	for _, option := range []string{"keepAliveTimeout: 0", "requestTimeout: -5", "responseTimeout: 'soon'", "maxHeaderSize: NaN"} {
+		instance, err := manager.CreateVM(context.Background())
+		require.NoError(t, err)
+
+		_, err = instance.RunString(fmt.Sprintf(`
+			const serve = require('http/server');
+			serve({ port: 0, hostname: "127.0.0.1", %s }, () => "ok");
+		`, option))
+		require.Error(t, err, option)
+		assert.Contains(t, err.Error(), "TypeError", option)
+		assert.Contains(t, err.Error(), "must be a positive number", option)
+		instance.Close()
+	}
+}
+
+func TestServe_RequestTimeoutApplied(t *testing.T) {
+	url := serveScriptWithOptions(t, "requestTimeout: 100, responseTimeout: 1000", `(req) => "ok"`)
+
+	// A client that never finishes its request headers is cut off
+	conn, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\n"))
+	require.NoError(t, err)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	start := time.Now()
+	_, err = io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+
+	_, body := get(t, url)
+	assert.Equal(t, "ok", body)
+}
+
+func TestServe_OnRequestHook(t *testing.T) {
+	url := serveScriptWithOptions(t, `onRequest: async (req) => {
+		if (req.headers.get("authorization") !== "Bearer secret") {
+			return new Response("unauthorized", { status: 401 });
+		}
+	}`, `(req) => "welcome"`)
+
+	resp, body := get(t, url)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "unauthorized", body)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	allowed, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "welcome", string(allowed))
+}
+
+func TestServe_Stats(t *testing.T) {
+	url := serveScript(t, `(req) => {
+		if (req.path === "/stats") return server.stats();
+		if (req.path === "/fail") throw new Error("boom");
+		return "ok";
+	}`)
+
+	for i := 0; i < 3; i++ {
+		resp, _ := get(t, url)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	resp, _ := get(t, url+"/fail")
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	// The stats request itself is counted but not yet timed
+	_, body := get(t, url+"/stats")
+	var stats struct {
+		Requests         int64   `json:"requests"`
+		Errors           int64   `json:"errors"`
+		AverageLatencyMs float64 `json:"averageLatencyMs"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &stats))
+	assert.Equal(t, int64(5), stats.Requests)
+	assert.Equal(t, int64(1), stats.Errors)
+	assert.Greater(t, stats.AverageLatencyMs, 0.0)
+}
+
+func TestServe_ConfiguredDefaultPort(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	require.NoError(t, ln.Close())
+
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules:  []string{"http"},
+		HTTPDefaultPort: port,
+	})
+	t.Cleanup(handler.Cleanup)
+
+	// The server keeps running in the background
+	runCode(t, handler, `
+		const serve = require('http/server');
+		serve(() => "default port");
+	`)
+	url := fmt.Sprintf("http://127.0.0.1:%d/", port)
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err == nil
+	}, 2*time.Second, 20*time.Millisecond)
+	_, body := get(t, url)
+	assert.Equal(t, "default port", body)
+
+	// With AnyPort the OS picks the port, which the script can read
+	manager := vm.NewVMManager([]string{"http"})
+	manager.RegisterModule(httpmodule.NewHTTPModuleWithOptions(httpmodule.Options{DefaultPort: httpmodule.AnyPort}))
+	instance, err := manager.CreateVM(context.Background())
+	require.NoError(t, err)
+	defer instance.Close()
+	_, err = instance.RunString(`
+		const serve = require('http/server');
+		const server = serve(() => "any port");
+		globalThis.port = server.port;
+		globalThis.url = server.url;
+		server.close();
+	`)
+	require.NoError(t, err)
+	picked := instance.Runtime().Get("port").ToInteger()
+	assert.Positive(t, picked)
+	assert.NotEqual(t, int64(httpmodule.DefaultPort), picked)
+	assert.Equal(t, fmt.Sprintf("http://127.0.0.1:%d", picked), instance.Runtime().Get("url").String())
+}
+
+func TestServe_LoopbackOnly(t *testing.T) {
+	manager := vm.NewVMManager([]string{"http"})
+	manager.RegisterModule(httpmodule.NewHTTPModuleWithOptions(httpmodule.Options{LoopbackOnly: true}))
+
+	instance, err := manager.CreateVM(context.Background())
+	require.NoError(t, err)
+	defer instance.Close()
+	_, err = instance.RunString(`
+		const serve = require('http/server');
+		try {
+			serve({ port: 0, hostname: "0.0.0.0" }, () => "exposed");
+			globalThis.rejected = "no";
+		} catch (e) {
+			globalThis.rejected = e.name + " " + e.code;
+		}
+		const server = serve({ port: 0, hostname: "localhost" }, () => "loopback");
+		globalThis.hostname = server.hostname;
+		server.close();
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, "TypeError ERR_NOT_SUPPORTED", instance.Runtime().Get("rejected").String())
+	assert.Equal(t, "localhost", instance.Runtime().Get("hostname").String())
+}
+
+func TestServe_HandlerTimeout(t *testing.T) {
+	url := serveScriptWithOptions(t, `handlerTimeout: 200`, `(req) => {
+		if (req.url.endsWith("/loop")) {
+			while (true) {}
+		}
+		if (req.url.endsWith("/hang")) {
+			return new Promise(() => {});
+		}
+		return new Response("ok");
+	}`)
+
+	start := time.Now()
+	resp, _ := get(t, url+"/loop")
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	resp, _ = get(t, url+"/hang")
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+
+	// The interrupted handler released the event loop
+	resp, body := get(t, url+"/ok")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ok", body)
+}
+
+func TestServe_StreamBody(t *testing.T) {
+	url := serveScriptWithOptions(t, `streamBody: true`, `async (req) => {
+		if (req.url.endsWith("/text")) {
+			return new Response("text: " + req.text().length);
+		}
+		const reader = req.body.getReader();
+		let total = 0, chunks = 0, largest = 0;
+		while (true) {
+			const { value, done } = await reader.read();
+			if (done) break;
+			total += value.byteLength;
+			largest = Math.max(largest, value.byteLength);
+			chunks++;
+		}
+		return new Response(total + " " + (chunks > 1) + " " + (largest <= 65536) + " " + req.body.locked);
+	}`)
+
+	const size = 4 << 20
+	resp, err := http.Post(url, "application/octet-stream", io.LimitReader(neverEnding('x'), size))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, fmt.Sprintf("%d true true true", size), string(body))
+
+	// text() still reads the whole body
+	resp, err = http.Post(url+"/text", "text/plain", strings.NewReader("hello"))
+	require.NoError(t, err)
+	body, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "text: 5", string(body))
+}
+
+// neverEnding is a reader returning the same byte forever
+type neverEnding byte
+
+func (b neverEnding) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(b)
+	}
+	return len(p), nil
+}
+
+func TestServe_RequestEventAndAddr(t *testing.T) {
+	url := serveScriptWithOptions(t, `onListen: () => {
+		globalThis.seen = [];
+		server.on("request", (req) => seen.push(req.method + " " + req.path));
+	}`, `(req) => new Response(JSON.stringify({ seen: globalThis.seen, addr: server.addr() }))`)
+
+	get(t, url+"/first")
+	_, body := get(t, url+"/second")
+	var got struct {
+		Seen []string `json:"seen"`
+		Addr struct {
+			Hostname string `json:"hostname"`
+			Port     int    `json:"port"`
+		} `json:"addr"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &got))
+	assert.Equal(t, []string{"GET /first", "GET /second"}, got.Seen)
+	assert.Equal(t, "127.0.0.1", got.Addr.Hostname)
+	assert.Equal(t, url, fmt.Sprintf("http://127.0.0.1:%d", got.Addr.Port))
+}
+
+func TestServe_CloseEvent(t *testing.T) {
+	manager := vm.NewVMManager([]string{"http"})
+	manager.RegisterModule(httpmodule.NewHTTPModuleWithOptions(httpmodule.Options{DefaultPort: httpmodule.AnyPort}))
+	instance, err := manager.CreateVM(context.Background())
+	require.NoError(t, err)
+	defer instance.Close()
+
+	_, err = instance.RunString(`
+		const serve = require('http/server');
+		const server = serve(() => new Response("ok"));
+		globalThis.events = [];
+		server.on("close", () => events.push("closed"));
+		try {
+			server.on("connect", () => {});
+		} catch (e) {
+			events.push(e.name);
+		}
+		server.close();
+		events.push("close called");
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, []any{"TypeError", "close called", "closed"}, instance.Runtime().Get("events").Export())
+}
+
+func TestServe_StreamBodyPipeThroughTextDecoder(t *testing.T) {
+	url := serveScriptWithOptions(t, `streamBody: true`, `async (req) => {
+		const reader = req.body.pipeThrough(new TextDecoderStream()).getReader();
+		let text = "";
+		while (true) {
+			const { value, done } = await reader.read();
+			if (done) break;
+			text += value;
+		}
+		return new Response(text.length + " " + text.slice(0, 3));
+	}`)
+
+	body := strings.Repeat("é", 100000)
+	resp, err := http.Post(url, "text/plain", strings.NewReader(body))
+	require.NoError(t, err)
+	got, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "100000 ééé", string(got))
+}
@@ -0,0 +1,248 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/codebench-mcp/server/modules/kv"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubKVStore is a minimal kv.Store used to verify the module delegates to it
+type stubKVStore struct {
+	mu    sync.Mutex
+	items map[string]any
+}
+
+func newStubKVStore() *stubKVStore {
+	return &stubKVStore{items: make(map[string]any)}
+}
+
+func (s *stubKVStore) Get(_ context.Context, key string) (any, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.items[key]
+	return value, ok, nil
+}
+
+func (s *stubKVStore) Set(_ context.Context, key string, value any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[key] = value
+	return nil
+}
+
+func (s *stubKVStore) Delete(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.items[key]
+	delete(s.items, key)
+	return ok, nil
+}
+
+func (s *stubKVStore) Keys(_ context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.items))
+	for key := range s.items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *stubKVStore) Clear(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = make(map[string]any)
+	return nil
+}
+
+func TestKVModule_CustomStore(t *testing.T) {
+	store := newStubKVStore()
+	store.items["existing"] = "from store"
+
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"kv"},
+		KVStore:        store,
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			kv.set('name', 'CodeBench');
+			console.log("existing:", kv.get('existing'));
+			console.log("keys:", kv.list().join(','));
+			kv.size();
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "existing: from store")
+	assert.Contains(t, text, "keys: existing,name")
+	assert.Contains(t, text, "Result: 2")
+
+	// Custom stores outlive the VM
+	assert.Equal(t, "CodeBench", store.items["name"])
+}
+
+func TestKVModule_DefaultStoreSurvivesCleanup(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"kv"},
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `kv.set('a', 1); kv.get('a');`,
+	}
+
+	for i := 0; i < 2; i++ {
+		result, err := handler.handleExecuteJS(context.Background(), request)
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: 1")
+	}
+}
+
+func TestKVModule_FileStorePersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kv.json")
+
+	run := func(code string) string {
+		store, err := kv.NewFileStore(path)
+		require.NoError(t, err)
+
+		handler := NewJSHandlerWithConfig(ModuleConfig{
+			EnabledModules: []string{"kv"},
+			KVStore:        store,
+		})
+
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "executeJS"
+		request.Params.Arguments = map[string]any{"code": code}
+
+		result, err := handler.handleExecuteJS(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	run(`kv.set('config', { name: 'CodeBench', tags: ['a', 'b'] });`)
+
+	// Reopening the file simulates a server restart
+	text := run(`
+		const config = kv.get('config');
+		console.log("name:", config.name);
+		console.log("tags:", config.tags.join(','));
+	`)
+	assert.Contains(t, text, "name: CodeBench")
+	assert.Contains(t, text, "tags: a,b")
+}
+
+func TestKVModule_ValuesAreSnapshots(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"kv"},
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const original = { name: 'CodeBench', tags: ['a'], nested: { n: 1 } };
+			kv.set('config', original);
+			original.name = 'changed';
+			original.tags.push('b');
+			original.nested.n = 2;
+
+			const fetched = kv.get('config');
+			fetched.name = 'changed again';
+			fetched.tags.push('c');
+
+			// Copying a fetched value to another key doesn't share it either
+			kv.set('copy', kv.get('config'));
+			kv.get('copy').nested.n = 3;
+
+			JSON.stringify([kv.get('config'), kv.get('copy').nested.n]);
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.False(t, result.IsError, text)
+	assert.Contains(t, text, `Result: [{"name":"CodeBench","nested":{"n":1},"tags":["a"]},1]`)
+}
+
+func TestKVModule_KeysAndEntriesWithPrefix(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"kv"},
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			kv.set('user:2', { name: 'Bob' });
+			kv.set('user:1', { name: 'Alice' });
+			kv.set('order:1', 42);
+
+			console.log("all:", kv.keys().join(','));
+			console.log("users:", kv.keys('user:').join(','));
+			console.log("none:", kv.keys('missing:').length);
+			console.log("entries:", JSON.stringify(kv.entries('user:')));
+			JSON.stringify(kv.entries());
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.False(t, result.IsError, text)
+	assert.Contains(t, text, "all: order:1,user:1,user:2")
+	assert.Contains(t, text, "users: user:1,user:2")
+	assert.Contains(t, text, "none: 0")
+	assert.Contains(t, text, `entries: [["user:1",{"name":"Alice"}],["user:2",{"name":"Bob"}]]`)
+	assert.Contains(t, text, `Result: [["order:1",42],["user:1",{"name":"Alice"}],["user:2",{"name":"Bob"}]]`)
+}
+
+func TestKVModule_Namespaces(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"kv"},
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const users = kv.namespace('users');
+			const orders = kv.namespace('orders');
+			kv.set('id', 'root');
+			users.set('id', 'user');
+			orders.set('id', 'order');
+			users.namespace('admins').set('id', 'admin');
+
+			console.log("values:", [kv.get('id'), users.get('id'), orders.get('id'), kv.namespace('users').namespace('admins').get('id')].join(','));
+			console.log("keys:", kv.keys().join(','), users.keys().join(','), kv.size(), users.size());
+
+			orders.clear();
+			console.log("after clear:", kv.get('id'), users.get('id'), orders.has('id'));
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.False(t, result.IsError, text)
+	assert.Contains(t, text, "values: root,user,order,admin")
+	assert.Contains(t, text, "keys: id id 1 1")
+	assert.Contains(t, text, "after clear: root user false")
+}
@@ -0,0 +1,191 @@
+package buffer
+
+import (
+	"strings"
+	"time"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/modules/internal/view"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// blob holds the immutable contents of a Blob
+type blob struct {
+	data []byte
+	typ  string
+}
+
+// setupBlob sets up the global Blob class
+func (b *BufferModule) setupBlob(runtime *sobek.Runtime) {
+	runtime.Set("Blob", func(call sobek.ConstructorCall) *sobek.Object {
+		data := blobParts(runtime, call.Argument(0), "Blob")
+		initBlob(runtime, call.This, &blob{data: data, typ: blobType(runtime, call.Argument(1))})
+		return nil
+	})
+}
+
+// setupFile sets up the global File class, a named Blob
+func (b *BufferModule) setupFile(runtime *sobek.Runtime) {
+	runtime.Set("File", func(call sobek.ConstructorCall) *sobek.Object {
+		if len(call.Arguments) < 2 {
+			panic(vm.NewTypeError(runtime, "buffer", vm.CodeInvalidArgument, "File: 2 arguments required (fileBits, fileName)"))
+		}
+		obj := call.This
+		data := blobParts(runtime, call.Argument(0), "File")
+		initBlob(runtime, obj, &blob{data: data, typ: blobType(runtime, call.Argument(2))})
+
+		lastModified := time.Now().UnixMilli()
+		if opts := call.Argument(2); !sobek.IsUndefined(opts) && !sobek.IsNull(opts) {
+			if v := opts.ToObject(runtime).Get("lastModified"); v != nil && !sobek.IsUndefined(v) {
+				lastModified = v.ToInteger()
+			}
+		}
+		obj.Set("name", call.Argument(1).String())
+		obj.Set("lastModified", lastModified)
+		return nil
+	})
+
+	// File instances are also Blob instances
+	fileProto := runtime.Get("File").ToObject(runtime).Get("prototype").ToObject(runtime)
+	blobProto := runtime.Get("Blob").ToObject(runtime).Get("prototype").ToObject(runtime)
+	if err := fileProto.SetPrototype(blobProto); err != nil {
+		panic(vm.NewError(runtime, "buffer", vm.CodeOperationFailed, err))
+	}
+}
+
+// initBlob attaches the Blob properties and methods to obj
+func initBlob(runtime *sobek.Runtime, obj *sobek.Object, bl *blob) {
+	_ = obj.DefineDataProperty("__blob", runtime.ToValue(bl), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_FALSE)
+	obj.Set("size", len(bl.data))
+	obj.Set("type", bl.typ)
+
+	// text() - resolves to the contents decoded as UTF-8
+	obj.Set("text", func(call sobek.FunctionCall) sobek.Value {
+		return resolveLater(runtime, func() any {
+			return string(bl.data)
+		})
+	})
+
+	// arrayBuffer() - resolves to a copy of the contents
+	obj.Set("arrayBuffer", func(call sobek.FunctionCall) sobek.Value {
+		return resolveLater(runtime, func() any {
+			copied := make([]byte, len(bl.data))
+			copy(copied, bl.data)
+			return runtime.NewArrayBuffer(copied)
+		})
+	})
+
+	// slice(start?, end?, contentType?) - returns a new Blob over a byte range
+	obj.Set("slice", func(call sobek.FunctionCall) sobek.Value {
+		size := int64(len(bl.data))
+		start, end := int64(0), size
+		if v := call.Argument(0); !sobek.IsUndefined(v) {
+			start = relativeIndex(v.ToInteger(), size)
+		}
+		if v := call.Argument(1); !sobek.IsUndefined(v) {
+			end = relativeIndex(v.ToInteger(), size)
+		}
+		if start > end {
+			start = end
+		}
+		typ := ""
+		if v := call.Argument(2); !sobek.IsUndefined(v) {
+			typ = strings.ToLower(v.String())
+		}
+
+		sliced := make([]byte, end-start)
+		copy(sliced, bl.data[start:end])
+		return newBlob(runtime, &blob{data: sliced, typ: typ})
+	})
+}
+
+// newBlob creates a Blob instance with the Blob prototype
+func newBlob(runtime *sobek.Runtime, bl *blob) *sobek.Object {
+	proto := runtime.Get("Blob").ToObject(runtime).Get("prototype").ToObject(runtime)
+	obj := runtime.CreateObject(proto)
+	initBlob(runtime, obj, bl)
+	return obj
+}
+
+// resolveLater returns a promise resolved with value() on the event loop
+func resolveLater(runtime *sobek.Runtime, value func() any) sobek.Value {
+	promise, resolve, _ := runtime.NewPromise()
+	enqueue := vm.EnqueueJob(runtime)
+	go enqueue(func() error {
+		return resolve(value())
+	})
+	return runtime.ToValue(promise)
+}
+
+// blobParts concatenates the byte contents of a Blob parts array
+func blobParts(runtime *sobek.Runtime, partsVal sobek.Value, class string) []byte {
+	if sobek.IsUndefined(partsVal) || sobek.IsNull(partsVal) {
+		return []byte{}
+	}
+	parts, ok := partsVal.(*sobek.Object)
+	if !ok || parts.ClassName() != "Array" {
+		panic(vm.NewTypeError(runtime, "buffer", vm.CodeInvalidArgument, class+": parts must be an array"))
+	}
+
+	var data []byte
+	length := parts.Get("length").ToInteger()
+	for i := int64(0); i < length; i++ {
+		data = append(data, toBytes(runtime, parts.Get(runtime.ToValue(i).String()))...)
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return data
+}
+
+// blobType reads the lower-cased type from a Blob options object
+func blobType(runtime *sobek.Runtime, optsVal sobek.Value) string {
+	if sobek.IsUndefined(optsVal) || sobek.IsNull(optsVal) {
+		return ""
+	}
+	if v := optsVal.ToObject(runtime).Get("type"); v != nil && !sobek.IsUndefined(v) {
+		return strings.ToLower(v.String())
+	}
+	return ""
+}
+
+// toBytes converts a Blob part (Blob, Buffer, ArrayBuffer, typed array or string) to bytes
+func toBytes(runtime *sobek.Runtime, value sobek.Value) []byte {
+	if obj, ok := value.(*sobek.Object); ok {
+		if v := obj.Get("__blob"); v != nil {
+			if bl, ok := v.Export().(*blob); ok {
+				return bl.data
+			}
+		}
+		if v := obj.Get("__data__"); v != nil {
+			if data, ok := v.Export().([]byte); ok {
+				return data
+			}
+		}
+		switch v := obj.Export().(type) {
+		case sobek.ArrayBuffer:
+			return v.Bytes()
+		case []byte:
+			return v
+		}
+		// Other typed arrays and DataViews expose their underlying buffer
+		if data, ok := view.Bytes(runtime, "buffer", obj); ok {
+			return data
+		}
+	}
+	return []byte(value.String())
+}
+
+// relativeIndex resolves a possibly negative index against size, clamped to [0, size]
+func relativeIndex(i, size int64) int64 {
+	if i < 0 {
+		i += size
+	}
+	if i < 0 {
+		return 0
+	}
+	if i > size {
+		return size
+	}
+	return i
+}
@@ -3,6 +3,7 @@ package buffer
 import (
 	"encoding/base64"
 	"encoding/hex"
+	"strings"
 
 	"github.com/grafana/sobek"
 	"github.com/mark3labs/codebench-mcp/server/vm"
@@ -38,23 +39,11 @@ func (b *BufferModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) erro
 					encoding = call.Argument(1).String()
 				}
 
-				str := arg.String()
-				switch encoding {
-				case "base64":
-					decoded, err := base64.StdEncoding.DecodeString(str)
-					if err != nil {
-						panic(runtime.NewGoError(err))
-					}
-					data = decoded
-				case "hex":
-					decoded, err := hex.DecodeString(str)
-					if err != nil {
-						panic(runtime.NewGoError(err))
-					}
-					data = decoded
-				default: // utf8
-					data = []byte(str)
+				decoded, err := decodeString(arg.String(), encoding)
+				if err != nil {
+					panic(vm.NewError(runtime, "buffer", vm.CodeOperationFailed, err))
 				}
+				data = decoded
 			} else if sobek.IsNumber(arg) {
 				// Create buffer of specified size
 				size := arg.ToInteger()
@@ -70,7 +59,10 @@ func (b *BufferModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) erro
 					// Array of any (same as []interface{})
 					data = make([]byte, len(v))
 					for i, val := range v {
-						if num, ok := val.(float64); ok {
+						switch num := val.(type) {
+						case int64:
+							data[i] = byte(num)
+						case float64:
 							data[i] = byte(int(num))
 						}
 					}
@@ -80,7 +72,10 @@ func (b *BufferModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) erro
 
 		// Store the data
 		obj.Set("__data__", data)
-		obj.Set("length", len(data))
+		defineLength(runtime, obj)
+		obj.Set("toJSON", bufferToJSON(runtime))
+		obj.Set("write", bufferWrite(runtime))
+		obj.Set("fill", bufferFill(runtime))
 
 		// toString method
 		obj.Set("toString", func(call sobek.FunctionCall) sobek.Value {
@@ -94,6 +89,8 @@ func (b *BufferModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) erro
 			switch encoding {
 			case "base64":
 				return runtime.ToValue(base64.StdEncoding.EncodeToString(data))
+			case "base64url":
+				return runtime.ToValue(base64.RawURLEncoding.EncodeToString(data))
 			case "hex":
 				return runtime.ToValue(hex.EncodeToString(data))
 			default: // utf8
@@ -136,11 +133,14 @@ func (b *BufferModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) erro
 			// Create new Buffer object
 			newBuffer := runtime.NewObject()
 			newBuffer.Set("__data__", sliced)
-			newBuffer.Set("length", len(sliced))
+			defineLength(runtime, newBuffer)
 
 			// Copy methods to new buffer
 			newBuffer.Set("toString", obj.Get("toString"))
 			newBuffer.Set("slice", obj.Get("slice"))
+			newBuffer.Set("toJSON", obj.Get("toJSON"))
+			newBuffer.Set("write", obj.Get("write"))
+			newBuffer.Set("fill", obj.Get("fill"))
 
 			return newBuffer
 		})
@@ -159,7 +159,7 @@ func (b *BufferModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) erro
 		constructor, _ := sobek.AssertFunction(runtime.Get("Buffer"))
 		result, err := constructor(sobek.Undefined(), call.Arguments...)
 		if err != nil {
-			panic(runtime.NewGoError(err))
+			panic(vm.NewError(runtime, "buffer", vm.CodeOperationFailed, err))
 		}
 		return result
 	})
@@ -183,18 +183,187 @@ func (b *BufferModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) erro
 
 		newBuffer := runtime.NewObject()
 		newBuffer.Set("__data__", data)
-		newBuffer.Set("length", len(data))
+		defineLength(runtime, newBuffer)
 
 		// Add methods
 		newBuffer.Set("toString", bufferObj.Get("toString"))
 		newBuffer.Set("slice", bufferObj.Get("slice"))
+		newBuffer.Set("toJSON", bufferToJSON(runtime))
+		newBuffer.Set("write", bufferWrite(runtime))
+		newBuffer.Set("fill", bufferFill(runtime))
 
 		return newBuffer
 	})
 
+	// Buffer.isBuffer static method
+	bufferObj.Set("isBuffer", func(call sobek.FunctionCall) sobek.Value {
+		_, ok := bufferData(call.Argument(0))
+		return runtime.ToValue(ok)
+	})
+
+	// Buffer.byteLength static method - the number of bytes in a Buffer or
+	// in a string decoded with the given encoding
+	bufferObj.Set("byteLength", func(call sobek.FunctionCall) sobek.Value {
+		if data, ok := bufferData(call.Argument(0)); ok {
+			return runtime.ToValue(len(data))
+		}
+		if buf, ok := call.Argument(0).Export().(sobek.ArrayBuffer); ok {
+			return runtime.ToValue(len(buf.Bytes()))
+		}
+		if !sobek.IsString(call.Argument(0)) {
+			panic(vm.NewTypeError(runtime, "buffer", vm.CodeInvalidArgument, "byteLength: argument must be a string, Buffer or ArrayBuffer"))
+		}
+		constructor, _ := sobek.AssertFunction(runtime.Get("Buffer"))
+		result, err := constructor(sobek.Undefined(), call.Arguments...)
+		if err != nil {
+			panic(vm.NewError(runtime, "buffer", vm.CodeOperationFailed, err))
+		}
+		data, _ := bufferData(result)
+		return runtime.ToValue(len(data))
+	})
+
+	// Blob and File classes for immutable binary data
+	b.setupBlob(runtime)
+	b.setupFile(runtime)
+
 	return nil
 }
 
+// decodeString converts str to bytes using a toString encoding
+func decodeString(str, encoding string) ([]byte, error) {
+	switch encoding {
+	case "base64":
+		return base64.StdEncoding.DecodeString(str)
+	case "base64url":
+		// Padding is optional in base64url
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(str, "="))
+	case "hex":
+		return hex.DecodeString(str)
+	default: // utf8
+		return []byte(str), nil
+	}
+}
+
+// bufferData returns the bytes of a Buffer, reporting false for other values
+func bufferData(v sobek.Value) ([]byte, bool) {
+	obj, ok := v.(*sobek.Object)
+	if !ok {
+		return nil, false
+	}
+	dataVal := obj.Get("__data__")
+	if dataVal == nil {
+		return nil, false
+	}
+	data, ok := dataVal.Export().([]byte)
+	return data, ok
+}
+
+// defineLength adds a length getter to a Buffer, so it follows its data
+func defineLength(runtime *sobek.Runtime, obj *sobek.Object) {
+	getter := runtime.ToValue(func(call sobek.FunctionCall) sobek.Value {
+		data, _ := bufferData(call.This)
+		return runtime.ToValue(len(data))
+	})
+	_ = obj.DefineAccessorProperty("length", getter, nil, sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+}
+
+// bufferToJSON returns the Buffer toJSON method, giving { type: 'Buffer', data: [...] } like Node
+func bufferToJSON(runtime *sobek.Runtime) func(call sobek.FunctionCall) sobek.Value {
+	return func(call sobek.FunctionCall) sobek.Value {
+		data, _ := bufferData(call.This)
+		values := make([]any, len(data))
+		for i, b := range data {
+			values[i] = int64(b)
+		}
+		result := runtime.NewObject()
+		result.Set("type", "Buffer")
+		result.Set("data", runtime.NewArray(values...))
+		return result
+	}
+}
+
+// bufferWrite returns the Buffer write method, write(string, offset?, length?, encoding?),
+// which copies the encoded string into the buffer and returns the bytes written.
+// The encoding may also take the place of offset or length, like Node.
+func bufferWrite(runtime *sobek.Runtime) func(call sobek.FunctionCall) sobek.Value {
+	return func(call sobek.FunctionCall) sobek.Value {
+		data, _ := bufferData(call.This)
+		offset, length := 0, -1
+		encoding := "utf8"
+		for i, arg := range call.Arguments[min(1, len(call.Arguments)):] {
+			if sobek.IsUndefined(arg) {
+				continue
+			}
+			if sobek.IsString(arg) {
+				encoding = arg.String()
+				break
+			}
+			switch i {
+			case 0:
+				offset = int(arg.ToInteger())
+			case 1:
+				length = int(arg.ToInteger())
+			}
+		}
+		if offset < 0 || offset > len(data) {
+			panic(vm.NewRangeError(runtime, "buffer", vm.CodeInvalidArgument, "write: offset is out of bounds"))
+		}
+
+		encoded, err := decodeString(call.Argument(0).String(), encoding)
+		if err != nil {
+			panic(vm.NewError(runtime, "buffer", vm.CodeInvalidArgument, err))
+		}
+		if length >= 0 && length < len(encoded) {
+			encoded = encoded[:length]
+		}
+		return runtime.ToValue(copy(data[offset:], encoded))
+	}
+}
+
+// bufferFill returns the Buffer fill method, fill(value, start?, end?, encoding?),
+// which repeats a byte, string or Buffer over the range and returns the buffer
+func bufferFill(runtime *sobek.Runtime) func(call sobek.FunctionCall) sobek.Value {
+	return func(call sobek.FunctionCall) sobek.Value {
+		data, _ := bufferData(call.This)
+		start, end := 0, len(data)
+		if v := call.Argument(1); !sobek.IsUndefined(v) {
+			start = int(v.ToInteger())
+		}
+		if v := call.Argument(2); !sobek.IsUndefined(v) {
+			end = int(v.ToInteger())
+		}
+		if start < 0 || end > len(data) || start > end {
+			panic(vm.NewRangeError(runtime, "buffer", vm.CodeInvalidArgument, "fill: range is out of bounds"))
+		}
+
+		value := call.Argument(0)
+		var pattern []byte
+		if buf, ok := bufferData(value); ok {
+			pattern = buf
+		} else if sobek.IsString(value) {
+			encoding := "utf8"
+			if v := call.Argument(3); !sobek.IsUndefined(v) {
+				encoding = v.String()
+			}
+			decoded, err := decodeString(value.String(), encoding)
+			if err != nil {
+				panic(vm.NewError(runtime, "buffer", vm.CodeInvalidArgument, err))
+			}
+			pattern = decoded
+		} else {
+			pattern = []byte{byte(value.ToInteger())}
+		}
+		if len(pattern) == 0 {
+			pattern = []byte{0}
+		}
+
+		for i := start; i < end; i += len(pattern) {
+			copy(data[i:end], pattern)
+		}
+		return call.This
+	}
+}
+
 // Cleanup performs any necessary cleanup
 func (b *BufferModule) Cleanup() error {
 	// Buffer module doesn't need cleanup
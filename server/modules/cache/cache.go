@@ -2,16 +2,32 @@ package cache
 
 import (
 	"context"
+	"math"
 	"sync"
 	"time"
 
 	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/modules/internal/view"
+	"github.com/mark3labs/codebench-mcp/server/quota"
 	"github.com/mark3labs/codebench-mcp/server/vm"
 )
 
 // CacheModule provides in-memory caching with TTL support
 type CacheModule struct {
 	cache Cache
+	perVM bool // give each VM its own in-memory cache instead of cache
+}
+
+// Options configures a cache module
+type Options struct {
+	// Backend stores the items of every VM, e.g. a shared store so cache
+	// state is visible across processes (defaults to an in-memory cache)
+	Backend Cache
+	// PerVM gives each VM its own in-memory cache, so items never leak
+	// between unrelated executions; a persistent VM keeps its items across
+	// the executions of its session. Ignored when Backend is set, since
+	// custom backends are meant to be shared.
+	PerVM bool
 }
 
 // NewCacheModule creates a new cache module
@@ -21,6 +37,102 @@ func NewCacheModule() *CacheModule {
 	}
 }
 
+// NewCacheModuleWithBackend creates a cache module that stores items in backend,
+// e.g. a shared store so cache state is visible across processes.
+// A nil backend falls back to the in-memory cache.
+func NewCacheModuleWithBackend(backend Cache) *CacheModule {
+	if backend == nil {
+		return NewCacheModule()
+	}
+	return &CacheModule{
+		cache: backend,
+	}
+}
+
+// NewCacheModuleWithOptions creates a cache module configured by opts
+func NewCacheModuleWithOptions(opts Options) *CacheModule {
+	if opts.Backend != nil {
+		return NewCacheModuleWithBackend(opts.Backend)
+	}
+	c := NewCacheModule()
+	c.perVM = opts.PerVM
+	return c
+}
+
+// Backend returns the store the module keeps its items in. With per-VM
+// caches it is a shared store that scripts don't see.
+func (c *CacheModule) Backend() Cache {
+	return c.cache
+}
+
+var symCache = sobek.NewSymbol(`Symbol.__cache__`)
+
+// vmCache is the cache of one VM. Its keys count against the quota of the
+// sessions that stored them until the VM is closed.
+type vmCache struct {
+	Cache
+	mu     sync.Mutex
+	usages map[*quota.Usage]struct{}
+}
+
+// track records that usage counts keys of the cache
+func (v *vmCache) track(usage *quota.Usage) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.usages[usage] = struct{}{}
+}
+
+// scope returns the quota scope of the keys of v, nil for the shared store
+func (v *vmCache) scope() any {
+	if v == nil {
+		return nil
+	}
+	return v
+}
+
+// release gives the quota held by the keys of the cache back to the
+// sessions that stored them
+func (v *vmCache) release() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for usage := range v.usages {
+		usage.ReleaseCacheScope(v)
+	}
+	v.usages = nil
+}
+
+// store returns the cache the items of runtime are kept in, and with per-VM
+// caches, the VM's cache as the scope its keys are counted in
+func (c *CacheModule) store(runtime *sobek.Runtime) (Cache, *vmCache) {
+	if !c.perVM {
+		return c.cache, nil
+	}
+	global := runtime.GlobalObject()
+	if v := global.GetSymbol(symCache); v != nil {
+		store := v.Export().(*vmCache)
+		return store, store
+	}
+	store := &vmCache{Cache: NewCache(), usages: make(map[*quota.Usage]struct{})}
+	_ = global.SetSymbol(symCache, store)
+	vm.OnClose(runtime, store.release)
+	return store, store
+}
+
+// countEntry counts key against the session quota of runtime, if any,
+// throwing if the quota is exceeded
+func countEntry(runtime *sobek.Runtime, owner *vmCache, key string, ttl time.Duration) {
+	usage := quota.FromContext(vm.Context(runtime))
+	if usage == nil {
+		return
+	}
+	if owner != nil {
+		owner.track(usage)
+	}
+	if err := usage.AddCacheEntry(owner.scope(), key, ttl); err != nil {
+		panic(vm.NewError(runtime, "cache", vm.CodeQuotaExceeded, err))
+	}
+}
+
 // Name returns the module name
 func (c *CacheModule) Name() string {
 	return "cache"
@@ -40,6 +152,7 @@ func (c *CacheModule) CreateModuleObject(runtime *sobek.Runtime) sobek.Value {
 // createCacheObject creates the cache object with all methods
 func (c *CacheModule) createCacheObject(runtime *sobek.Runtime) sobek.Value {
 	cache := runtime.NewObject()
+	store, owner := c.store(runtime)
 
 	// get(key) - returns string value or undefined
 	cache.Set("get", func(call sobek.FunctionCall) sobek.Value {
@@ -48,86 +161,77 @@ func (c *CacheModule) createCacheObject(runtime *sobek.Runtime) sobek.Value {
 		}
 		
 		key := call.Argument(0).String()
-		if bytes, err := c.cache.Get(context.Background(), key); err == nil && bytes != nil {
+		if bytes, err := store.Get(context.Background(), key); err == nil && bytes != nil {
 			return runtime.ToValue(string(bytes))
 		}
 		return sobek.Undefined()
 	})
 
-	// getBytes(key) - returns ArrayBuffer or undefined
+	// getBytes(key, { asUint8Array }?) - returns ArrayBuffer, or a Uint8Array
+	// with asUint8Array, or undefined
 	cache.Set("getBytes", func(call sobek.FunctionCall) sobek.Value {
 		if len(call.Arguments) == 0 {
 			return sobek.Undefined()
 		}
 		
 		key := call.Argument(0).String()
-		if bytes, err := c.cache.Get(context.Background(), key); err == nil && bytes != nil {
-			return runtime.ToValue(runtime.NewArrayBuffer(bytes))
+		if bytes, err := store.Get(context.Background(), key); err == nil && bytes != nil {
+			// Copied so changes from the script don't reach the stored item
+			buffer := runtime.NewArrayBuffer(append([]byte(nil), bytes...))
+			if opts := call.Argument(1); !sobek.IsUndefined(opts) && !sobek.IsNull(opts) {
+				if v := opts.ToObject(runtime).Get("asUint8Array"); v != nil && v.ToBoolean() {
+					arr, err := runtime.New(runtime.Get("Uint8Array"), runtime.ToValue(buffer))
+					if err != nil {
+						panic(err)
+					}
+					return arr
+				}
+			}
+			return runtime.ToValue(buffer)
 		}
 		return sobek.Undefined()
 	})
 
-	// set(key, value, ttlMs?) - stores string value with optional TTL in milliseconds
+	// set(key, value, ttlMs?) - stores string value with optional TTL in
+	// milliseconds; an omitted or 0 TTL stores it without expiry
 	cache.Set("set", func(call sobek.FunctionCall) sobek.Value {
 		if len(call.Arguments) < 2 {
-			panic(runtime.NewTypeError("cache.set requires at least 2 arguments"))
+			panic(vm.NewTypeError(runtime, "cache", vm.CodeInvalidArgument, "cache.set requires at least 2 arguments"))
 		}
 		
 		key := call.Argument(0).String()
 		value := []byte(call.Argument(1).String())
 		
-		var timeout time.Duration
-		if len(call.Arguments) > 2 && !sobek.IsUndefined(call.Argument(2)) {
-			timeout = time.Millisecond * time.Duration(call.Argument(2).ToInteger())
-		}
+		timeout := ttlArgument(runtime, call.Argument(2), "cache.set")
 		
-		err := c.cache.Set(context.Background(), key, value, timeout)
+		countEntry(runtime, owner, key, timeout)
+
+		err := store.Set(context.Background(), key, value, timeout)
 		if err != nil {
-			panic(runtime.NewGoError(err))
+			panic(vm.NewError(runtime, "cache", vm.CodeOperationFailed, err))
 		}
 		
 		return sobek.Undefined()
 	})
 
-	// setBytes(key, arrayBuffer, ttlMs?) - stores ArrayBuffer with optional TTL
+	// setBytes(key, bytes, ttlMs?) - stores an ArrayBuffer, typed array,
+	// DataView, array of numbers or string (as UTF-8) with optional TTL
 	cache.Set("setBytes", func(call sobek.FunctionCall) sobek.Value {
 		if len(call.Arguments) < 2 {
-			panic(runtime.NewTypeError("cache.setBytes requires at least 2 arguments"))
+			panic(vm.NewTypeError(runtime, "cache", vm.CodeInvalidArgument, "cache.setBytes requires at least 2 arguments"))
 		}
 		
 		key := call.Argument(0).String()
 		
-		// Convert value to bytes
-		var value []byte
-		arg := call.Argument(1)
-		if exported := arg.Export(); exported != nil {
-			switch v := exported.(type) {
-			case []byte:
-				value = v
-			case []any:
-				// Convert array of numbers to bytes
-				value = make([]byte, len(v))
-				for i, val := range v {
-					if num, ok := val.(float64); ok {
-						value[i] = byte(int(num))
-					}
-				}
-			default:
-				// Convert to string and then bytes
-				value = []byte(arg.String())
-			}
-		} else {
-			value = []byte(arg.String())
-		}
-		
-		var timeout time.Duration
-		if len(call.Arguments) > 2 && !sobek.IsUndefined(call.Argument(2)) {
-			timeout = time.Millisecond * time.Duration(call.Argument(2).ToInteger())
-		}
+		value := bytesArgument(runtime, call.Argument(1))
+
+		timeout := ttlArgument(runtime, call.Argument(2), "cache.setBytes")
 		
-		err := c.cache.Set(context.Background(), key, value, timeout)
+		countEntry(runtime, owner, key, timeout)
+
+		err := store.Set(context.Background(), key, value, timeout)
 		if err != nil {
-			panic(runtime.NewGoError(err))
+			panic(vm.NewError(runtime, "cache", vm.CodeOperationFailed, err))
 		}
 		
 		return sobek.Undefined()
@@ -140,9 +244,12 @@ func (c *CacheModule) createCacheObject(runtime *sobek.Runtime) sobek.Value {
 		}
 		
 		key := call.Argument(0).String()
-		err := c.cache.Del(context.Background(), key)
+		err := store.Del(context.Background(), key)
 		if err != nil {
-			panic(runtime.NewGoError(err))
+			panic(vm.NewError(runtime, "cache", vm.CodeOperationFailed, err))
+		}
+		if usage := quota.FromContext(vm.Context(runtime)); usage != nil {
+			usage.RemoveCacheEntry(owner.scope(), key)
 		}
 		
 		return sobek.Undefined()
@@ -151,6 +258,55 @@ func (c *CacheModule) createCacheObject(runtime *sobek.Runtime) sobek.Value {
 	return cache
 }
 
+// bytesArgument copies the bytes of an ArrayBuffer, a typed array or
+// DataView (only the range it views), an array of numbers or a string (as
+// UTF-8)
+func bytesArgument(runtime *sobek.Runtime, value sobek.Value) []byte {
+	// Typed arrays and DataViews expose their underlying buffer
+	if obj, ok := value.(*sobek.Object); ok {
+		if data, ok := view.Bytes(runtime, "cache", obj); ok {
+			return append([]byte(nil), data...)
+		}
+	}
+
+	switch v := value.Export().(type) {
+	case sobek.ArrayBuffer:
+		return append([]byte(nil), v.Bytes()...)
+	case []byte:
+		return append([]byte(nil), v...)
+	case []any:
+		data := make([]byte, len(v))
+		for i, val := range v {
+			switch num := val.(type) {
+			case int64:
+				data[i] = byte(num)
+			case float64:
+				data[i] = byte(int(num))
+			}
+		}
+		return data
+	}
+	return []byte(value.String())
+}
+
+// ttlArgument converts the ttlMs argument of method to a duration. Undefined
+// and 0 mean no expiry; negative or non-numeric values throw a TypeError
+// rather than silently storing the item forever.
+func ttlArgument(runtime *sobek.Runtime, ttl sobek.Value, method string) time.Duration {
+	if ttl == nil || sobek.IsUndefined(ttl) {
+		return 0
+	}
+	ms := ttl.ToFloat()
+	if math.IsNaN(ms) || ms < 0 {
+		panic(vm.NewTypeError(runtime, "cache", vm.CodeInvalidArgument, method+": ttlMs must be a non-negative number of milliseconds, got "+ttl.String()))
+	}
+	if ms >= float64(math.MaxInt64/int64(time.Millisecond)) {
+		// Infinity or too far away to represent, so it never expires
+		return 0
+	}
+	return time.Millisecond * time.Duration(ms)
+}
+
 // Cleanup performs any necessary cleanup
 func (c *CacheModule) Cleanup() error {
 	// Memory cache doesn't need explicit cleanup
@@ -164,6 +320,7 @@ func (c *CacheModule) IsEnabled(enabledModules map[string]bool) bool {
 }
 
 // Cache interface for storing bytes with TTL
+// Implementations must be safe for concurrent use.
 type Cache interface {
 	Get(ctx context.Context, key string) ([]byte, error)
 	Set(ctx context.Context, key string, value []byte, timeout time.Duration) error
@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dirEntry is the JSON form of a cache item in its file
+type dirEntry struct {
+	Key     string `json:"key"`
+	Value   []byte `json:"value"`
+	Expires int64  `json:"expires,omitempty"` // Unix milliseconds, 0 for no expiry
+}
+
+// dirCache is an implementation of Cache that keeps items in memory and
+// persists each one to its own file in a directory, so cached values survive
+// restarts. Every write rewrites the item's file atomically.
+type dirCache struct {
+	sync.Mutex
+	dir   string
+	items map[string]dirEntry
+}
+
+// NewDirCache returns a Cache persisted in dir, creating it if needed and
+// loading the unexpired items already in it
+func NewDirCache(dir string) (Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	c := &dirCache{
+		dir:   dir,
+		items: make(map[string]dirEntry),
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UnixMilli()
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var entry dirEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("cache file %s: %w", path, err)
+		}
+		if entry.Expires != 0 && now > entry.Expires {
+			os.Remove(path)
+			continue
+		}
+		c.items[entry.Key] = entry
+	}
+	return c, nil
+}
+
+// Get returns the []byte if existing and not expired
+func (c *dirCache) Get(_ context.Context, key string) ([]byte, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	entry, exists := c.items[key]
+	if !exists {
+		return nil, nil
+	}
+	if entry.Expires != 0 && time.Now().UnixMilli() > entry.Expires {
+		delete(c.items, key)
+		return nil, c.remove(key)
+	}
+	return entry.Value, nil
+}
+
+// Set saves []byte to the cache with key and optional timeout, and persists it
+func (c *dirCache) Set(_ context.Context, key string, value []byte, timeout time.Duration) error {
+	c.Lock()
+	defer c.Unlock()
+
+	entry := dirEntry{Key: key, Value: value}
+	if timeout > 0 {
+		entry.Expires = time.Now().Add(timeout).UnixMilli()
+	}
+	if err := c.save(entry); err != nil {
+		return err
+	}
+	c.items[key] = entry
+	return nil
+}
+
+// Del removes key from the cache and its file
+func (c *dirCache) Del(_ context.Context, key string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.items, key)
+	return c.remove(key)
+}
+
+// path returns the file of key, named by its hash so any key is a valid name
+func (c *dirCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// save writes entry to a temp file and renames it over the entry's file, so
+// a crash never leaves a partially written item. Callers hold the lock.
+func (c *dirCache) save(entry dirEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(c.dir, "entry.*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(entry.Key))
+}
+
+// remove deletes the file of key, if any. Callers hold the lock.
+func (c *dirCache) remove(key string) error {
+	if err := os.Remove(c.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+	gochart "github.com/wcharczuk/go-chart/v2"
+)
+
+// ChartModule provides chart rendering to SVG or PNG
+type ChartModule struct{}
+
+// NewChartModule creates a new chart module
+func NewChartModule() *ChartModule {
+	return &ChartModule{}
+}
+
+// Name returns the module name
+func (c *ChartModule) Name() string {
+	return "chart"
+}
+
+// Setup initializes the chart module in the VM
+func (c *ChartModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) error {
+	// No setup needed - the module will be available via require()
+	return nil
+}
+
+// CreateModuleObject creates the chart object when required
+func (c *ChartModule) CreateModuleObject(runtime *sobek.Runtime) sobek.Value {
+	chart := runtime.NewObject()
+
+	// line(options) - renders one or more line series
+	chart.Set("line", func(call sobek.FunctionCall) sobek.Value {
+		opts := c.options(runtime, call, "line")
+
+		seriesVal := opts.Get("series")
+		if seriesVal == nil || sobek.IsUndefined(seriesVal) || sobek.IsNull(seriesVal) {
+			panic(vm.NewTypeError(runtime, "chart", vm.CodeInvalidArgument, "chart.line requires a series array"))
+		}
+
+		var series []map[string]any
+		if err := runtime.ExportTo(seriesVal, &series); err != nil {
+			panic(vm.NewTypeError(runtime, "chart", vm.CodeInvalidArgument, "chart.line: invalid series: "+err.Error()))
+		}
+		if len(series) == 0 {
+			panic(vm.NewTypeError(runtime, "chart", vm.CodeInvalidArgument, "chart.line requires at least one series"))
+		}
+
+		graph := gochart.Chart{
+			Title:  stringOption(opts, "title"),
+			Width:  intOption(opts, "width", 800),
+			Height: intOption(opts, "height", 400),
+		}
+		for i, s := range series {
+			y := toFloats(s["y"])
+			if len(y) == 0 {
+				panic(vm.NewTypeError(runtime, "chart", vm.CodeInvalidArgument, fmt.Sprintf("chart.line: series %d has no y values", i)))
+			}
+			x := toFloats(s["x"])
+			if len(x) == 0 {
+				x = make([]float64, len(y))
+				for j := range x {
+					x[j] = float64(j)
+				}
+			}
+			if len(x) != len(y) {
+				panic(vm.NewTypeError(runtime, "chart", vm.CodeInvalidArgument, fmt.Sprintf("chart.line: series %d has %d x values and %d y values", i, len(x), len(y))))
+			}
+			name, _ := s["name"].(string)
+			if name == "" {
+				name = fmt.Sprintf("Series %d", i+1)
+			}
+			graph.Series = append(graph.Series, gochart.ContinuousSeries{
+				Name:    name,
+				XValues: x,
+				YValues: y,
+			})
+		}
+		graph.Elements = []gochart.Renderable{gochart.Legend(&graph)}
+
+		return c.render(runtime, opts, graph.Render)
+	})
+
+	// bar(options) - renders a bar chart from labelled values
+	chart.Set("bar", func(call sobek.FunctionCall) sobek.Value {
+		opts := c.options(runtime, call, "bar")
+
+		barsVal := opts.Get("bars")
+		if barsVal == nil || sobek.IsUndefined(barsVal) || sobek.IsNull(barsVal) {
+			panic(vm.NewTypeError(runtime, "chart", vm.CodeInvalidArgument, "chart.bar requires a bars array"))
+		}
+
+		var bars []map[string]any
+		if err := runtime.ExportTo(barsVal, &bars); err != nil {
+			panic(vm.NewTypeError(runtime, "chart", vm.CodeInvalidArgument, "chart.bar: invalid bars: "+err.Error()))
+		}
+		if len(bars) == 0 {
+			panic(vm.NewTypeError(runtime, "chart", vm.CodeInvalidArgument, "chart.bar requires at least one bar"))
+		}
+
+		graph := gochart.BarChart{
+			Title:    stringOption(opts, "title"),
+			Width:    intOption(opts, "width", 800),
+			Height:   intOption(opts, "height", 400),
+			BarWidth: intOption(opts, "barWidth", 40),
+		}
+		for _, b := range bars {
+			label, _ := b["label"].(string)
+			graph.Bars = append(graph.Bars, gochart.Value{
+				Label: label,
+				Value: toFloat(b["value"]),
+			})
+		}
+
+		return c.render(runtime, opts, graph.Render)
+	})
+
+	return chart
+}
+
+// options returns the options object passed as the first argument
+func (c *ChartModule) options(runtime *sobek.Runtime, call sobek.FunctionCall, fn string) *sobek.Object {
+	arg := call.Argument(0)
+	if sobek.IsUndefined(arg) || sobek.IsNull(arg) {
+		panic(vm.NewTypeError(runtime, "chart", vm.CodeInvalidArgument, fmt.Sprintf("chart.%s requires an options object", fn)))
+	}
+	return arg.ToObject(runtime)
+}
+
+// render draws the chart in the requested format and returns the bytes
+func (c *ChartModule) render(runtime *sobek.Runtime, opts *sobek.Object, draw func(gochart.RendererProvider, io.Writer) error) sobek.Value {
+	var provider gochart.RendererProvider
+	switch format := stringOption(opts, "format"); format {
+	case "", "svg":
+		provider = gochart.SVG
+	case "png":
+		provider = gochart.PNG
+	default:
+		panic(vm.NewTypeError(runtime, "chart", vm.CodeNotSupported, "unsupported chart format: "+format))
+	}
+
+	var buf bytes.Buffer
+	if err := draw(provider, &buf); err != nil {
+		panic(vm.NewError(runtime, "chart", vm.CodeOperationFailed, err))
+	}
+	return runtime.ToValue(buf.Bytes())
+}
+
+// stringOption reads a string option, returning "" when unset
+func stringOption(opts *sobek.Object, name string) string {
+	v := opts.Get(name)
+	if v == nil || sobek.IsUndefined(v) || sobek.IsNull(v) {
+		return ""
+	}
+	return v.String()
+}
+
+// intOption reads an integer option, returning def when unset
+func intOption(opts *sobek.Object, name string, def int) int {
+	v := opts.Get(name)
+	if v == nil || sobek.IsUndefined(v) || sobek.IsNull(v) {
+		return def
+	}
+	if n := int(v.ToInteger()); n > 0 {
+		return n
+	}
+	return def
+}
+
+// toFloats converts an exported JS array to float64 values
+func toFloats(v any) []float64 {
+	values, ok := v.([]any)
+	if !ok {
+		return nil
+	}
+	result := make([]float64, len(values))
+	for i, val := range values {
+		result[i] = toFloat(val)
+	}
+	return result
+}
+
+// toFloat converts an exported JS number to float64
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	default:
+		return 0
+	}
+}
+
+// Warmup parses the default font used to render chart labels
+func (c *ChartModule) Warmup() error {
+	_, err := gochart.GetDefaultFont()
+	return err
+}
+
+// Cleanup performs any necessary cleanup
+func (c *ChartModule) Cleanup() error {
+	// Chart module doesn't need cleanup
+	return nil
+}
+
+// IsEnabled checks if the module should be enabled based on configuration
+func (c *ChartModule) IsEnabled(enabledModules map[string]bool) bool {
+	enabled, exists := enabledModules["chart"]
+	return exists && enabled
+}
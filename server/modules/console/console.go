@@ -3,13 +3,27 @@ package console
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/internal/logger"
 )
 
+// DefaultMaxOutput is how many bytes of output are captured per execution
+// unless configured otherwise
+const DefaultMaxOutput = 1 << 20
+
+// truncatedMarker ends the captured output once it reaches the limit
+const truncatedMarker = "...[output truncated]\n"
+
 // ConsoleModule provides console.log, console.error, etc.
 type ConsoleModule struct {
-	output *strings.Builder
+	output    *strings.Builder
+	tee       bool   // also forward messages to the internal logger
+	logPrefix string // prefix for forwarded messages
+	maxOutput int    // bytes of output captured before truncating
+	truncated bool   // set once output reached maxOutput
+	listener  func(line string) // called with each captured line, if set
 }
 
 // NewConsoleModule creates a new console module
@@ -18,8 +32,35 @@ func NewConsoleModule(output *strings.Builder) *ConsoleModule {
 		output = &strings.Builder{}
 	}
 	return &ConsoleModule{
-		output: output,
+		output:    output,
+		maxOutput: DefaultMaxOutput,
+	}
+}
+
+// SetMaxOutput limits the captured output to limit bytes, after which further
+// output is dropped and a truncation marker is added. Zero or less uses
+// DefaultMaxOutput.
+func (c *ConsoleModule) SetMaxOutput(limit int) {
+	if limit <= 0 {
+		limit = DefaultMaxOutput
 	}
+	c.maxOutput = limit
+}
+
+// SetListener calls listener with every line added to the captured output as
+// it is written, e.g. to stream the output of a long execution. Lines dropped
+// once the output is truncated are not passed on.
+func (c *ConsoleModule) SetListener(listener func(line string)) {
+	c.listener = listener
+}
+
+// NewConsoleModuleWithTee creates a console module that also forwards every
+// message to the internal logger, prefixed with prefix
+func NewConsoleModuleWithTee(output *strings.Builder, prefix string) *ConsoleModule {
+	c := NewConsoleModule(output)
+	c.tee = true
+	c.logPrefix = prefix
+	return c
 }
 
 // Name returns the module name
@@ -39,10 +80,45 @@ func (c *ConsoleModule) formatArgs(args []sobek.Value) string {
 
 // writeMessage writes a message to the output
 func (c *ConsoleModule) writeMessage(message string) {
-	if c.output != nil {
-		c.output.WriteString(message)
-		c.output.WriteString("\n")
+	if c.output == nil || c.truncated {
+		return
+	}
+	if c.listener != nil {
+		c.listener(message)
+	}
+	line := message + "\n"
+	if remaining := c.maxOutput - c.output.Len(); len(line) > remaining {
+		// Keep what fits without splitting a UTF-8 sequence
+		cut := max(remaining, 0)
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		c.output.WriteString(line[:cut])
+		if cut > 0 && line[cut-1] != '\n' {
+			c.output.WriteString("\n")
+		}
+		c.output.WriteString(truncatedMarker)
+		c.truncated = true
+		return
+	}
+	c.output.WriteString(line)
+}
+
+// WriteLine adds a line to the captured output, subject to the same limit as
+// console messages
+func (c *ConsoleModule) WriteLine(message string) {
+	c.writeMessage(message)
+}
+
+// logMessage forwards a message to the internal logger when tee is enabled
+func (c *ConsoleModule) logMessage(log func(msg interface{}, keyvals ...interface{}), message string) {
+	if !c.tee {
+		return
+	}
+	if c.logPrefix != "" {
+		message = c.logPrefix + " " + message
 	}
+	log(message)
 }
 
 // GetOutput returns the captured console output
@@ -61,6 +137,7 @@ func (c *ConsoleModule) Setup(runtime *sobek.Runtime) error {
 	console.Set("log", func(call sobek.FunctionCall) sobek.Value {
 		message := c.formatArgs(call.Arguments)
 		c.writeMessage(message)
+		c.logMessage(logger.Info, message)
 		return sobek.Undefined()
 	})
 
@@ -68,6 +145,7 @@ func (c *ConsoleModule) Setup(runtime *sobek.Runtime) error {
 	console.Set("error", func(call sobek.FunctionCall) sobek.Value {
 		message := c.formatArgs(call.Arguments)
 		c.writeMessage(message)
+		c.logMessage(logger.Error, message)
 		return sobek.Undefined()
 	})
 
@@ -75,6 +153,7 @@ func (c *ConsoleModule) Setup(runtime *sobek.Runtime) error {
 	console.Set("warn", func(call sobek.FunctionCall) sobek.Value {
 		message := c.formatArgs(call.Arguments)
 		c.writeMessage(message)
+		c.logMessage(logger.Warn, message)
 		return sobek.Undefined()
 	})
 
@@ -82,6 +161,7 @@ func (c *ConsoleModule) Setup(runtime *sobek.Runtime) error {
 	console.Set("info", func(call sobek.FunctionCall) sobek.Value {
 		message := c.formatArgs(call.Arguments)
 		c.writeMessage(message)
+		c.logMessage(logger.Info, message)
 		return sobek.Undefined()
 	})
 
@@ -89,6 +169,7 @@ func (c *ConsoleModule) Setup(runtime *sobek.Runtime) error {
 	console.Set("debug", func(call sobek.FunctionCall) sobek.Value {
 		message := c.formatArgs(call.Arguments)
 		c.writeMessage(message)
+		c.logMessage(logger.Debug, message)
 		return sobek.Undefined()
 	})
 
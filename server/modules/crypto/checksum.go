@@ -0,0 +1,61 @@
+package crypto
+
+import (
+	"encoding/binary"
+	"hash/adler32"
+	"hash/crc32"
+	"strings"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// crc32Tables maps the polynomial names accepted by crc32 to their tables
+var crc32Tables = map[string]*crc32.Table{
+	"ieee":       crc32.IEEETable,
+	"castagnoli": crc32.MakeTable(crc32.Castagnoli),
+	"koopman":    crc32.MakeTable(crc32.Koopman),
+}
+
+// crc32 handles crypto.crc32(data, polynomial?), where polynomial is "ieee"
+// (the default), "castagnoli" or "koopman", or an object with a polynomial field
+func (c *CryptoModule) crc32(runtime *sobek.Runtime, call sobek.FunctionCall) sobek.Value {
+	polynomial := "ieee"
+	switch opt := call.Argument(1); {
+	case sobek.IsUndefined(opt) || sobek.IsNull(opt):
+	case isObject(opt):
+		if v := opt.ToObject(runtime).Get("polynomial"); v != nil && !sobek.IsUndefined(v) {
+			polynomial = v.String()
+		}
+	default:
+		polynomial = opt.String()
+	}
+
+	table, ok := crc32Tables[strings.ToLower(polynomial)]
+	if !ok {
+		panic(vm.NewTypeError(runtime, "crypto", vm.CodeNotSupported, "unsupported crc32 polynomial: "+polynomial))
+	}
+	return newChecksumObject(runtime, crc32.Checksum(c.toBytes(runtime, call.Argument(0)), table))
+}
+
+// adler32 handles crypto.adler32(data)
+func (c *CryptoModule) adler32(runtime *sobek.Runtime, call sobek.FunctionCall) sobek.Value {
+	return newChecksumObject(runtime, adler32.Checksum(c.toBytes(runtime, call.Argument(0))))
+}
+
+// newChecksumObject returns an Encoder over the big-endian checksum bytes with
+// the numeric checksum as its value, also used when it is compared as a number
+func newChecksumObject(runtime *sobek.Runtime, sum uint32) *sobek.Object {
+	obj := newEncoderObject(runtime, binary.BigEndian.AppendUint32(nil, sum))
+	obj.Set("value", sum)
+	obj.Set("valueOf", func(call sobek.FunctionCall) sobek.Value {
+		return runtime.ToValue(sum)
+	})
+	return obj
+}
+
+// isObject reports whether v is a JavaScript object
+func isObject(v sobek.Value) bool {
+	_, ok := v.(*sobek.Object)
+	return ok
+}
@@ -0,0 +1,104 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"strconv"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// cbcKeySizes maps the supported AES-CBC algorithms to their key length in bytes
+var cbcKeySizes = map[string]int{
+	"aes-128-cbc": 16,
+	"aes-192-cbc": 24,
+	"aes-256-cbc": 32,
+}
+
+// cbcArgs validates the algorithm, key and iv arguments of encrypt and decrypt
+func (c *CryptoModule) cbcArgs(runtime *sobek.Runtime, call sobek.FunctionCall, fn string) (cipher.Block, []byte) {
+	if len(call.Arguments) < 4 {
+		panic(vm.NewTypeError(runtime, "crypto", vm.CodeInvalidArgument, fn+" requires algorithm, key, data and iv"))
+	}
+	algorithm := call.Argument(0).String()
+	keySize, ok := cbcKeySizes[algorithm]
+	if !ok {
+		panic(vm.NewTypeError(runtime, "crypto", vm.CodeNotSupported, "unsupported cipher algorithm: "+algorithm))
+	}
+
+	key := c.keyBytes(runtime, call.Argument(1), "key")
+	if len(key) != keySize {
+		panic(vm.NewTypeError(runtime, "crypto", vm.CodeInvalidArgument,
+			algorithm+" key must be "+strconv.Itoa(keySize)+" bytes, got "+strconv.Itoa(len(key))))
+	}
+	iv := c.keyBytes(runtime, call.Argument(3), "iv")
+	if len(iv) != aes.BlockSize {
+		panic(vm.NewTypeError(runtime, "crypto", vm.CodeInvalidArgument,
+			"iv must be "+strconv.Itoa(aes.BlockSize)+" bytes, got "+strconv.Itoa(len(iv))))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(vm.NewError(runtime, "crypto", vm.CodeOperationFailed, err))
+	}
+	return block, iv
+}
+
+// encrypt handles crypto.encrypt(algorithm, key, data, iv), returning the
+// PKCS#7 padded ciphertext as an Encoder
+func (c *CryptoModule) encrypt(runtime *sobek.Runtime, call sobek.FunctionCall) sobek.Value {
+	block, iv := c.cbcArgs(runtime, call, "encrypt")
+	plaintext := pkcs7Pad(c.toBytes(runtime, call.Argument(2)), aes.BlockSize)
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, plaintext)
+	return newEncoderObject(runtime, ciphertext)
+}
+
+// decrypt handles crypto.decrypt(algorithm, key, data, iv), where data is an
+// Encoder, a hex string or binary data, returning the plaintext as an Encoder
+func (c *CryptoModule) decrypt(runtime *sobek.Runtime, call sobek.FunctionCall) sobek.Value {
+	block, iv := c.cbcArgs(runtime, call, "decrypt")
+	ciphertext := c.keyBytes(runtime, call.Argument(2), "data")
+	if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		panic(vm.NewError(runtime, "crypto", vm.CodeInvalidArgument, errCiphertextSize))
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+	unpadded, err := pkcs7Unpad(plaintext, aes.BlockSize)
+	if err != nil {
+		panic(vm.NewError(runtime, "crypto", vm.CodeOperationFailed, err))
+	}
+	return newEncoderObject(runtime, unpadded)
+}
+
+// pkcs7Pad appends PKCS#7 padding up to a multiple of blockSize
+func pkcs7Pad(data []byte, blockSize int) []byte {
+	n := blockSize - len(data)%blockSize
+	return append(append([]byte{}, data...), bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+// pkcs7Unpad strips PKCS#7 padding, failing when it is malformed
+func pkcs7Unpad(data []byte, blockSize int) ([]byte, error) {
+	if len(data) == 0 || len(data)%blockSize != 0 {
+		return nil, errBadPadding
+	}
+	n := int(data[len(data)-1])
+	if n == 0 || n > blockSize {
+		return nil, errBadPadding
+	}
+	for _, b := range data[len(data)-n:] {
+		if int(b) != n {
+			return nil, errBadPadding
+		}
+	}
+	return data[:len(data)-n], nil
+}
+
+var (
+	errBadPadding     = errors.New("bad decrypt: invalid PKCS#7 padding")
+	errCiphertextSize = errors.New("bad decrypt: ciphertext length must be a non-zero multiple of 16 bytes")
+)
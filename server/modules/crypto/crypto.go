@@ -10,6 +10,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"hash"
+	"strings"
 
 	"github.com/grafana/sobek"
 	"github.com/mark3labs/codebench-mcp/server/vm"
@@ -43,6 +44,11 @@ func (e *Encoder) base64() string {
 	return base64.StdEncoding.EncodeToString(e.data)
 }
 
+// base64url returns the unpadded URL-safe base64 encoding of the data
+func (e *Encoder) base64url() string {
+	return base64.RawURLEncoding.EncodeToString(e.data)
+}
+
 // bytes returns the raw bytes
 func (e *Encoder) bytes() []byte {
 	return e.data
@@ -87,7 +93,7 @@ func (c *CryptoModule) createCryptoObject(runtime *sobek.Runtime) sobek.Value {
 	// HMAC functions
 	crypto.Set("hmac", func(call sobek.FunctionCall) sobek.Value {
 		if len(call.Arguments) < 3 {
-			panic(runtime.NewTypeError("hmac requires algorithm, key, and data"))
+			panic(vm.NewTypeError(runtime, "crypto", vm.CodeInvalidArgument, "hmac requires algorithm, key, and data"))
 		}
 		algorithm := call.Argument(0).String()
 		key := call.Argument(1)
@@ -95,18 +101,68 @@ func (c *CryptoModule) createCryptoObject(runtime *sobek.Runtime) sobek.Value {
 		return c.hmac(runtime, algorithm, key, data)
 	})
 
+	crypto.Set("hmacVerify", func(call sobek.FunctionCall) sobek.Value {
+		if len(call.Arguments) < 4 {
+			panic(vm.NewTypeError(runtime, "crypto", vm.CodeInvalidArgument, "hmacVerify requires algorithm, key, data, and expected"))
+		}
+		return runtime.ToValue(c.hmacVerify(runtime, call.Argument(0).String(), call.Argument(1), call.Argument(2), call.Argument(3).String()))
+	})
+
+	// Checksums
+	crypto.Set("crc32", func(call sobek.FunctionCall) sobek.Value {
+		return c.crc32(runtime, call)
+	})
+
+	crypto.Set("adler32", func(call sobek.FunctionCall) sobek.Value {
+		return c.adler32(runtime, call)
+	})
+
+	// Incremental hashing
+	crypto.Set("createHash", func(call sobek.FunctionCall) sobek.Value {
+		algorithm := strings.ToLower(call.Argument(0).String())
+		hasher := c.getHasher(algorithm)
+		if hasher == nil {
+			panic(vm.NewTypeError(runtime, "crypto", vm.CodeNotSupported, "unsupported hash algorithm: "+algorithm))
+		}
+		return c.newHashObject(runtime, hasher)
+	})
+
+	crypto.Set("createHmac", func(call sobek.FunctionCall) sobek.Value {
+		if len(call.Arguments) < 2 {
+			panic(vm.NewTypeError(runtime, "crypto", vm.CodeInvalidArgument, "createHmac requires algorithm and key"))
+		}
+		algorithm := strings.ToLower(call.Argument(0).String())
+		if c.getHasher(algorithm) == nil {
+			panic(vm.NewTypeError(runtime, "crypto", vm.CodeNotSupported, "unsupported hash algorithm: "+algorithm))
+		}
+		h := hmac.New(func() hash.Hash { return c.getHasher(algorithm) }, c.toBytes(runtime, call.Argument(1)))
+		return c.newHashObject(runtime, h)
+	})
+
+	// AES-CBC encryption with PKCS#7 padding
+	crypto.Set("encrypt", func(call sobek.FunctionCall) sobek.Value {
+		return c.encrypt(runtime, call)
+	})
+
+	crypto.Set("decrypt", func(call sobek.FunctionCall) sobek.Value {
+		return c.decrypt(runtime, call)
+	})
+
+	// Ed25519 signatures
+	crypto.Set("ed25519", c.createEd25519Object(runtime))
+
 	// Random bytes
 	crypto.Set("randomBytes", func(call sobek.FunctionCall) sobek.Value {
 		if len(call.Arguments) == 0 {
-			panic(runtime.NewTypeError("randomBytes requires size argument"))
+			panic(vm.NewTypeError(runtime, "crypto", vm.CodeInvalidArgument, "randomBytes requires size argument"))
 		}
 		size := int(call.Argument(0).ToInteger())
 		if size < 1 {
-			panic(runtime.NewTypeError("invalid size"))
+			panic(vm.NewTypeError(runtime, "crypto", vm.CodeInvalidArgument, "invalid size"))
 		}
 		bytes := make([]byte, size)
 		if _, err := rand.Read(bytes); err != nil {
-			panic(runtime.NewGoError(err))
+			panic(vm.NewError(runtime, "crypto", vm.CodeOperationFailed, err))
 		}
 		return runtime.ToValue(bytes)
 	})
@@ -117,50 +173,75 @@ func (c *CryptoModule) createCryptoObject(runtime *sobek.Runtime) sobek.Value {
 // hash performs hashing with the specified algorithm
 func (c *CryptoModule) hash(runtime *sobek.Runtime, algorithm string, args []sobek.Value) sobek.Value {
 	if len(args) == 0 {
-		panic(runtime.NewTypeError("hash function requires data argument"))
+		panic(vm.NewTypeError(runtime, "crypto", vm.CodeInvalidArgument, "hash function requires data argument"))
 	}
 
-	data := c.toBytes(args[0])
+	data := c.toBytes(runtime, args[0])
 	hasher := c.getHasher(algorithm)
 	if hasher == nil {
-		panic(runtime.NewTypeError("unsupported hash algorithm: " + algorithm))
+		panic(vm.NewTypeError(runtime, "crypto", vm.CodeNotSupported, "unsupported hash algorithm: "+algorithm))
 	}
 
 	hasher.Write(data)
 	result := hasher.Sum(nil)
 
-	encoder := &Encoder{data: result}
-
-	// Create encoder object with methods
-	encoderObj := runtime.NewObject()
-	encoderObj.Set("hex", func(call sobek.FunctionCall) sobek.Value {
-		return runtime.ToValue(encoder.hex())
-	})
-	encoderObj.Set("base64", func(call sobek.FunctionCall) sobek.Value {
-		return runtime.ToValue(encoder.base64())
-	})
-	encoderObj.Set("bytes", func(call sobek.FunctionCall) sobek.Value {
-		return runtime.ToValue(encoder.bytes())
-	})
-
-	return encoderObj
+	return newEncoderObject(runtime, result)
 }
 
 // hmac performs HMAC with the specified algorithm
 func (c *CryptoModule) hmac(runtime *sobek.Runtime, algorithm string, key, data sobek.Value) sobek.Value {
-	keyBytes := c.toBytes(key)
-	dataBytes := c.toBytes(data)
+	keyBytes := c.toBytes(runtime, key)
+	dataBytes := c.toBytes(runtime, data)
 
 	hasher := c.getHasher(algorithm)
 	if hasher == nil {
-		panic(runtime.NewTypeError("unsupported hash algorithm: " + algorithm))
+		panic(vm.NewTypeError(runtime, "crypto", vm.CodeNotSupported, "unsupported hash algorithm: "+algorithm))
 	}
 
 	h := hmac.New(func() hash.Hash { return c.getHasher(algorithm) }, keyBytes)
 	h.Write(dataBytes)
 	result := h.Sum(nil)
 
-	encoder := &Encoder{data: result}
+	return newEncoderObject(runtime, result)
+}
+
+// hmacVerify recomputes the HMAC of data and compares it in constant time
+// against expected, given as hex, base64 or base64url
+func (c *CryptoModule) hmacVerify(runtime *sobek.Runtime, algorithm string, key, data sobek.Value, expected string) bool {
+	if c.getHasher(algorithm) == nil {
+		panic(vm.NewTypeError(runtime, "crypto", vm.CodeNotSupported, "unsupported hash algorithm: "+algorithm))
+	}
+
+	h := hmac.New(func() hash.Hash { return c.getHasher(algorithm) }, c.toBytes(runtime, key))
+	h.Write(c.toBytes(runtime, data))
+	actual := h.Sum(nil)
+
+	want, ok := decodeDigest(expected, len(actual))
+	if !ok {
+		return false
+	}
+	return hmac.Equal(actual, want)
+}
+
+// decodeDigest decodes a digest of size bytes given as hex or base64,
+// telling the two apart by length
+func decodeDigest(s string, size int) ([]byte, bool) {
+	if len(s) == hex.EncodedLen(size) {
+		if data, err := hex.DecodeString(s); err == nil {
+			return data, true
+		}
+	}
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if data, err := encoding.DecodeString(s); err == nil && len(data) == size {
+			return data, true
+		}
+	}
+	return nil, false
+}
+
+// newEncoderObject exposes data through the hex, base64 and bytes methods of an Encoder
+func newEncoderObject(runtime *sobek.Runtime, data []byte) *sobek.Object {
+	encoder := &Encoder{data: data}
 
 	// Create encoder object with methods
 	encoderObj := runtime.NewObject()
@@ -170,6 +251,9 @@ func (c *CryptoModule) hmac(runtime *sobek.Runtime, algorithm string, key, data
 	encoderObj.Set("base64", func(call sobek.FunctionCall) sobek.Value {
 		return runtime.ToValue(encoder.base64())
 	})
+	encoderObj.Set("base64url", func(call sobek.FunctionCall) sobek.Value {
+		return runtime.ToValue(encoder.base64url())
+	})
 	encoderObj.Set("bytes", func(call sobek.FunctionCall) sobek.Value {
 		return runtime.ToValue(encoder.bytes())
 	})
@@ -196,22 +280,39 @@ func (c *CryptoModule) getHasher(algorithm string) hash.Hash {
 }
 
 // toBytes converts a Sobek value to bytes
-func (c *CryptoModule) toBytes(value sobek.Value) []byte {
+func (c *CryptoModule) toBytes(runtime *sobek.Runtime, value sobek.Value) []byte {
 	if value == nil || sobek.IsUndefined(value) || sobek.IsNull(value) {
 		return []byte{}
 	}
 
 	// Try to get as bytes first
 	if exported := value.Export(); exported != nil {
-		if bytes, ok := exported.([]byte); ok {
-			return bytes
+		switch v := exported.(type) {
+		case []byte:
+			return v
+		case sobek.ArrayBuffer:
+			return v.Bytes()
 		}
 	}
 
+	// Typed arrays and DataViews expose their underlying buffer
+	if view, ok := bufferView(runtime, value); ok {
+		return view
+	}
+
 	// Convert to string and then bytes
 	return []byte(value.String())
 }
 
+// Warmup initializes the hash implementations and the random source
+func (c *CryptoModule) Warmup() error {
+	for _, algorithm := range []string{"md5", "sha1", "sha256", "sha384", "sha512"} {
+		c.getHasher(algorithm).Sum(nil)
+	}
+	_, err := rand.Read(make([]byte, 1))
+	return err
+}
+
 // Cleanup performs any necessary cleanup
 func (c *CryptoModule) Cleanup() error {
 	// Crypto module doesn't need cleanup
@@ -0,0 +1,88 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// createEd25519Object creates the crypto.ed25519 namespace
+func (c *CryptoModule) createEd25519Object(runtime *sobek.Runtime) *sobek.Object {
+	obj := runtime.NewObject()
+
+	// generateKeyPair() - returns { publicKey, privateKey } as Encoders
+	obj.Set("generateKeyPair", func(call sobek.FunctionCall) sobek.Value {
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			panic(vm.NewError(runtime, "crypto", vm.CodeOperationFailed, err))
+		}
+		pair := runtime.NewObject()
+		pair.Set("publicKey", newEncoderObject(runtime, publicKey))
+		pair.Set("privateKey", newEncoderObject(runtime, privateKey))
+		return pair
+	})
+
+	// sign(privateKey, message) - returns the signature as an Encoder
+	obj.Set("sign", func(call sobek.FunctionCall) sobek.Value {
+		if len(call.Arguments) < 2 {
+			panic(vm.NewTypeError(runtime, "crypto", vm.CodeInvalidArgument, "ed25519.sign requires privateKey and message"))
+		}
+		key := c.keyBytes(runtime, call.Argument(0), "privateKey")
+		var privateKey ed25519.PrivateKey
+		switch len(key) {
+		case ed25519.PrivateKeySize:
+			privateKey = ed25519.PrivateKey(key)
+		case ed25519.SeedSize:
+			privateKey = ed25519.NewKeyFromSeed(key)
+		default:
+			panic(vm.NewTypeError(runtime, "crypto", vm.CodeInvalidArgument,
+				"ed25519 private key must be "+strconv.Itoa(ed25519.PrivateKeySize)+" bytes or a "+strconv.Itoa(ed25519.SeedSize)+" byte seed"))
+		}
+		return newEncoderObject(runtime, ed25519.Sign(privateKey, c.toBytes(runtime, call.Argument(1))))
+	})
+
+	// verify(publicKey, message, signature) - reports whether signature is valid
+	obj.Set("verify", func(call sobek.FunctionCall) sobek.Value {
+		if len(call.Arguments) < 3 {
+			panic(vm.NewTypeError(runtime, "crypto", vm.CodeInvalidArgument, "ed25519.verify requires publicKey, message and signature"))
+		}
+		publicKey := c.keyBytes(runtime, call.Argument(0), "publicKey")
+		if len(publicKey) != ed25519.PublicKeySize {
+			panic(vm.NewTypeError(runtime, "crypto", vm.CodeInvalidArgument,
+				"ed25519 public key must be "+strconv.Itoa(ed25519.PublicKeySize)+" bytes"))
+		}
+		signature := c.keyBytes(runtime, call.Argument(2), "signature")
+		if len(signature) != ed25519.SignatureSize {
+			return runtime.ToValue(false)
+		}
+		return runtime.ToValue(ed25519.Verify(publicKey, c.toBytes(runtime, call.Argument(1)), signature))
+	})
+
+	return obj
+}
+
+// keyBytes converts a key or signature given as an Encoder, a hex string or
+// binary data to bytes
+func (c *CryptoModule) keyBytes(runtime *sobek.Runtime, value sobek.Value, name string) []byte {
+	if obj, ok := value.(*sobek.Object); ok {
+		if bytesFn, ok := sobek.AssertFunction(obj.Get("bytes")); ok {
+			result, err := bytesFn(obj)
+			if err != nil {
+				panic(err)
+			}
+			return c.toBytes(runtime, result)
+		}
+	}
+	if s, ok := value.Export().(string); ok {
+		data, err := hex.DecodeString(s)
+		if err != nil {
+			panic(vm.NewTypeError(runtime, "crypto", vm.CodeInvalidArgument, name+" string must be hex encoded"))
+		}
+		return data
+	}
+	return c.toBytes(runtime, value)
+}
@@ -0,0 +1,56 @@
+package crypto
+
+import (
+	"errors"
+	"hash"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// newHashObject wraps h in a stateful object with update(data) and
+// digest(encoding?), as returned by createHash and createHmac
+func (c *CryptoModule) newHashObject(runtime *sobek.Runtime, h hash.Hash) *sobek.Object {
+	obj := runtime.NewObject()
+	finished := false
+
+	ensureActive := func() {
+		if finished {
+			panic(vm.NewError(runtime, "crypto", vm.CodeInvalidArgument, errDigestCalled))
+		}
+	}
+
+	// update(data) - adds a string, Buffer, ArrayBuffer or typed array; returns the hash for chaining
+	obj.Set("update", func(call sobek.FunctionCall) sobek.Value {
+		ensureActive()
+		h.Write(c.toBytes(runtime, call.Argument(0)))
+		return obj
+	})
+
+	// digest(encoding?) - returns a "hex", "base64" or "base64url" string, or an Encoder without an encoding
+	obj.Set("digest", func(call sobek.FunctionCall) sobek.Value {
+		ensureActive()
+		finished = true
+		result := h.Sum(nil)
+
+		encoding := call.Argument(0)
+		if sobek.IsUndefined(encoding) {
+			return newEncoderObject(runtime, result)
+		}
+		encoder := &Encoder{data: result}
+		switch encoding.String() {
+		case "hex":
+			return runtime.ToValue(encoder.hex())
+		case "base64":
+			return runtime.ToValue(encoder.base64())
+		case "base64url":
+			return runtime.ToValue(encoder.base64url())
+		default:
+			panic(vm.NewTypeError(runtime, "crypto", vm.CodeNotSupported, "unsupported digest encoding: "+encoding.String()))
+		}
+	})
+
+	return obj
+}
+
+var errDigestCalled = errors.New("digest already called")
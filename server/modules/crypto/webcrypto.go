@@ -0,0 +1,115 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/modules/internal/view"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// maxRandomValues is the Web Crypto limit for a single getRandomValues call
+const maxRandomValues = 65536
+
+// GetGlobalName returns the global name for this module
+func (c *CryptoModule) GetGlobalName() string {
+	return "crypto"
+}
+
+// CreateGlobalObject creates the Web Crypto compatible global crypto object,
+// layered on the functions available through require('crypto')
+func (c *CryptoModule) CreateGlobalObject(runtime *sobek.Runtime) sobek.Value {
+	crypto := c.createCryptoObject(runtime).(*sobek.Object)
+
+	// randomUUID() - returns a random RFC 4122 version 4 UUID
+	crypto.Set("randomUUID", func(call sobek.FunctionCall) sobek.Value {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			panic(vm.NewError(runtime, "crypto", vm.CodeOperationFailed, err))
+		}
+		b[6] = (b[6] & 0x0f) | 0x40 // version 4
+		b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+		return runtime.ToValue(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]))
+	})
+
+	// getRandomValues(typedArray) - fills an integer typed array in place
+	crypto.Set("getRandomValues", func(call sobek.FunctionCall) sobek.Value {
+		arg := call.Argument(0)
+		obj, ok := arg.(*sobek.Object)
+		if !ok || !isIntegerTypedArray(runtime, obj) {
+			panic(vm.NewTypeError(runtime, "crypto", vm.CodeInvalidArgument, "getRandomValues: argument must be an integer typed array"))
+		}
+		data, _ := bufferView(runtime, obj)
+		if len(data) > maxRandomValues {
+			panic(vm.NewNamedError(runtime, "crypto", vm.CodeQuotaExceeded, "QuotaExceededError",
+				fmt.Sprintf("getRandomValues: byte length %d exceeds %d", len(data), maxRandomValues)))
+		}
+		if _, err := rand.Read(data); err != nil {
+			panic(vm.NewError(runtime, "crypto", vm.CodeOperationFailed, err))
+		}
+		return arg
+	})
+
+	// subtle.digest(algorithm, data) - resolves to an ArrayBuffer with the digest
+	subtle := runtime.NewObject()
+	subtle.Set("digest", func(call sobek.FunctionCall) sobek.Value {
+		algorithm := call.Argument(0)
+		if obj, ok := algorithm.(*sobek.Object); ok {
+			algorithm = obj.Get("name")
+		}
+		if algorithm == nil || sobek.IsUndefined(algorithm) {
+			panic(vm.NewTypeError(runtime, "crypto", vm.CodeInvalidArgument, "digest: algorithm is required"))
+		}
+		name := webCryptoHashName(algorithm.String())
+		hasher := c.getHasher(name)
+		if name == "md5" || hasher == nil {
+			panic(vm.NewNamedError(runtime, "crypto", vm.CodeNotSupported, "NotSupportedError", "Unrecognized algorithm name: "+algorithm.String()))
+		}
+
+		// Copy the input so later mutations don't affect the pending digest
+		data := append([]byte(nil), c.toBytes(runtime, call.Argument(1))...)
+
+		promise, resolve, _ := runtime.NewPromise()
+		enqueue := vm.EnqueueJob(runtime)
+		go func() {
+			hasher.Write(data)
+			sum := hasher.Sum(nil)
+			enqueue(func() error {
+				return resolve(runtime.NewArrayBuffer(sum))
+			})
+		}()
+		return runtime.ToValue(promise)
+	})
+	crypto.Set("subtle", subtle)
+
+	return crypto
+}
+
+// webCryptoHashName maps Web Crypto names like "SHA-256" to getHasher names
+func webCryptoHashName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "-", "")
+}
+
+// isIntegerTypedArray reports whether obj is an integer typed array. It goes
+// by the type the array exports as rather than its constructor, which
+// scripts can spoof.
+func isIntegerTypedArray(runtime *sobek.Runtime, obj *sobek.Object) bool {
+	switch obj.Export().(type) {
+	case []int8, []uint8, []int16, []uint16, []int32, []uint32, []int64, []uint64:
+		_, ok := bufferView(runtime, obj)
+		return ok
+	}
+	return false
+}
+
+// bufferView returns the bytes viewed by a typed array or DataView, sharing
+// its underlying buffer
+func bufferView(runtime *sobek.Runtime, value sobek.Value) ([]byte, bool) {
+	obj, ok := value.(*sobek.Object)
+	if !ok {
+		return nil, false
+	}
+	return view.Bytes(runtime, "crypto", obj)
+}
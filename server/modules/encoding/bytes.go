@@ -0,0 +1,107 @@
+package encoding
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/modules/internal/view"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// CreateModuleObject creates the byte encoding helpers returned by require('encoding')
+func (e *EncodingModule) CreateModuleObject(runtime *sobek.Runtime) sobek.Value {
+	obj := runtime.NewObject()
+
+	// toBase64(bytes) - encodes bytes as a padded base64 string
+	obj.Set("toBase64", func(call sobek.FunctionCall) sobek.Value {
+		return runtime.ToValue(base64.StdEncoding.EncodeToString(toBytes(runtime, call.Argument(0))))
+	})
+
+	// fromBase64(str) - decodes a base64 string, with or without padding, to a Uint8Array
+	obj.Set("fromBase64", func(call sobek.FunctionCall) sobek.Value {
+		s := call.Argument(0).String()
+		data, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			if data, err = base64.RawStdEncoding.DecodeString(s); err != nil {
+				panic(vm.NewTypeError(runtime, "encoding", vm.CodeInvalidArgument, "fromBase64: invalid base64 string"))
+			}
+		}
+		return newUint8Array(runtime, data)
+	})
+
+	// toBase64Url(bytes) - encodes bytes as an unpadded URL-safe base64 string
+	obj.Set("toBase64Url", func(call sobek.FunctionCall) sobek.Value {
+		return runtime.ToValue(base64.RawURLEncoding.EncodeToString(toBytes(runtime, call.Argument(0))))
+	})
+
+	// fromBase64Url(str) - decodes a URL-safe base64 string, with or without padding, to a Uint8Array
+	obj.Set("fromBase64Url", func(call sobek.FunctionCall) sobek.Value {
+		data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(call.Argument(0).String(), "="))
+		if err != nil {
+			panic(vm.NewTypeError(runtime, "encoding", vm.CodeInvalidArgument, "fromBase64Url: invalid base64url string"))
+		}
+		return newUint8Array(runtime, data)
+	})
+
+	// toHex(bytes) - encodes bytes as a lower-case hex string
+	obj.Set("toHex", func(call sobek.FunctionCall) sobek.Value {
+		return runtime.ToValue(hex.EncodeToString(toBytes(runtime, call.Argument(0))))
+	})
+
+	// fromHex(str) - decodes a hex string to a Uint8Array
+	obj.Set("fromHex", func(call sobek.FunctionCall) sobek.Value {
+		data, err := hex.DecodeString(call.Argument(0).String())
+		if err != nil {
+			panic(vm.NewTypeError(runtime, "encoding", vm.CodeInvalidArgument, "fromHex: invalid hex string"))
+		}
+		return newUint8Array(runtime, data)
+	})
+
+	return obj
+}
+
+// toBytes converts a typed array, DataView, ArrayBuffer, array of numbers or
+// string (as UTF-8) to bytes
+func toBytes(runtime *sobek.Runtime, value sobek.Value) []byte {
+	if value == nil || sobek.IsUndefined(value) || sobek.IsNull(value) {
+		return []byte{}
+	}
+
+	switch v := value.Export().(type) {
+	case []byte:
+		return v
+	case sobek.ArrayBuffer:
+		return v.Bytes()
+	case []any:
+		data := make([]byte, len(v))
+		for i, val := range v {
+			switch num := val.(type) {
+			case int64:
+				data[i] = byte(num)
+			case float64:
+				data[i] = byte(int(num))
+			}
+		}
+		return data
+	}
+
+	// Typed arrays and DataViews expose their underlying buffer
+	if obj, ok := value.(*sobek.Object); ok {
+		if data, ok := view.Bytes(runtime, "encoding", obj); ok {
+			return data
+		}
+	}
+
+	return []byte(value.String())
+}
+
+// newUint8Array creates a Uint8Array over data
+func newUint8Array(runtime *sobek.Runtime, data []byte) sobek.Value {
+	arr, err := runtime.New(runtime.Get("Uint8Array"), runtime.ToValue(runtime.NewArrayBuffer(data)))
+	if err != nil {
+		panic(err)
+	}
+	return arr
+}
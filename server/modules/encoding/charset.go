@@ -0,0 +1,33 @@
+package encoding
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// Decode converts data in the character encoding named by label, any WHATWG
+// encoding label such as "utf-8", "latin1" or "shift_jis", to a string.
+// UTF-8 data is returned as is.
+func Decode(label string, data []byte) (string, error) {
+	enc, name := charset.Lookup(strings.TrimSpace(label))
+	if enc == nil {
+		return "", fmt.Errorf("unknown encoding %q", label)
+	}
+	if name == "utf-8" {
+		return string(data), nil
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// Known reports whether label names a supported encoding, returning its
+// canonical name
+func Known(label string) (string, bool) {
+	enc, name := charset.Lookup(strings.TrimSpace(label))
+	return name, enc != nil
+}
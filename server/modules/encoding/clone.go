@@ -0,0 +1,262 @@
+package encoding
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// cloner deep-copies JavaScript values for structuredClone
+type cloner struct {
+	rt       *sobek.Runtime
+	toString sobek.Callable
+	*brands
+	memo   map[*sobek.Object]sobek.Value // completed clones, so shared references stay shared
+	active map[*sobek.Object]bool        // objects currently being cloned, to detect cycles
+}
+
+// brands holds built-in methods and getters that only work on objects of
+// their own type, taken before any script runs. Object.prototype.toString
+// honors a script's Symbol.toStringTag, so its tag alone proves nothing.
+type brands struct {
+	checks        map[string]sobek.Callable // by tag, throwing for other objects
+	typedArrayTag sobek.Callable            // the real name of a typed array, undefined for other objects
+	errorProto    *sobek.Object
+}
+
+// newBrands collects the brand checks from the built-ins of runtime
+func newBrands(runtime *sobek.Runtime) *brands {
+	prototype := func(name string) *sobek.Object {
+		return runtime.Get(name).ToObject(runtime).Get("prototype").ToObject(runtime)
+	}
+	method := func(name, key string) sobek.Callable {
+		fn, _ := sobek.AssertFunction(prototype(name).Get(key))
+		return fn
+	}
+	describe, _ := sobek.AssertFunction(runtime.Get("Object").ToObject(runtime).Get("getOwnPropertyDescriptor"))
+	getter := func(obj *sobek.Object, key sobek.Value) sobek.Callable {
+		desc, err := describe(sobek.Undefined(), obj, key)
+		if err != nil {
+			panic(err)
+		}
+		fn, _ := sobek.AssertFunction(desc.ToObject(runtime).Get("get"))
+		return fn
+	}
+	isArray, _ := sobek.AssertFunction(runtime.Get("Array").ToObject(runtime).Get("isArray"))
+
+	return &brands{
+		checks: map[string]sobek.Callable{
+			"Date":     method("Date", "getTime"),
+			"RegExp":   getter(prototype("RegExp"), runtime.ToValue("source")),
+			"Boolean":  method("Boolean", "valueOf"),
+			"Number":   method("Number", "valueOf"),
+			"String":   method("String", "valueOf"),
+			"DataView": getter(prototype("DataView"), runtime.ToValue("byteLength")),
+			"Map":      getter(prototype("Map"), runtime.ToValue("size")),
+			"Set":      getter(prototype("Set"), runtime.ToValue("size")),
+			"Array": func(this sobek.Value, _ ...sobek.Value) (sobek.Value, error) {
+				result, err := isArray(sobek.Undefined(), this)
+				if err == nil && !result.ToBoolean() {
+					err = errors.New("not an array")
+				}
+				return result, err
+			},
+		},
+		typedArrayTag: getter(prototype("Uint8Array").Prototype(), sobek.SymToStringTag),
+		errorProto:    prototype("Error"),
+	}
+}
+
+// setupStructuredClone sets up the global structuredClone function
+func (e *EncodingModule) setupStructuredClone(runtime *sobek.Runtime) {
+	objectProto := runtime.Get("Object").ToObject(runtime).Get("prototype").ToObject(runtime)
+	toString, _ := sobek.AssertFunction(objectProto.Get("toString"))
+	brands := newBrands(runtime)
+
+	runtime.Set("structuredClone", func(call sobek.FunctionCall) sobek.Value {
+		if len(call.Arguments) == 0 {
+			panic(vm.NewTypeError(runtime, "encoding", vm.CodeInvalidArgument, "structuredClone: 1 argument required, but only 0 present"))
+		}
+		c := &cloner{
+			rt:       runtime,
+			toString: toString,
+			brands:   brands,
+			memo:     make(map[*sobek.Object]sobek.Value),
+			active:   make(map[*sobek.Object]bool),
+		}
+		return c.clone(call.Argument(0))
+	})
+}
+
+// clone returns a deep copy of v
+func (c *cloner) clone(v sobek.Value) sobek.Value {
+	if _, ok := v.(*sobek.Symbol); ok {
+		c.throw(v.String() + " could not be cloned.")
+	}
+	obj, ok := v.(*sobek.Object)
+	if !ok {
+		// Primitives are immutable and copied by value
+		return v
+	}
+	if c.active[obj] {
+		c.throw("Circular reference could not be cloned.")
+	}
+	if cloned, ok := c.memo[obj]; ok {
+		return cloned
+	}
+	c.active[obj] = true
+	defer delete(c.active, obj)
+
+	tag := c.tag(obj)
+	switch tag {
+	case "Date", "RegExp":
+		// Both constructors copy an existing instance
+		c.check(tag, obj)
+		return c.remember(obj, c.construct(tag, obj))
+	case "Boolean", "Number", "String":
+		primitive := c.check(tag, obj)
+		return c.remember(obj, primitive.ToObject(c.rt))
+	case "ArrayBuffer":
+		buffer, ok := obj.Export().(sobek.ArrayBuffer)
+		if !ok {
+			c.throw(tag + " object could not be cloned.")
+		}
+		data := buffer.Bytes()
+		copied := make([]byte, len(data))
+		copy(copied, data)
+		return c.remember(obj, c.rt.ToValue(c.rt.NewArrayBuffer(copied)))
+	case "DataView":
+		c.check(tag, obj)
+		buffer := c.clone(obj.Get("buffer"))
+		return c.remember(obj, c.construct(tag, buffer, obj.Get("byteOffset"), obj.Get("byteLength")))
+	case "Map":
+		c.check(tag, obj)
+		result := c.remember(obj, c.construct("Map")).(*sobek.Object)
+		set, _ := sobek.AssertFunction(result.Get("set"))
+		c.forEach(obj, func(value, key sobek.Value) {
+			if _, err := set(result, c.clone(key), c.clone(value)); err != nil {
+				panic(err)
+			}
+		})
+		return result
+	case "Set":
+		c.check(tag, obj)
+		result := c.remember(obj, c.construct("Set")).(*sobek.Object)
+		add, _ := sobek.AssertFunction(result.Get("add"))
+		c.forEach(obj, func(value, _ sobek.Value) {
+			if _, err := add(result, c.clone(value)); err != nil {
+				panic(err)
+			}
+		})
+		return result
+	case "Array":
+		c.check(tag, obj)
+		result := c.rt.NewArray()
+		c.remember(obj, result)
+		length := obj.Get("length").ToInteger()
+		for i := int64(0); i < length; i++ {
+			key := strconv.FormatInt(i, 10)
+			result.Set(key, c.clone(obj.Get(key)))
+		}
+		return result
+	case "Error":
+		if !c.isError(obj) {
+			c.throw(tag + " object could not be cloned.")
+		}
+		name := "Error"
+		if v := obj.Get("name"); v != nil {
+			name = v.String()
+		}
+		switch name {
+		case "Error", "EvalError", "RangeError", "ReferenceError", "SyntaxError", "TypeError", "URIError":
+		default:
+			name = "Error"
+		}
+		result := c.construct(name, obj.Get("message")).(*sobek.Object)
+		if stack := obj.Get("stack"); stack != nil && !sobek.IsUndefined(stack) {
+			result.Set("stack", stack)
+		}
+		return c.remember(obj, result)
+	case "Object", "Arguments":
+		result := c.rt.NewObject()
+		c.remember(obj, result)
+		for _, key := range obj.Keys() {
+			result.Set(key, c.clone(obj.Get(key)))
+		}
+		return result
+	}
+
+	if name, err := c.typedArrayTag(obj); err == nil && name.String() == tag {
+		// Typed arrays are copied together with their underlying buffer
+		buffer := c.clone(obj.Get("buffer"))
+		return c.remember(obj, c.construct(tag, buffer, obj.Get("byteOffset"), obj.Get("length")))
+	}
+
+	c.throw(tag + " object could not be cloned.")
+	return nil
+}
+
+// tag returns the built-in type of obj as reported by Object.prototype.toString
+func (c *cloner) tag(obj *sobek.Object) string {
+	v, err := c.toString(obj)
+	if err != nil {
+		panic(err)
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(v.String(), "[object "), "]")
+}
+
+// check runs the brand check for tag on obj, returning its result, and
+// throws a DataCloneError if obj is not a built-in of that type
+func (c *cloner) check(tag string, obj *sobek.Object) sobek.Value {
+	result, err := c.checks[tag](obj)
+	if err != nil {
+		c.throw(tag + " object could not be cloned.")
+	}
+	return result
+}
+
+// isError reports whether Error.prototype is on the prototype chain of obj,
+// which unlike instanceof doesn't go through Symbol.hasInstance
+func (c *cloner) isError(obj *sobek.Object) bool {
+	for proto := obj.Prototype(); proto != nil; proto = proto.Prototype() {
+		if proto == c.errorProto {
+			return true
+		}
+	}
+	return false
+}
+
+// construct calls the named global constructor with args
+func (c *cloner) construct(name string, args ...sobek.Value) sobek.Value {
+	obj, err := c.rt.New(c.rt.Get(name), args...)
+	if err != nil {
+		panic(err)
+	}
+	return obj
+}
+
+// forEach iterates a Map or Set through its own forEach method
+func (c *cloner) forEach(obj *sobek.Object, fn func(value, key sobek.Value)) {
+	forEach, _ := sobek.AssertFunction(obj.Get("forEach"))
+	_, err := forEach(obj, c.rt.ToValue(func(call sobek.FunctionCall) sobek.Value {
+		fn(call.Argument(0), call.Argument(1))
+		return sobek.Undefined()
+	}))
+	if err != nil {
+		panic(err)
+	}
+}
+
+// remember records the clone of obj and returns it
+func (c *cloner) remember(obj *sobek.Object, cloned sobek.Value) sobek.Value {
+	c.memo[obj] = cloned
+	return cloned
+}
+
+// throw raises a DataCloneError like browsers do for uncloneable values
+func (c *cloner) throw(message string) {
+	panic(vm.NewNamedError(c.rt, "encoding", vm.CodeDataClone, "DataCloneError", message))
+}
@@ -44,8 +44,12 @@ func (e *EncodingModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) er
 		obj := call.This
 
 		encoding := "utf-8"
-		if len(call.Arguments) > 0 {
-			encoding = call.Argument(0).String()
+		if len(call.Arguments) > 0 && !sobek.IsUndefined(call.Argument(0)) {
+			name, ok := Known(call.Argument(0).String())
+			if !ok {
+				panic(vm.NewRangeError(runtime, "encoding", vm.CodeNotSupported, "TextDecoder: unsupported encoding "+call.Argument(0).String()))
+			}
+			encoding = name
 		}
 
 		// decode method
@@ -78,7 +82,11 @@ func (e *EncodingModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) er
 				bytes = []byte(arg.String())
 			}
 
-			return runtime.ToValue(string(bytes))
+			text, err := Decode(encoding, bytes)
+			if err != nil {
+				panic(vm.NewTypeError(runtime, "encoding", vm.CodeInvalidArgument, "TextDecoder: "+err.Error()))
+			}
+			return runtime.ToValue(text)
 		})
 
 		// encoding property
@@ -87,6 +95,18 @@ func (e *EncodingModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) er
 		return nil
 	})
 
+	// TextEncoderStream and TextDecoderStream for chunked text
+	e.setupTextStreams(runtime)
+
+	// structuredClone global for deep-copying values
+	e.setupStructuredClone(runtime)
+
+	// JSON5 global for parsing relaxed JSON
+	e.setupJSON5(runtime)
+
+	// Intl global with basic number and date formatting
+	e.setupIntl(runtime)
+
 	return nil
 }
 
@@ -0,0 +1,616 @@
+package encoding
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// intlLocale holds the formatting conventions of a locale. Only the common
+// cases are covered: month and weekday names are always English, and
+// separators follow ICU, e.g. a no-break space before a trailing € or %.
+type intlLocale struct {
+	tag          string
+	group        string // Thousands separator
+	decimal      string // Decimal separator
+	symbolAfter  bool   // Currency symbol and percent sign follow the number
+	dateOrder    string // "mdy", "dmy" or "ymd"
+	dateSep      string // Separator of numeric dates
+	padDate      bool   // Numeric day and month are two digits by default
+	hour12       bool   // 12-hour clock by default
+	textDayFirst bool   // "15 January 2024" rather than "January 15, 2024"
+}
+
+var intlLocales = map[string]intlLocale{
+	"en-us": {tag: "en-US", group: ",", decimal: ".", dateOrder: "mdy", dateSep: "/", hour12: true},
+	"en-gb": {tag: "en-GB", group: ",", decimal: ".", dateOrder: "dmy", dateSep: "/", padDate: true, textDayFirst: true},
+	"en-in": {tag: "en-IN", group: ",", decimal: ".", dateOrder: "dmy", dateSep: "/", hour12: true, textDayFirst: true},
+	"de":    {tag: "de", group: ".", decimal: ",", symbolAfter: true, dateOrder: "dmy", dateSep: ".", textDayFirst: true},
+	"fr":    {tag: "fr", group: "\u202f", decimal: ",", symbolAfter: true, dateOrder: "dmy", dateSep: "/", padDate: true, textDayFirst: true},
+	"es":    {tag: "es", group: ".", decimal: ",", symbolAfter: true, dateOrder: "dmy", dateSep: "/", textDayFirst: true},
+	"it":    {tag: "it", group: ".", decimal: ",", symbolAfter: true, dateOrder: "dmy", dateSep: "/", textDayFirst: true},
+	"nl":    {tag: "nl", group: ".", decimal: ",", dateOrder: "dmy", dateSep: "-", textDayFirst: true},
+	"pt":    {tag: "pt", group: ".", decimal: ",", dateOrder: "dmy", dateSep: "/", padDate: true, textDayFirst: true},
+	"ja":    {tag: "ja", group: ",", decimal: ".", dateOrder: "ymd", dateSep: "/"},
+	"zh":    {tag: "zh", group: ",", decimal: ".", dateOrder: "ymd", dateSep: "/"},
+}
+
+// Currency symbols and the fraction digits they use; other currencies are
+// shown by their code with two fraction digits
+var intlCurrencies = map[string]struct {
+	symbol string
+	digits int
+}{
+	"USD": {"$", 2},
+	"EUR": {"€", 2},
+	"GBP": {"£", 2},
+	"JPY": {"¥", 0},
+	"CNY": {"CN¥", 2},
+	"INR": {"₹", 2},
+	"KRW": {"₩", 0},
+	"CAD": {"CA$", 2},
+	"AUD": {"A$", 2},
+	"CHF": {"CHF", 2},
+}
+
+// lookupLocale resolves a locale argument (a tag, an array of tags or
+// undefined) to the first known locale, falling back to its language and
+// finally to en-US
+func lookupLocale(runtime *sobek.Runtime, v sobek.Value) intlLocale {
+	var tags []string
+	if v != nil && !sobek.IsUndefined(v) && !sobek.IsNull(v) {
+		if arr, ok := v.Export().([]any); ok {
+			for _, tag := range arr {
+				if s, ok := tag.(string); ok {
+					tags = append(tags, s)
+				}
+			}
+		} else {
+			tags = append(tags, v.String())
+		}
+	}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+		if loc, ok := intlLocales[tag]; ok {
+			return loc
+		}
+		lang, _, _ := strings.Cut(tag, "-")
+		if lang == "en" {
+			// Other regional English variants follow US rules
+			return intlLocales["en-us"]
+		}
+		if loc, ok := intlLocales[lang]; ok {
+			return loc
+		}
+	}
+	return intlLocales["en-us"]
+}
+
+// intlOptions reads formatter options from an optional options object
+type intlOptions struct {
+	runtime *sobek.Runtime
+	obj     *sobek.Object
+}
+
+func newIntlOptions(runtime *sobek.Runtime, v sobek.Value) intlOptions {
+	opts := intlOptions{runtime: runtime}
+	if v != nil && !sobek.IsUndefined(v) && !sobek.IsNull(v) {
+		opts.obj = v.ToObject(runtime)
+	}
+	return opts
+}
+
+// get returns the option named name, or nil if it isn't set
+func (o intlOptions) get(name string) sobek.Value {
+	if o.obj == nil {
+		return nil
+	}
+	v := o.obj.Get(name)
+	if v == nil || sobek.IsUndefined(v) {
+		return nil
+	}
+	return v
+}
+
+// string returns a string option, checking it is one of allowed
+func (o intlOptions) string(name string, allowed ...string) string {
+	v := o.get(name)
+	if v == nil {
+		return ""
+	}
+	s := v.String()
+	for _, a := range allowed {
+		if s == a {
+			return s
+		}
+	}
+	panic(vm.NewRangeError(o.runtime, "encoding", vm.CodeInvalidArgument, "Value "+s+" out of range for Intl option "+name))
+}
+
+// digits returns a fraction digits option between 0 and 20, or def if unset
+func (o intlOptions) digits(name string, def int) int {
+	v := o.get(name)
+	if v == nil {
+		return def
+	}
+	n := v.ToFloat()
+	if math.IsNaN(n) || n < 0 || n > 20 {
+		panic(vm.NewRangeError(o.runtime, "encoding", vm.CodeInvalidArgument, name+" value is out of range."))
+	}
+	return int(n)
+}
+
+// setupIntl sets up the global Intl object with minimal NumberFormat and
+// DateTimeFormat constructors backed by Go formatting
+func (e *EncodingModule) setupIntl(runtime *sobek.Runtime) {
+	intl := runtime.NewObject()
+	intl.Set("NumberFormat", func(call sobek.ConstructorCall) *sobek.Object {
+		newNumberFormat(runtime, call.This, lookupLocale(runtime, call.Argument(0)), newIntlOptions(runtime, call.Argument(1)))
+		return nil
+	})
+	intl.Set("DateTimeFormat", func(call sobek.ConstructorCall) *sobek.Object {
+		newDateTimeFormat(runtime, call.This, lookupLocale(runtime, call.Argument(0)), newIntlOptions(runtime, call.Argument(1)))
+		return nil
+	})
+	runtime.Set("Intl", intl)
+}
+
+// numberFormat is the state of an Intl.NumberFormat instance
+type numberFormat struct {
+	locale       intlLocale
+	style        string // "decimal", "currency" or "percent"
+	currency     string
+	currencyCode bool // Show the currency code instead of its symbol
+	minFraction  int
+	maxFraction  int
+	useGrouping  bool
+}
+
+// newNumberFormat initializes obj as an Intl.NumberFormat
+func newNumberFormat(runtime *sobek.Runtime, obj *sobek.Object, locale intlLocale, opts intlOptions) {
+	f := &numberFormat{locale: locale, useGrouping: true}
+	f.style = opts.string("style", "decimal", "currency", "percent")
+	if f.style == "" {
+		f.style = "decimal"
+	}
+
+	minDefault, maxDefault := 0, 3
+	switch f.style {
+	case "currency":
+		v := opts.get("currency")
+		if v == nil {
+			panic(vm.NewTypeError(runtime, "encoding", vm.CodeInvalidArgument, "Currency code is required with currency style."))
+		}
+		f.currency = strings.ToUpper(v.String())
+		if len(f.currency) != 3 {
+			panic(vm.NewRangeError(runtime, "encoding", vm.CodeInvalidArgument, "Invalid currency code : "+v.String()))
+		}
+		f.currencyCode = opts.string("currencyDisplay", "symbol", "narrowSymbol", "code") == "code"
+		minDefault, maxDefault = 2, 2
+		if c, ok := intlCurrencies[f.currency]; ok {
+			minDefault, maxDefault = c.digits, c.digits
+		}
+	case "percent":
+		maxDefault = 0
+	}
+
+	f.minFraction = opts.digits("minimumFractionDigits", minDefault)
+	if f.minFraction > maxDefault {
+		maxDefault = f.minFraction
+	}
+	f.maxFraction = opts.digits("maximumFractionDigits", maxDefault)
+	if opts.get("maximumFractionDigits") != nil && opts.get("minimumFractionDigits") == nil && f.minFraction > f.maxFraction {
+		f.minFraction = f.maxFraction
+	}
+	if f.minFraction > f.maxFraction {
+		panic(vm.NewRangeError(runtime, "encoding", vm.CodeInvalidArgument, "maximumFractionDigits value is out of range."))
+	}
+	if v := opts.get("useGrouping"); v != nil {
+		f.useGrouping = v.ToBoolean()
+	}
+
+	obj.Set("format", func(call sobek.FunctionCall) sobek.Value {
+		return runtime.ToValue(f.format(call.Argument(0).ToFloat()))
+	})
+	obj.Set("resolvedOptions", func(call sobek.FunctionCall) sobek.Value {
+		resolved := runtime.NewObject()
+		resolved.Set("locale", f.locale.tag)
+		resolved.Set("numberingSystem", "latn")
+		resolved.Set("style", f.style)
+		if f.style == "currency" {
+			resolved.Set("currency", f.currency)
+		}
+		resolved.Set("minimumFractionDigits", f.minFraction)
+		resolved.Set("maximumFractionDigits", f.maxFraction)
+		resolved.Set("useGrouping", f.useGrouping)
+		return resolved
+	})
+}
+
+// format formats n in the locale and style of f
+func (f *numberFormat) format(n float64) string {
+	if math.IsNaN(n) {
+		return "NaN"
+	}
+	if f.style == "percent" {
+		n *= 100
+	}
+
+	negative := n < 0
+	var number string
+	if math.IsInf(n, 0) {
+		number = "∞"
+	} else {
+		number = f.formatDigits(math.Abs(n))
+		// Values rounding to zero don't keep their sign
+		if strings.Trim(number, "0"+f.locale.group+f.locale.decimal) == "" {
+			negative = false
+		}
+	}
+
+	switch f.style {
+	case "currency":
+		symbol := f.currency
+		if c, ok := intlCurrencies[f.currency]; ok && !f.currencyCode {
+			symbol = c.symbol
+		}
+		if f.locale.symbolAfter {
+			number += "\u00a0" + symbol
+		} else if len(symbol) == 3 && symbol == f.currency {
+			number = symbol + "\u00a0" + number
+		} else {
+			number = symbol + number
+		}
+	case "percent":
+		if f.locale.symbolAfter {
+			number += "\u00a0%"
+		} else {
+			number += "%"
+		}
+	}
+	if negative {
+		number = "-" + number
+	}
+	return number
+}
+
+// formatDigits rounds n to the fraction digits of f and adds the locale's
+// separators
+func (f *numberFormat) formatDigits(n float64) string {
+	integer, fraction := roundHalfExpand(n, f.maxFraction)
+	for len(fraction) > f.minFraction && strings.HasSuffix(fraction, "0") {
+		fraction = fraction[:len(fraction)-1]
+	}
+
+	if f.useGrouping && len(integer) > 3 {
+		var grouped strings.Builder
+		for i, digit := range integer {
+			if i > 0 && (len(integer)-i)%3 == 0 {
+				grouped.WriteString(f.locale.group)
+			}
+			grouped.WriteRune(digit)
+		}
+		integer = grouped.String()
+	}
+	if fraction == "" {
+		return integer
+	}
+	return integer + f.locale.decimal + fraction
+}
+
+// dateTimeFormat is the state of an Intl.DateTimeFormat instance. Empty
+// component fields are left out of the output.
+type dateTimeFormat struct {
+	locale       intlLocale
+	location     *time.Location
+	timeZone     string
+	weekday      string // "long", "short" or "narrow"
+	year         string // "numeric" or "2-digit"
+	month        string // "numeric", "2-digit", "long", "short" or "narrow"
+	day          string // "numeric" or "2-digit"
+	hour         string // "numeric" or "2-digit"
+	minute       string
+	second       string
+	timeZoneName string // "short" or "long"
+	hour12       bool
+}
+
+// Component options implied by the dateStyle and timeStyle options
+var (
+	intlDateStyles = map[string][4]string{ // weekday, year, month, day
+		"full":   {"long", "numeric", "long", "numeric"},
+		"long":   {"", "numeric", "long", "numeric"},
+		"medium": {"", "numeric", "short", "numeric"},
+		"short":  {"", "2-digit", "numeric", "numeric"},
+	}
+	intlTimeStyles = map[string][4]string{ // hour, minute, second, timeZoneName
+		"full":   {"numeric", "2-digit", "2-digit", "long"},
+		"long":   {"numeric", "2-digit", "2-digit", "short"},
+		"medium": {"numeric", "2-digit", "2-digit", ""},
+		"short":  {"numeric", "2-digit", "", ""},
+	}
+)
+
+// newDateTimeFormat initializes obj as an Intl.DateTimeFormat
+func newDateTimeFormat(runtime *sobek.Runtime, obj *sobek.Object, locale intlLocale, opts intlOptions) {
+	f := &dateTimeFormat{locale: locale, location: time.Local, hour12: locale.hour12}
+	if v := opts.get("timeZone"); v != nil {
+		loc, err := time.LoadLocation(v.String())
+		if err != nil {
+			panic(vm.NewRangeError(runtime, "encoding", vm.CodeInvalidArgument, "Invalid time zone specified: "+v.String()))
+		}
+		f.location = loc
+		f.timeZone = v.String()
+	} else {
+		f.timeZone = localTimeZone()
+	}
+
+	textual := []string{"long", "short", "narrow"}
+	numeric := []string{"numeric", "2-digit"}
+	f.weekday = opts.string("weekday", textual...)
+	f.year = opts.string("year", numeric...)
+	f.month = opts.string("month", append(numeric, textual...)...)
+	f.day = opts.string("day", numeric...)
+	f.hour = opts.string("hour", numeric...)
+	f.minute = opts.string("minute", numeric...)
+	f.second = opts.string("second", numeric...)
+	f.timeZoneName = opts.string("timeZoneName", "short", "long")
+
+	styles := []string{"full", "long", "medium", "short"}
+	if style := opts.string("dateStyle", styles...); style != "" {
+		s := intlDateStyles[style]
+		f.weekday, f.year, f.month, f.day = s[0], s[1], s[2], s[3]
+	}
+	if style := opts.string("timeStyle", styles...); style != "" {
+		s := intlTimeStyles[style]
+		f.hour, f.minute, f.second, f.timeZoneName = s[0], s[1], s[2], s[3]
+	}
+	if f.weekday == "" && f.year == "" && f.month == "" && f.day == "" &&
+		f.hour == "" && f.minute == "" && f.second == "" {
+		f.year, f.month, f.day = "numeric", "numeric", "numeric"
+	}
+	if v := opts.get("hour12"); v != nil {
+		f.hour12 = v.ToBoolean()
+	}
+
+	obj.Set("format", func(call sobek.FunctionCall) sobek.Value {
+		return runtime.ToValue(f.format(dateArgument(runtime, call.Argument(0))))
+	})
+	obj.Set("resolvedOptions", func(call sobek.FunctionCall) sobek.Value {
+		resolved := runtime.NewObject()
+		resolved.Set("locale", f.locale.tag)
+		resolved.Set("calendar", "gregory")
+		resolved.Set("numberingSystem", "latn")
+		resolved.Set("timeZone", f.timeZone)
+		for name, value := range map[string]string{
+			"weekday": f.weekday, "year": f.year, "month": f.month, "day": f.day,
+			"hour": f.hour, "minute": f.minute, "second": f.second, "timeZoneName": f.timeZoneName,
+		} {
+			if value != "" {
+				resolved.Set(name, value)
+			}
+		}
+		if f.hour != "" {
+			resolved.Set("hour12", f.hour12)
+		}
+		return resolved
+	})
+}
+
+// localTimeZone returns the name of the local time zone, which is "Local"
+// for Go unless TZ names one
+func localTimeZone() string {
+	if name := time.Local.String(); name != "Local" {
+		return name
+	}
+	if _, offset := time.Now().Zone(); offset == 0 {
+		return "UTC"
+	}
+	name, _ := time.Now().Zone()
+	return name
+}
+
+// dateArgument converts a Date, a timestamp in milliseconds or undefined
+// (the current time) to a time
+func dateArgument(runtime *sobek.Runtime, v sobek.Value) time.Time {
+	if sobek.IsUndefined(v) {
+		return time.Now()
+	}
+	if t, ok := v.Export().(time.Time); ok {
+		return t
+	}
+	ms := v.ToFloat()
+	if math.IsNaN(ms) || math.IsInf(ms, 0) {
+		panic(vm.NewRangeError(runtime, "encoding", vm.CodeInvalidArgument, "Invalid time value"))
+	}
+	return time.UnixMilli(int64(ms))
+}
+
+// format formats t with the components and locale of f
+func (f *dateTimeFormat) format(t time.Time) string {
+	t = t.In(f.location)
+	var parts []string
+	if date := f.formatDate(t); date != "" {
+		parts = append(parts, date)
+	}
+	if clock := f.formatTime(t); clock != "" {
+		parts = append(parts, clock)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatDate formats the weekday, day, month and year components of t
+func (f *dateTimeFormat) formatDate(t time.Time) string {
+	weekday := ""
+	switch f.weekday {
+	case "long":
+		weekday = t.Weekday().String()
+	case "short":
+		weekday = t.Weekday().String()[:3]
+	case "narrow":
+		weekday = t.Weekday().String()[:1]
+	}
+
+	year := ""
+	switch f.year {
+	case "numeric":
+		year = strconv.Itoa(t.Year())
+	case "2-digit":
+		year = pad2(t.Year() % 100)
+	}
+	day := ""
+	switch f.day {
+	case "numeric":
+		day = strconv.Itoa(t.Day())
+		if f.locale.padDate && f.month != "" && !isTextMonth(f.month) {
+			day = pad2(t.Day())
+		}
+	case "2-digit":
+		day = pad2(t.Day())
+	}
+
+	if isTextMonth(f.month) {
+		month := t.Month().String()
+		switch f.month {
+		case "short":
+			month = month[:3]
+		case "narrow":
+			month = month[:1]
+		}
+		var date string
+		if f.locale.textDayFirst {
+			date = joinNonEmpty(" ", day, month, year)
+		} else {
+			date = joinNonEmpty(" ", month, day)
+			if year != "" {
+				date = joinNonEmpty(", ", date, year)
+			}
+		}
+		return joinNonEmpty(", ", weekday, date)
+	}
+
+	month := ""
+	switch f.month {
+	case "numeric":
+		month = strconv.Itoa(int(t.Month()))
+		if f.locale.padDate && f.day != "" {
+			month = pad2(int(t.Month()))
+		}
+	case "2-digit":
+		month = pad2(int(t.Month()))
+	}
+	var date string
+	switch f.locale.dateOrder {
+	case "dmy":
+		date = joinNonEmpty(f.locale.dateSep, day, month, year)
+	case "ymd":
+		date = joinNonEmpty(f.locale.dateSep, year, month, day)
+	default:
+		date = joinNonEmpty(f.locale.dateSep, month, day, year)
+	}
+	return joinNonEmpty(", ", weekday, date)
+}
+
+// formatTime formats the hour, minute, second and time zone components of t
+func (f *dateTimeFormat) formatTime(t time.Time) string {
+	var fields []string
+	suffix := ""
+	if f.hour != "" {
+		hour := t.Hour()
+		if f.hour12 {
+			suffix = " AM"
+			if hour >= 12 {
+				suffix = " PM"
+			}
+			hour %= 12
+			if hour == 0 {
+				hour = 12
+			}
+		}
+		if f.hour == "2-digit" || (!f.hour12 && f.minute != "") {
+			fields = append(fields, pad2(hour))
+		} else {
+			fields = append(fields, strconv.Itoa(hour))
+		}
+	}
+	if f.minute != "" {
+		if f.hour == "" && f.second == "" {
+			fields = append(fields, strconv.Itoa(t.Minute()))
+		} else {
+			fields = append(fields, pad2(t.Minute()))
+		}
+	}
+	if f.second != "" {
+		if len(fields) == 0 {
+			fields = append(fields, strconv.Itoa(t.Second()))
+		} else {
+			fields = append(fields, pad2(t.Second()))
+		}
+	}
+
+	clock := strings.Join(fields, ":")
+	if clock != "" {
+		clock += suffix
+	}
+	switch f.timeZoneName {
+	case "short":
+		zone, _ := t.Zone()
+		clock = joinNonEmpty(" ", clock, zone)
+	case "long":
+		clock = joinNonEmpty(" ", clock, f.timeZone)
+	}
+	return clock
+}
+
+// roundHalfExpand rounds the non-negative n to digits fraction digits with
+// ties rounded up, as Intl does, rather than to even like strconv. The
+// shortest decimal form of n is rounded, so 1.005 becomes 1.01.
+func roundHalfExpand(n float64, digits int) (integer, fraction string) {
+	integer, fraction, _ = strings.Cut(strconv.FormatFloat(n, 'f', -1, 64), ".")
+	if len(fraction) <= digits {
+		return integer, fraction + strings.Repeat("0", digits-len(fraction))
+	}
+	roundUp := fraction[digits] >= '5'
+	kept := []byte(integer + fraction[:digits])
+	if roundUp {
+		i := len(kept) - 1
+		for ; i >= 0 && kept[i] == '9'; i-- {
+			kept[i] = '0'
+		}
+		if i < 0 {
+			kept = append([]byte{'1'}, kept...)
+		} else {
+			kept[i]++
+		}
+	}
+	split := len(kept) - digits
+	return string(kept[:split]), string(kept[split:])
+}
+
+// isTextMonth reports whether month is a month option spelled out as a name
+func isTextMonth(month string) bool {
+	return month == "long" || month == "short" || month == "narrow"
+}
+
+// pad2 formats n with at least two digits
+func pad2(n int) string {
+	if n < 10 {
+		return "0" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
+
+// joinNonEmpty joins the non-empty parts with sep
+func joinNonEmpty(sep string, parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, sep)
+}
@@ -0,0 +1,61 @@
+package encoding
+
+import (
+	"sort"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+	"github.com/titanous/json5"
+)
+
+// setupJSON5 sets up the global JSON5 object, whose parse accepts comments,
+// trailing commas, unquoted keys, single-quoted strings and the other JSON5
+// extensions. The standard JSON global is left as it is.
+func (e *EncodingModule) setupJSON5(runtime *sobek.Runtime) {
+	obj := runtime.NewObject()
+
+	// JSON5.parse(text) - object keys come out sorted, since the parser
+	// doesn't keep their order
+	obj.Set("parse", func(call sobek.FunctionCall) sobek.Value {
+		if len(call.Arguments) == 0 {
+			panic(vm.NewTypeError(runtime, "encoding", vm.CodeInvalidArgument, "JSON5.parse: 1 argument required, but only 0 present"))
+		}
+		var value any
+		if err := json5.Unmarshal([]byte(call.Argument(0).String()), &value); err != nil {
+			panic(vm.NewSyntaxError(runtime, "encoding", vm.CodeInvalidArgument, "JSON5.parse: "+err.Error()))
+		}
+		return json5Value(runtime, value)
+	})
+
+	// JSON5.stringify(value, replacer?, space?) - JSON output is valid JSON5
+	obj.Set("stringify", runtime.Get("JSON").ToObject(runtime).Get("stringify"))
+
+	runtime.Set("JSON5", obj)
+}
+
+// json5Value converts a parsed JSON5 value into plain JavaScript objects and
+// arrays
+func json5Value(runtime *sobek.Runtime, value any) sobek.Value {
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		obj := runtime.NewObject()
+		for _, key := range keys {
+			obj.Set(key, json5Value(runtime, v[key]))
+		}
+		return obj
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = json5Value(runtime, item)
+		}
+		return runtime.NewArray(items...)
+	default:
+		// Strings, numbers, booleans and null
+		return runtime.ToValue(v)
+	}
+}
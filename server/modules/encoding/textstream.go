@@ -0,0 +1,103 @@
+package encoding
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/modules/internal/streams"
+)
+
+// setupTextStreams sets up the TextEncoderStream and TextDecoderStream
+// globals. Like TransformStream they have readable and writable sides, so
+// they can be written to and read with getWriter() and getReader(), or piped
+// into with pipeThrough() from a stream such as a request body. They also
+// expose their transform(chunk) and flush() steps, for callers applying them
+// to each chunk in turn.
+func (e *EncodingModule) setupTextStreams(runtime *sobek.Runtime) {
+	// TextEncoderStream - encodes string chunks to UTF-8 Uint8Arrays
+	runtime.Set("TextEncoderStream", func(call sobek.ConstructorCall) *sobek.Object {
+		obj := call.This
+		obj.Set("encoding", "utf-8")
+
+		// transform(chunk) - returns the UTF-8 bytes of chunk
+		obj.Set("transform", func(call sobek.FunctionCall) sobek.Value {
+			return newUint8Array(runtime, []byte(call.Argument(0).String()))
+		})
+
+		// flush() - the encoder keeps no state, so there is nothing left
+		obj.Set("flush", func(call sobek.FunctionCall) sobek.Value {
+			return newUint8Array(runtime, []byte{})
+		})
+
+		readable, writable := streams.NewTransform(runtime, "encoding",
+			func(chunk sobek.Value) (sobek.Value, bool) {
+				data := []byte(chunk.String())
+				return newUint8Array(runtime, data), len(data) > 0
+			},
+			func() (sobek.Value, bool) { return nil, false })
+		obj.Set("readable", readable)
+		obj.Set("writable", writable)
+
+		return nil
+	})
+
+	// TextDecoderStream - decodes UTF-8 byte chunks to strings, keeping a
+	// multibyte sequence split across chunks until its remaining bytes arrive
+	runtime.Set("TextDecoderStream", func(call sobek.ConstructorCall) *sobek.Object {
+		obj := call.This
+		obj.Set("encoding", "utf-8")
+
+		var pending []byte
+		decode := func(chunk sobek.Value) string {
+			data := append(pending, toBytes(runtime, chunk)...)
+			end := incompleteSuffix(data)
+			pending = append([]byte(nil), data[end:]...)
+			return strings.ToValidUTF8(string(data[:end]), "\uFFFD")
+		}
+		flush := func() string {
+			rest := strings.ToValidUTF8(string(pending), "\uFFFD")
+			pending = nil
+			return rest
+		}
+
+		// transform(chunk) - returns the text of the complete sequences so far
+		obj.Set("transform", func(call sobek.FunctionCall) sobek.Value {
+			return runtime.ToValue(decode(call.Argument(0)))
+		})
+
+		// flush() - returns a replacement character for a truncated sequence
+		// left at the end of the input
+		obj.Set("flush", func(call sobek.FunctionCall) sobek.Value {
+			return runtime.ToValue(flush())
+		})
+
+		readable, writable := streams.NewTransform(runtime, "encoding",
+			func(chunk sobek.Value) (sobek.Value, bool) {
+				text := decode(chunk)
+				return runtime.ToValue(text), text != ""
+			},
+			func() (sobek.Value, bool) {
+				text := flush()
+				return runtime.ToValue(text), text != ""
+			})
+		obj.Set("readable", readable)
+		obj.Set("writable", writable)
+
+		return nil
+	})
+}
+
+// incompleteSuffix returns the offset of a UTF-8 sequence at the end of data
+// that is missing bytes, or len(data) if it ends on a complete character
+func incompleteSuffix(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax+1; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(data)
+}
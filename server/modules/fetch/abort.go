@@ -0,0 +1,199 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+var (
+	errAborted = errors.New("This operation was aborted")
+	errTimeout = errors.New("The operation was aborted due to timeout")
+)
+
+// abortSignal backs a JavaScript AbortSignal with a Go context so aborting
+// also cancels in-flight requests
+type abortSignal struct {
+	rt         *sobek.Runtime
+	obj        *sobek.Object
+	ctx        context.Context
+	cancel     context.CancelCauseFunc
+	reason     sobek.Value
+	dispatched bool
+	listeners  []sobek.Value
+}
+
+// setupAbortGlobals sets up the AbortController and AbortSignal globals
+func (f *FetchModule) setupAbortGlobals(runtime *sobek.Runtime) {
+	// AbortController constructor
+	runtime.Set("AbortController", func(call sobek.ConstructorCall) *sobek.Object {
+		obj := call.This
+		signal := newAbortSignal(runtime)
+		obj.Set("signal", signal.obj)
+		obj.Set("abort", func(call sobek.FunctionCall) sobek.Value {
+			signal.abort(errAborted, call.Argument(0))
+			return sobek.Undefined()
+		})
+		return nil
+	})
+
+	// AbortSignal cannot be constructed directly, only through its statics
+	runtime.Set("AbortSignal", func(call sobek.ConstructorCall) *sobek.Object {
+		panic(vm.NewTypeError(runtime, "fetch", vm.CodeInvalidArgument, "Illegal constructor"))
+	})
+	signalCtor := runtime.Get("AbortSignal").ToObject(runtime)
+
+	// AbortSignal.abort(reason?) - returns an already aborted signal
+	signalCtor.Set("abort", func(call sobek.FunctionCall) sobek.Value {
+		signal := newAbortSignal(runtime)
+		signal.abort(errAborted, call.Argument(0))
+		return signal.obj
+	})
+
+	// AbortSignal.timeout(ms) - returns a signal that aborts after ms milliseconds
+	signalCtor.Set("timeout", func(call sobek.FunctionCall) sobek.Value {
+		ms := call.Argument(0).ToInteger()
+		if ms < 0 {
+			panic(vm.NewTypeError(runtime, "fetch", vm.CodeInvalidArgument, "AbortSignal.timeout: delay must be a non-negative number"))
+		}
+		signal := newAbortSignal(runtime)
+
+		// The timer cancels the Go context right away so a blocking fetch
+		// returns, then notifies listeners on the event loop. Like browsers,
+		// the pending timeout does not keep the script alive.
+		timer := time.AfterFunc(time.Duration(ms)*time.Millisecond, func() {
+			signal.cancel(errTimeout)
+			vm.Post(runtime, func() error {
+				signal.abort(errTimeout, sobek.Undefined())
+				return nil
+			})
+		})
+		vm.Cleanup(runtime, func() { timer.Stop() })
+
+		return signal.obj
+	})
+}
+
+// newAbortSignal creates a signal and its JavaScript object
+func newAbortSignal(runtime *sobek.Runtime) *abortSignal {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	s := &abortSignal{
+		rt:     runtime,
+		obj:    runtime.NewObject(),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	_ = s.obj.DefineDataProperty("__signal", runtime.ToValue(s), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_FALSE)
+	_ = s.obj.DefineAccessorProperty("aborted", runtime.ToValue(func(sobek.FunctionCall) sobek.Value {
+		return runtime.ToValue(s.aborted())
+	}), nil, sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+	_ = s.obj.DefineAccessorProperty("reason", runtime.ToValue(func(sobek.FunctionCall) sobek.Value {
+		if !s.aborted() {
+			return sobek.Undefined()
+		}
+		return s.reasonValue()
+	}), nil, sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+	s.obj.Set("onabort", sobek.Null())
+
+	s.obj.Set("addEventListener", func(call sobek.FunctionCall) sobek.Value {
+		if call.Argument(0).String() != "abort" {
+			return sobek.Undefined()
+		}
+		if listener := call.Argument(1); isFunc(listener) {
+			s.listeners = append(s.listeners, listener)
+		}
+		return sobek.Undefined()
+	})
+
+	s.obj.Set("removeEventListener", func(call sobek.FunctionCall) sobek.Value {
+		if call.Argument(0).String() != "abort" {
+			return sobek.Undefined()
+		}
+		target := call.Argument(1)
+		for i, listener := range s.listeners {
+			if listener.SameAs(target) {
+				s.listeners = append(s.listeners[:i], s.listeners[i+1:]...)
+				break
+			}
+		}
+		return sobek.Undefined()
+	})
+
+	s.obj.Set("throwIfAborted", func(call sobek.FunctionCall) sobek.Value {
+		if s.aborted() {
+			panic(s.reasonValue())
+		}
+		return sobek.Undefined()
+	})
+
+	return s
+}
+
+// aborted reports whether the signal has been aborted, including by a timer
+// that has not yet reached the event loop
+func (s *abortSignal) aborted() bool {
+	return s.ctx.Err() != nil
+}
+
+// reasonValue returns the abort reason, creating the default error lazily
+func (s *abortSignal) reasonValue() sobek.Value {
+	if s.reason == nil {
+		if errors.Is(context.Cause(s.ctx), errTimeout) {
+			s.reason = vm.NewNamedError(s.rt, "fetch", vm.CodeTimeout, "TimeoutError", errTimeout.Error())
+		} else {
+			s.reason = vm.NewNamedError(s.rt, "fetch", vm.CodeAborted, "AbortError", errAborted.Error())
+		}
+	}
+	return s.reason
+}
+
+// abort marks the signal aborted and dispatches the abort event once
+func (s *abortSignal) abort(cause error, reason sobek.Value) {
+	if s.dispatched {
+		return
+	}
+	if !s.aborted() && reason != nil && !sobek.IsUndefined(reason) {
+		s.reason = reason
+	}
+	s.cancel(cause)
+	s.dispatched = true
+
+	event := s.rt.NewObject()
+	event.Set("type", "abort")
+	event.Set("target", s.obj)
+
+	if onabort, ok := sobek.AssertFunction(s.obj.Get("onabort")); ok {
+		_, _ = onabort(s.obj, event)
+	}
+	for _, listener := range s.listeners {
+		if fn, ok := sobek.AssertFunction(listener); ok {
+			_, _ = fn(s.obj, event)
+		}
+	}
+}
+
+// toAbortSignal extracts the Go signal from a JavaScript AbortSignal
+func toAbortSignal(value sobek.Value) (*abortSignal, bool) {
+	if value == nil || sobek.IsUndefined(value) || sobek.IsNull(value) {
+		return nil, false
+	}
+	obj, ok := value.(*sobek.Object)
+	if !ok {
+		return nil, false
+	}
+	if v := obj.Get("__signal"); v != nil {
+		s, ok := v.Export().(*abortSignal)
+		return s, ok
+	}
+	return nil, false
+}
+
+// isFunc reports whether the value is callable
+func isFunc(v sobek.Value) bool {
+	_, ok := sobek.AssertFunction(v)
+	return ok
+}
@@ -0,0 +1,69 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/internal/logger"
+	"github.com/mark3labs/codebench-mcp/server/quota"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// setupBeacon sets up the global sendBeacon(url, data?), which POSTs data
+// without the script waiting for the response, e.g. for telemetry. The event
+// loop stays open until the request completes, so a beacon sent just before
+// the script ends is still delivered. It returns false when the request
+// can't be queued.
+func (f *FetchModule) setupBeacon(runtime *sobek.Runtime) {
+	runtime.Set("sendBeacon", func(call sobek.FunctionCall) sobek.Value {
+		if len(call.Arguments) == 0 {
+			panic(vm.NewTypeError(runtime, "fetch", vm.CodeInvalidArgument, "sendBeacon: URL is required"))
+		}
+		url := call.Argument(0).String()
+		if f.safeOnly {
+			return runtime.ToValue(false)
+		}
+
+		var body []byte
+		contentType := ""
+		if data := call.Argument(1); !sobek.IsUndefined(data) && !sobek.IsNull(data) {
+			body = bodyBytes(runtime, data)
+			if sobek.IsString(data) {
+				contentType = "text/plain;charset=UTF-8"
+			}
+		}
+
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return runtime.ToValue(false)
+		}
+		if usage := quota.FromContext(vm.Context(runtime)); usage != nil {
+			if err := usage.CountFetch(); err != nil {
+				return runtime.ToValue(false)
+			}
+		}
+		for key, value := range f.headers {
+			req.Header.Set(key, value)
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		// The reservation keeps the loop running until the beacon is sent
+		enqueue := vm.EnqueueJob(runtime)
+		go func() {
+			resp, err := f.client.Do(req)
+			if err != nil {
+				logger.Debug("sendBeacon failed", "url", url, "error", err)
+			} else {
+				_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, f.maxBody))
+				resp.Body.Close()
+			}
+			enqueue(func() error { return nil })
+		}()
+		return runtime.ToValue(true)
+	})
+}
@@ -1,31 +1,105 @@
 package fetch
 
 import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/http/cookiejar"
-	"strings"
 	"time"
 
 	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/modules/cache"
+	"github.com/mark3labs/codebench-mcp/server/modules/encoding"
+	"github.com/mark3labs/codebench-mcp/server/modules/internal/form"
+	"github.com/mark3labs/codebench-mcp/server/modules/internal/headers"
+	"github.com/mark3labs/codebench-mcp/server/quota"
 	"github.com/mark3labs/codebench-mcp/server/vm"
 )
 
 // FetchModule provides fetch API functionality
 type FetchModule struct {
-	client *http.Client
+	client   *http.Client
+	cache    cache.Cache       // nil disables HTTP caching
+	headers  map[string]string // default headers sent with every request
+	safeOnly bool              // reject methods other than GET and HEAD
+	maxBody  int64             // largest response body read, in bytes
 }
 
+// Options configures a fetch module
+type Options struct {
+	// Cache stores GET responses while Cache-Control max-age says they are
+	// fresh, serving them without a network call. It is shared by every
+	// execution, so it must not be reachable by scripts. Nil disables caching.
+	Cache cache.Cache
+	// Headers are added to every request; headers passed to fetch take precedence
+	Headers map[string]string
+	// SafeMethodsOnly restricts fetch to GET and HEAD, for read-only sandboxes
+	SafeMethodsOnly bool
+	// Timeout bounds each request, including reading the response body
+	// (defaults to DefaultTimeout)
+	Timeout time.Duration
+	// Mocks, if not nil, serve every request from canned responses keyed by
+	// "URL" or "METHOD URL" without any network access; other requests fail
+	Mocks map[string]MockResponse
+	// MaxResponseSize caps the bytes of a response body; larger responses
+	// fail instead of being read into memory (defaults to
+	// DefaultMaxResponseSize)
+	MaxResponseSize int64
+}
+
+// DefaultTimeout is the request timeout used when Options.Timeout is zero
+const DefaultTimeout = 30 * time.Second
+
+// DefaultMaxResponseSize is the response body cap used when
+// Options.MaxResponseSize is zero
+const DefaultMaxResponseSize = 50 << 20
+
 // NewFetchModule creates a new fetch module
 func NewFetchModule() *FetchModule {
+	return NewFetchModuleWithOptions(Options{})
+}
+
+// NewFetchModuleWithCache creates a fetch module that caches GET responses in
+// backend, serving them without a network call while Cache-Control max-age
+// says they are fresh. A nil backend disables caching.
+func NewFetchModuleWithCache(backend cache.Cache) *FetchModule {
+	return NewFetchModuleWithOptions(Options{Cache: backend})
+}
+
+// NewFetchModuleWithOptions creates a fetch module configured by opts
+func NewFetchModuleWithOptions(opts Options) *FetchModule {
 	// Create cookie jar for automatic cookie handling
 	jar, _ := cookiejar.New(nil)
-	
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	client := &http.Client{
+		Timeout: timeout,
+		Jar:     jar,
+	}
+	if opts.Mocks != nil {
+		client.Transport = &mockTransport{mocks: opts.Mocks}
+	}
+
+	maxBody := opts.MaxResponseSize
+	if maxBody <= 0 {
+		maxBody = DefaultMaxResponseSize
+	}
+
 	return &FetchModule{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-			Jar:     jar,
-		},
+		client:   client,
+		cache:    opts.Cache,
+		headers:  opts.Headers,
+		safeOnly: opts.SafeMethodsOnly,
+		maxBody:  maxBody,
 	}
 }
 
@@ -57,18 +131,16 @@ func (f *FetchModule) CreateGlobalObject(runtime *sobek.Runtime) sobek.Value {
 }
 
 // setupFetchGlobals sets up Request, Response, Headers, FormData constructors
+// and sendBeacon
 func (f *FetchModule) setupFetchGlobals(runtime *sobek.Runtime) {
+	// AbortController and AbortSignal for cancelling requests
+	f.setupAbortGlobals(runtime)
+
 	// Request constructor
-	runtime.Set("Request", func(call sobek.ConstructorCall) *sobek.Object {
-		obj := call.This
-		if len(call.Arguments) > 0 {
-			obj.Set("url", call.Argument(0).String())
-		}
-		if len(call.Arguments) > 1 {
-			obj.Set("options", call.Argument(1))
-		}
-		return nil
-	})
+	f.setupRequest(runtime)
+
+	// sendBeacon for requests the script doesn't wait for
+	f.setupBeacon(runtime)
 
 	// Response constructor
 	runtime.Set("Response", func(call sobek.ConstructorCall) *sobek.Object {
@@ -79,40 +151,49 @@ func (f *FetchModule) setupFetchGlobals(runtime *sobek.Runtime) {
 		if len(call.Arguments) > 1 {
 			obj.Set("options", call.Argument(1))
 		}
+		applyResponseInit(runtime, obj, call.Argument(1), nil)
 		return nil
 	})
 
-	// Headers constructor
+	// Response.json(data, init?) - a Response with a JSON body and content type
+	responseCtor := runtime.Get("Response").ToObject(runtime)
+	responseCtor.Set("json", func(call sobek.FunctionCall) sobek.Value {
+		stringify, _ := sobek.AssertFunction(runtime.Get("JSON").ToObject(runtime).Get("stringify"))
+		body, err := stringify(sobek.Undefined(), call.Argument(0))
+		if err != nil {
+			panic(err)
+		}
+		if sobek.IsUndefined(body) {
+			panic(vm.NewTypeError(runtime, "fetch", vm.CodeInvalidArgument, "Response.json: data is not JSON serializable"))
+		}
+
+		obj, err := runtime.New(runtime.Get("Response"), body)
+		if err != nil {
+			panic(err)
+		}
+		defaults := http.Header{"Content-Type": {"application/json"}}
+		applyResponseInit(runtime, obj, call.Argument(1), defaults)
+		return obj
+	})
+
+	// Headers constructor - new Headers(init?) with init an object, an array
+	// of [name, value] pairs or another Headers object
 	runtime.Set("Headers", func(call sobek.ConstructorCall) *sobek.Object {
-		obj := call.This
-		obj.Set("get", func(call sobek.FunctionCall) sobek.Value {
-			if len(call.Arguments) > 0 {
-				key := call.Argument(0).String()
-				return obj.Get(key)
-			}
-			return sobek.Undefined()
-		})
-		obj.Set("set", func(call sobek.FunctionCall) sobek.Value {
-			if len(call.Arguments) > 1 {
-				key := call.Argument(0).String()
-				value := call.Argument(1).String()
-				obj.Set(key, value)
-			}
-			return sobek.Undefined()
-		})
+		headers.Init(runtime, call.This, headers.Parse(runtime, call.Argument(0)), true)
 		return nil
 	})
 
 	// FormData constructor
 	runtime.Set("FormData", func(call sobek.ConstructorCall) *sobek.Object {
 		obj := call.This
-		data := make(map[string]string)
+		var entries []formEntry
 
+		// append(name, value, filename?) - Blob values are stored as File entries
 		obj.Set("append", func(call sobek.FunctionCall) sobek.Value {
 			if len(call.Arguments) > 1 {
 				key := call.Argument(0).String()
-				value := call.Argument(1).String()
-				data[key] = value
+				value := formValue(runtime, call.Argument(1), call.Argument(2))
+				entries = append(entries, formEntry{name: key, value: value})
 			}
 			return sobek.Undefined()
 		})
@@ -120,8 +201,10 @@ func (f *FetchModule) setupFetchGlobals(runtime *sobek.Runtime) {
 		obj.Set("get", func(call sobek.FunctionCall) sobek.Value {
 			if len(call.Arguments) > 0 {
 				key := call.Argument(0).String()
-				if value, exists := data[key]; exists {
-					return runtime.ToValue(value)
+				for _, entry := range entries {
+					if entry.name == key {
+						return entry.value
+					}
 				}
 			}
 			return sobek.Undefined()
@@ -131,83 +214,199 @@ func (f *FetchModule) setupFetchGlobals(runtime *sobek.Runtime) {
 	})
 }
 
+// applyResponseInit sets status, statusText and headers on a Response from
+// its init object. Headers in init override the defaults.
+func applyResponseInit(runtime *sobek.Runtime, obj *sobek.Object, init sobek.Value, defaults http.Header) {
+	status := http.StatusOK
+	statusText := ""
+	header := defaults.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	if !sobek.IsUndefined(init) && !sobek.IsNull(init) {
+		initObj := init.ToObject(runtime)
+		if v := initObj.Get("status"); v != nil && !sobek.IsUndefined(v) {
+			status = int(v.ToInteger())
+			if status < 200 || status > 599 {
+				panic(vm.NewRangeError(runtime, "fetch", vm.CodeInvalidArgument,
+					fmt.Sprintf("Response: status %d is outside the range [200, 599]", status)))
+			}
+		}
+		if v := initObj.Get("statusText"); v != nil && !sobek.IsUndefined(v) {
+			statusText = v.String()
+		}
+		for key, values := range headers.Parse(runtime, initObj.Get("headers")) {
+			header[key] = values
+		}
+	}
+
+	obj.Set("status", status)
+	obj.Set("statusText", statusText)
+	obj.Set("ok", status >= 200 && status < 300)
+	obj.Set("headers", headers.NewMutable(runtime, header))
+}
+
+// formEntry is a single FormData field
+type formEntry struct {
+	name  string
+	value sobek.Value
+}
+
+// formValue converts a FormData value to a string, or to a File for Blob values
+func formValue(runtime *sobek.Runtime, value, filename sobek.Value) sobek.Value {
+	obj, ok := value.(*sobek.Object)
+	if !ok || obj.Get("__blob") == nil {
+		return runtime.ToValue(value.String())
+	}
+
+	name := "blob"
+	if !sobek.IsUndefined(filename) {
+		name = filename.String()
+	} else if v := obj.Get("name"); v != nil && !sobek.IsUndefined(v) {
+		// Already a File with no new name
+		return obj
+	}
+
+	opts := runtime.NewObject()
+	opts.Set("type", obj.Get("type"))
+	file, err := runtime.New(runtime.Get("File"), runtime.NewArray(obj), runtime.ToValue(name), opts)
+	if err != nil {
+		panic(err)
+	}
+	return file
+}
+
 // handleFetch handles the main fetch function call
 func (f *FetchModule) handleFetch(call sobek.FunctionCall, runtime *sobek.Runtime) sobek.Value {
 	if len(call.Arguments) == 0 {
-		panic(runtime.NewTypeError("fetch: URL is required"))
+		panic(vm.NewTypeError(runtime, "fetch", vm.CodeInvalidArgument, "fetch: URL is required"))
 	}
 
-	url := call.Argument(0).String()
-
-	// Default options
-	method := "GET"
-	var body io.Reader
-	headers := make(map[string]string)
+	// The first argument is a URL or a Request, with options overriding it
+	rd := newRequestData(runtime, call.Argument(0))
+	applyRequestInit(runtime, rd, call.Argument(1))
+	url, method := rd.url, rd.method
+	ctx := context.Background()
+	var signal *abortSignal
+	cacheMode := "default"
+
+	if rd.signal != nil && !sobek.IsNull(rd.signal) {
+		var ok bool
+		if signal, ok = toAbortSignal(rd.signal); !ok {
+			panic(vm.NewTypeError(runtime, "fetch", vm.CodeInvalidArgument, "fetch: signal must be an AbortSignal"))
+		}
+		if signal.aborted() {
+			panic(signal.reasonValue())
+		}
+		ctx = signal.ctx
+	}
 
-	// Parse options if provided
-	if len(call.Arguments) > 1 && !sobek.IsUndefined(call.Argument(1)) {
+	if len(call.Arguments) > 1 && !sobek.IsUndefined(call.Argument(1)) && !sobek.IsNull(call.Argument(1)) {
 		options := call.Argument(1).ToObject(runtime)
-
-		if methodVal := options.Get("method"); methodVal != nil && !sobek.IsUndefined(methodVal) {
-			method = strings.ToUpper(methodVal.String())
+		if cacheVal := options.Get("cache"); cacheVal != nil && !sobek.IsUndefined(cacheVal) {
+			cacheMode = cacheVal.String()
 		}
+	}
 
-		if bodyVal := options.Get("body"); bodyVal != nil && !sobek.IsUndefined(bodyVal) {
-			bodyStr := bodyVal.String()
-			body = strings.NewReader(bodyStr)
-		}
+	if f.safeOnly && method != http.MethodGet && method != http.MethodHead {
+		panic(vm.NewTypeError(runtime, "fetch", vm.CodeNotSupported, "fetch: method "+method+" is not allowed, only GET and HEAD are permitted"))
+	}
 
-		if headersVal := options.Get("headers"); headersVal != nil && !sobek.IsUndefined(headersVal) {
-			headersObj := headersVal.ToObject(runtime)
-			for _, key := range headersObj.Keys() {
-				headers[key] = headersObj.Get(key).String()
-			}
+	if usage := quota.FromContext(vm.Context(runtime)); usage != nil {
+		if err := usage.CountFetch(); err != nil {
+			panic(vm.NewError(runtime, "fetch", vm.CodeQuotaExceeded, err))
 		}
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest(method, url, body)
+	var body io.Reader
+	if rd.hasBody {
+		body = bytes.NewReader(rd.body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
-		panic(runtime.NewGoError(err))
+		panic(vm.NewError(runtime, "fetch", vm.CodeOperationFailed, err))
 	}
 
-	// Set headers
-	for key, value := range headers {
+	// Set headers, letting request headers override the defaults
+	for key, value := range f.headers {
 		req.Header.Set(key, value)
 	}
+	for key, values := range rd.header {
+		req.Header[key] = values
+	}
+
+	// Serve fresh responses from the HTTP cache without a network call
+	var readCache, writeCache bool
+	key := cacheKey(method, url)
+	if f.cache != nil && req.URL.User == nil {
+		readCache, writeCache = cacheable(method, req.Header, cacheMode)
+	}
+	if readCache {
+		if cached, ok := f.lookupCache(ctx, key); ok {
+			return newResponseObject(runtime, cached.Status, cached.StatusText, cached.URL, cached.Header, cached.Body)
+		}
+	}
 
 	// Make the request
 	resp, err := f.client.Do(req)
 	if err != nil {
-		panic(runtime.NewGoError(err))
-	}
-
-	// Create Response object
-	responseObj := runtime.NewObject()
-	responseObj.Set("status", resp.StatusCode)
-	responseObj.Set("statusText", resp.Status)
-	responseObj.Set("ok", resp.StatusCode >= 200 && resp.StatusCode < 300)
-	responseObj.Set("url", resp.Request.URL.String())
-
-	// Headers object
-	headersObj := runtime.NewObject()
-	for key, values := range resp.Header {
-		if len(values) > 0 {
-			headersObj.Set(key, values[0])
+		if signal != nil && signal.aborted() {
+			panic(signal.reasonValue())
 		}
+		panic(vm.NewError(runtime, "fetch", vm.CodeNetwork, err))
 	}
-	responseObj.Set("headers", headersObj)
 
-	// Read response body
-	bodyBytes, err := io.ReadAll(resp.Body)
+	// Read response body, refusing bodies over the cap before and while
+	// reading them
+	if resp.ContentLength > f.maxBody {
+		resp.Body.Close()
+		panic(f.tooLarge(runtime))
+	}
+	bodyBytes, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBody+1))
 	resp.Body.Close()
 	if err != nil {
-		panic(runtime.NewGoError(err))
+		if signal != nil && signal.aborted() {
+			panic(signal.reasonValue())
+		}
+		panic(vm.NewError(runtime, "fetch", vm.CodeNetwork, err))
+	}
+	if int64(len(bodyBytes)) > f.maxBody {
+		panic(f.tooLarge(runtime))
 	}
 
-	// text() method
+	if writeCache {
+		f.storeCache(ctx, key, resp, bodyBytes)
+	}
+
+	return newResponseObject(runtime, resp.StatusCode, resp.Status, resp.Request.URL.String(), resp.Header, bodyBytes)
+}
+
+// newResponseObject creates the object returned by fetch
+func newResponseObject(runtime *sobek.Runtime, status int, statusText, url string, header http.Header, bodyBytes []byte) *sobek.Object {
+	responseObj := runtime.NewObject()
+	responseObj.Set("status", status)
+	responseObj.Set("statusText", statusText)
+	responseObj.Set("ok", status >= 200 && status < 300)
+	responseObj.Set("url", url)
+
+	// Headers object
+	headersObj := headers.New(runtime, header)
+	responseObj.Set("headers", headersObj)
+
+	// text() method, decoding the body from the charset in Content-Type
 	responseObj.Set("text", func(call sobek.FunctionCall) sobek.Value {
-		return runtime.ToValue(string(bodyBytes))
+		return runtime.ToValue(decodeBody(header, bodyBytes))
+	})
+
+	// bytes() - returns the raw body as a Uint8Array
+	responseObj.Set("bytes", func(call sobek.FunctionCall) sobek.Value {
+		value, err := runtime.New(runtime.Get("Uint8Array"), runtime.ToValue(runtime.NewArrayBuffer(bytes.Clone(bodyBytes))))
+		if err != nil {
+			panic(err)
+		}
+		return value
 	})
 
 	// json() method
@@ -217,7 +416,7 @@ func (f *FetchModule) handleFetch(call sobek.FunctionCall, runtime *sobek.Runtim
 			// Try to parse as JSON
 			jsonVal, err := runtime.RunString("JSON.parse(" + runtime.ToValue(string(bodyBytes)).String() + ")")
 			if err != nil {
-				panic(runtime.NewGoError(err))
+				panic(vm.NewError(runtime, "fetch", vm.CodeOperationFailed, err))
 			}
 			return jsonVal
 		}
@@ -229,9 +428,51 @@ func (f *FetchModule) handleFetch(call sobek.FunctionCall, runtime *sobek.Runtim
 		return runtime.ToValue(bodyBytes)
 	})
 
+	// formData() - resolves to the fields of a urlencoded or multipart body,
+	// chosen by the content type
+	responseObj.Set("formData", func(call sobek.FunctionCall) sobek.Value {
+		promise, resolve, reject := runtime.NewPromise()
+		fields, err := form.Parse(runtime, header.Get("Content-Type"), string(bodyBytes))
+		switch {
+		case errors.Is(err, form.ErrUnsupported):
+			_ = reject(vm.NewTypeError(runtime, "fetch", vm.CodeNotSupported, err.Error()))
+		case err != nil:
+			_ = reject(vm.NewError(runtime, "fetch", vm.CodeOperationFailed, err))
+		default:
+			_ = resolve(form.New(runtime, "fetch", fields))
+		}
+		return runtime.ToValue(promise)
+	})
+
 	return responseObj
 }
 
+// decodeBody converts body to a string using the charset parameter of the
+// Content-Type, treating it as UTF-8 when there is none or it is unknown
+func decodeBody(header http.Header, body []byte) string {
+	_, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil || params["charset"] == "" {
+		return string(body)
+	}
+	text, err := encoding.Decode(params["charset"], body)
+	if err != nil {
+		return string(body)
+	}
+	return text
+}
+
+// tooLarge is the error for a response body over the size cap
+func (f *FetchModule) tooLarge(runtime *sobek.Runtime) *sobek.Object {
+	return vm.NewRangeError(runtime, "fetch", vm.CodeQuotaExceeded,
+		fmt.Sprintf("fetch: response too large, the body exceeds %d bytes", f.maxBody))
+}
+
+// Warmup loads the system root certificates used by the first HTTPS request
+func (f *FetchModule) Warmup() error {
+	_, err := x509.SystemCertPool()
+	return err
+}
+
 // Cleanup performs any necessary cleanup
 func (f *FetchModule) Cleanup() error {
 	// HTTP client doesn't need explicit cleanup
@@ -0,0 +1,140 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cachedResponse is the form a fetch response is stored in the cache backend
+type cachedResponse struct {
+	Status     int         `json:"status"`
+	StatusText string      `json:"statusText"`
+	URL        string      `json:"url"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// cacheKey returns the cache key for a request
+func cacheKey(method, url string) string {
+	return "fetch:" + method + " " + url
+}
+
+// cacheControl parses a Cache-Control header into lower-cased directives
+func cacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, line := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(line, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if name == "" {
+				continue
+			}
+			directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+	return directives
+}
+
+// freshness returns how long a response may be served from the cache,
+// or zero if it must not be stored
+func freshness(resp *http.Response) time.Duration {
+	if resp.StatusCode != http.StatusOK {
+		return 0
+	}
+	// Responses that vary on request headers other than the transparently
+	// handled Accept-Encoding can't be keyed by URL alone
+	for _, line := range resp.Header.Values("Vary") {
+		for _, field := range strings.Split(line, ",") {
+			if field = strings.TrimSpace(field); field != "" && !strings.EqualFold(field, "Accept-Encoding") {
+				return 0
+			}
+		}
+	}
+
+	// The cache is shared by every execution, so a response meant for a
+	// single user is never stored
+	directives := cacheControl(resp.Header)
+	for _, name := range []string{"no-store", "no-cache", "private"} {
+		if _, ok := directives[name]; ok {
+			return 0
+		}
+	}
+	maxAge, err := strconv.Atoi(directives["max-age"])
+	if err != nil || maxAge <= 0 {
+		return 0
+	}
+
+	ttl := time.Duration(maxAge) * time.Second
+	if age, err := strconv.Atoi(resp.Header.Get("Age")); err == nil && age > 0 {
+		ttl -= time.Duration(age) * time.Second
+	}
+	return ttl
+}
+
+// cacheable reports whether a request may be served from or stored in the
+// cache. Requests carrying credentials never use it, as their responses
+// belong to whoever the credentials identify.
+func cacheable(method string, header http.Header, mode string) (read, write bool) {
+	if method != http.MethodGet || hasCredentials(header) {
+		return false, false
+	}
+	directives := cacheControl(header)
+	if _, ok := directives["no-store"]; ok {
+		return false, false
+	}
+	if _, ok := directives["no-cache"]; ok {
+		return false, true
+	}
+	switch mode {
+	case "no-store":
+		return false, false
+	case "reload", "no-cache":
+		return false, true
+	}
+	return true, true
+}
+
+// hasCredentials reports whether header authenticates the request
+func hasCredentials(header http.Header) bool {
+	for _, name := range []string{"Authorization", "Cookie", "Proxy-Authorization"} {
+		if header.Get(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupCache returns a fresh cached response for the request, if any
+func (f *FetchModule) lookupCache(ctx context.Context, key string) (*cachedResponse, bool) {
+	data, err := f.cache.Get(ctx, key)
+	if err != nil || data == nil {
+		return nil, false
+	}
+	var cached cachedResponse
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, false
+	}
+	return &cached, true
+}
+
+// storeCache stores a response for as long as it stays fresh
+func (f *FetchModule) storeCache(ctx context.Context, key string, resp *http.Response, body []byte) {
+	ttl := freshness(resp)
+	if ttl <= 0 {
+		return
+	}
+	data, err := json.Marshal(cachedResponse{
+		Status:     resp.StatusCode,
+		StatusText: resp.Status,
+		URL:        resp.Request.URL.String(),
+		Header:     resp.Header,
+		Body:       body,
+	})
+	if err != nil {
+		return
+	}
+	_ = f.cache.Set(ctx, key, data, ttl)
+}
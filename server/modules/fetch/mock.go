@@ -0,0 +1,77 @@
+package fetch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// MockResponse is a canned response served by fetch in mock mode
+type MockResponse struct {
+	Status  int               `json:"status"` // Defaults to 200
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+// LoadMocks reads mock responses from a JSON file mapping "URL" or
+// "METHOD URL" keys to responses, e.g. a cassette recorded from real traffic
+func LoadMocks(path string) (map[string]MockResponse, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var mocks map[string]MockResponse
+	if err := json.Unmarshal(data, &mocks); err != nil {
+		return nil, fmt.Errorf("mock file %s: %w", path, err)
+	}
+	return mocks, nil
+}
+
+// mockTransport serves requests from canned responses instead of the
+// network. A "METHOD URL" key takes precedence over a bare URL; requests
+// matching neither fail like an unreachable host.
+type mockTransport struct {
+	mocks map[string]MockResponse
+}
+
+// RoundTrip returns the mock response for req
+func (t *mockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	url := req.URL.String()
+	mock, ok := t.mocks[req.Method+" "+url]
+	if !ok {
+		mock, ok = t.mocks[url]
+	}
+	if !ok {
+		return nil, fmt.Errorf("no mock response for %s %s", req.Method, url)
+	}
+
+	status := mock.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	header := make(http.Header)
+	for key, value := range mock.Headers {
+		header.Set(key, value)
+	}
+	body := mock.Body
+	if req.Method == http.MethodHead {
+		body = ""
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
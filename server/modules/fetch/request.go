@@ -0,0 +1,161 @@
+package fetch
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/modules/internal/headers"
+	"github.com/mark3labs/codebench-mcp/server/modules/internal/view"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// requestData holds what a Request or a fetch call will send
+type requestData struct {
+	url     string
+	method  string
+	header  http.Header
+	body    []byte
+	hasBody bool
+	signal  sobek.Value
+}
+
+// setupRequest sets up the global Request class
+func (f *FetchModule) setupRequest(runtime *sobek.Runtime) {
+	runtime.Set("Request", func(call sobek.ConstructorCall) *sobek.Object {
+		obj := call.This
+		rd := newRequestData(runtime, call.Argument(0))
+		applyRequestInit(runtime, rd, call.Argument(1))
+		if rd.hasBody && (rd.method == http.MethodGet || rd.method == http.MethodHead) {
+			panic(vm.NewTypeError(runtime, "fetch", vm.CodeInvalidArgument, "Request with GET/HEAD method cannot have body"))
+		}
+
+		_ = obj.DefineDataProperty("__request", runtime.ToValue(rd), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_FALSE)
+		obj.Set("url", rd.url)
+		obj.Set("method", rd.method)
+		obj.Set("headers", headers.NewMutable(runtime, rd.header))
+		if rd.signal != nil {
+			obj.Set("signal", rd.signal)
+		}
+		if len(call.Arguments) > 1 {
+			obj.Set("options", call.Argument(1))
+		}
+
+		// text() - resolves to the body decoded as UTF-8
+		obj.Set("text", func(call sobek.FunctionCall) sobek.Value {
+			return settleLater(runtime, func() (sobek.Value, error) {
+				return runtime.ToValue(string(rd.body)), nil
+			})
+		})
+
+		// json() - resolves to the body parsed as JSON
+		obj.Set("json", func(call sobek.FunctionCall) sobek.Value {
+			return settleLater(runtime, func() (sobek.Value, error) {
+				parse, _ := sobek.AssertFunction(runtime.Get("JSON").ToObject(runtime).Get("parse"))
+				return parse(sobek.Undefined(), runtime.ToValue(string(rd.body)))
+			})
+		})
+
+		// arrayBuffer() - resolves to a copy of the body
+		obj.Set("arrayBuffer", func(call sobek.FunctionCall) sobek.Value {
+			return settleLater(runtime, func() (sobek.Value, error) {
+				return runtime.ToValue(runtime.NewArrayBuffer(bytes.Clone(rd.body))), nil
+			})
+		})
+
+		return nil
+	})
+}
+
+// newRequestData starts a request from a URL or copies an existing Request
+func newRequestData(runtime *sobek.Runtime, input sobek.Value) *requestData {
+	if src, ok := toRequestData(input); ok {
+		copied := *src
+		copied.header = src.header.Clone()
+		return &copied
+	}
+	if input == nil || sobek.IsUndefined(input) {
+		panic(vm.NewTypeError(runtime, "fetch", vm.CodeInvalidArgument, "Request: URL is required"))
+	}
+	return &requestData{
+		url:    input.String(),
+		method: http.MethodGet,
+		header: make(http.Header),
+	}
+}
+
+// applyRequestInit applies method, headers, body and signal from an init object
+func applyRequestInit(runtime *sobek.Runtime, rd *requestData, init sobek.Value) {
+	if init == nil || sobek.IsUndefined(init) || sobek.IsNull(init) {
+		return
+	}
+	options := init.ToObject(runtime)
+
+	if v := options.Get("method"); v != nil && !sobek.IsUndefined(v) {
+		rd.method = strings.ToUpper(v.String())
+	}
+
+	if v := options.Get("headers"); v != nil && !sobek.IsUndefined(v) && !sobek.IsNull(v) {
+		// Each header in init replaces all values of that header
+		for key, values := range headers.Parse(runtime, v) {
+			rd.header[key] = values
+		}
+	}
+
+	if v := options.Get("body"); v != nil && !sobek.IsUndefined(v) && !sobek.IsNull(v) {
+		rd.body = bodyBytes(runtime, v)
+		rd.hasBody = true
+	}
+
+	if v := options.Get("signal"); v != nil && !sobek.IsUndefined(v) {
+		rd.signal = v
+	}
+}
+
+// toRequestData extracts the request data from a JavaScript Request
+func toRequestData(value sobek.Value) (*requestData, bool) {
+	obj, ok := value.(*sobek.Object)
+	if !ok {
+		return nil, false
+	}
+	if v := obj.Get("__request"); v != nil {
+		rd, ok := v.Export().(*requestData)
+		return rd, ok
+	}
+	return nil, false
+}
+
+// bodyBytes converts a request body (string, ArrayBuffer or typed array) to bytes
+func bodyBytes(runtime *sobek.Runtime, value sobek.Value) []byte {
+	if obj, ok := value.(*sobek.Object); ok {
+		switch v := obj.Export().(type) {
+		case sobek.ArrayBuffer:
+			return v.Bytes()
+		case []byte:
+			return v
+		}
+		// Other typed arrays and DataViews expose their underlying buffer
+		if data, ok := view.Bytes(runtime, "fetch", obj); ok {
+			return data
+		}
+	}
+	return []byte(value.String())
+}
+
+// settleLater returns a promise settled with value() on the event loop
+func settleLater(runtime *sobek.Runtime, value func() (sobek.Value, error)) sobek.Value {
+	promise, resolve, reject := runtime.NewPromise()
+	enqueue := vm.EnqueueJob(runtime)
+	go enqueue(func() error {
+		v, err := value()
+		if err != nil {
+			if ex, ok := err.(*sobek.Exception); ok {
+				return reject(ex.Value())
+			}
+			return reject(vm.NewError(runtime, "fetch", vm.CodeOperationFailed, err))
+		}
+		return resolve(v)
+	})
+	return runtime.ToValue(promise)
+}
@@ -0,0 +1,290 @@
+package html
+
+import (
+	"strings"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+	"golang.org/x/net/html"
+)
+
+// HTMLModule provides HTML parsing into a read-only, queryable DOM-like tree
+type HTMLModule struct{}
+
+// NewHTMLModule creates a new html module
+func NewHTMLModule() *HTMLModule {
+	return &HTMLModule{}
+}
+
+// Name returns the module name
+func (h *HTMLModule) Name() string {
+	return "html"
+}
+
+// Setup initializes the html module in the VM
+func (h *HTMLModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) error {
+	// No setup needed - the module will be available via require()
+	return nil
+}
+
+// CreateModuleObject creates the html object when required
+func (h *HTMLModule) CreateModuleObject(runtime *sobek.Runtime) sobek.Value {
+	module := runtime.NewObject()
+
+	// parse(text) - parses an HTML document, adding the html, head and body
+	// elements when they are missing, and returns its document node
+	module.Set("parse", func(call sobek.FunctionCall) sobek.Value {
+		if len(call.Arguments) == 0 {
+			panic(vm.NewTypeError(runtime, "html", vm.CodeInvalidArgument, "parse: 1 argument required, but only 0 present"))
+		}
+		root, err := html.Parse(strings.NewReader(call.Argument(0).String()))
+		if err != nil {
+			panic(vm.NewError(runtime, "html", vm.CodeOperationFailed, err))
+		}
+		d := &document{rt: runtime, nodes: make(map[*html.Node]*sobek.Object)}
+		return d.wrap(root)
+	})
+
+	return module
+}
+
+// document wraps the nodes of one parsed document, keeping one object per
+// node so the same node always compares equal
+type document struct {
+	rt    *sobek.Runtime
+	nodes map[*html.Node]*sobek.Object
+}
+
+// Node types, as in the DOM
+const (
+	elementNode  = 1
+	textNode     = 3
+	commentNode  = 8
+	documentNode = 9
+	doctypeNode  = 10
+)
+
+// wrap returns the object for n, or null for nil
+func (d *document) wrap(n *html.Node) sobek.Value {
+	if n == nil {
+		return sobek.Null()
+	}
+	if obj, ok := d.nodes[n]; ok {
+		return obj
+	}
+	rt := d.rt
+	obj := rt.NewObject()
+	d.nodes[n] = obj
+
+	getter := func(name string, get func() sobek.Value) {
+		fn := rt.ToValue(func(sobek.FunctionCall) sobek.Value { return get() })
+		_ = obj.DefineAccessorProperty(name, fn, nil, sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+	}
+	method := func(name string, fn func(call sobek.FunctionCall) sobek.Value) {
+		_ = obj.DefineDataProperty(name, rt.ToValue(fn), sobek.FLAG_TRUE, sobek.FLAG_TRUE, sobek.FLAG_FALSE)
+	}
+
+	switch n.Type {
+	case html.ElementNode:
+		obj.Set("nodeType", elementNode)
+		obj.Set("nodeName", strings.ToUpper(n.Data))
+		obj.Set("tagName", strings.ToUpper(n.Data))
+		id, _ := attribute(n, "id")
+		obj.Set("id", id)
+		class, _ := attribute(n, "class")
+		obj.Set("className", class)
+		attrs := rt.NewObject()
+		for _, attr := range n.Attr {
+			attrs.Set(attr.Key, attr.Val)
+		}
+		obj.Set("attributes", attrs)
+	case html.TextNode:
+		obj.Set("nodeType", textNode)
+		obj.Set("nodeName", "#text")
+	case html.CommentNode:
+		obj.Set("nodeType", commentNode)
+		obj.Set("nodeName", "#comment")
+	case html.DocumentNode:
+		obj.Set("nodeType", documentNode)
+		obj.Set("nodeName", "#document")
+	case html.DoctypeNode:
+		obj.Set("nodeType", doctypeNode)
+		obj.Set("nodeName", n.Data)
+	}
+
+	getter("textContent", func() sobek.Value { return rt.ToValue(textContent(n)) })
+	getter("parentNode", func() sobek.Value { return d.wrap(n.Parent) })
+	getter("childNodes", func() sobek.Value {
+		var nodes []any
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			nodes = append(nodes, d.wrap(c))
+		}
+		return rt.NewArray(nodes...)
+	})
+	getter("children", func() sobek.Value {
+		var nodes []any
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode {
+				nodes = append(nodes, d.wrap(c))
+			}
+		}
+		return rt.NewArray(nodes...)
+	})
+	getter("innerHTML", func() sobek.Value {
+		var b strings.Builder
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			_ = html.Render(&b, c)
+		}
+		return rt.ToValue(b.String())
+	})
+	getter("outerHTML", func() sobek.Value {
+		var b strings.Builder
+		_ = html.Render(&b, n)
+		return rt.ToValue(b.String())
+	})
+
+	if n.Type == html.DocumentNode {
+		getter("documentElement", func() sobek.Value { return d.wrap(findElement(n, "html")) })
+		getter("head", func() sobek.Value { return d.wrap(findElement(n, "head")) })
+		getter("body", func() sobek.Value { return d.wrap(findElement(n, "body")) })
+		getter("title", func() sobek.Value {
+			if title := findElement(n, "title"); title != nil {
+				return rt.ToValue(strings.TrimSpace(textContent(title)))
+			}
+			return rt.ToValue("")
+		})
+	}
+
+	// getAttribute(name) - the attribute's value, or null
+	method("getAttribute", func(call sobek.FunctionCall) sobek.Value {
+		if value, ok := attribute(n, strings.ToLower(call.Argument(0).String())); ok {
+			return rt.ToValue(value)
+		}
+		return sobek.Null()
+	})
+	method("hasAttribute", func(call sobek.FunctionCall) sobek.Value {
+		_, ok := attribute(n, strings.ToLower(call.Argument(0).String()))
+		return rt.ToValue(ok)
+	})
+
+	// querySelector(selectors) - the first descendant element matching, or null
+	method("querySelector", func(call sobek.FunctionCall) sobek.Value {
+		list := d.selectors(call.Argument(0), "querySelector")
+		var found *html.Node
+		walk(n, func(e *html.Node) bool {
+			if matches(e, list) {
+				found = e
+				return false
+			}
+			return true
+		})
+		return d.wrap(found)
+	})
+	// querySelectorAll(selectors) - all descendant elements matching, in document order
+	method("querySelectorAll", func(call sobek.FunctionCall) sobek.Value {
+		list := d.selectors(call.Argument(0), "querySelectorAll")
+		return d.collect(n, func(e *html.Node) bool { return matches(e, list) })
+	})
+	method("getElementsByTagName", func(call sobek.FunctionCall) sobek.Value {
+		tag := strings.ToLower(call.Argument(0).String())
+		return d.collect(n, func(e *html.Node) bool { return tag == "*" || e.Data == tag })
+	})
+	method("getElementsByClassName", func(call sobek.FunctionCall) sobek.Value {
+		list := []selector{{compound{classes: strings.Fields(call.Argument(0).String())}}}
+		return d.collect(n, func(e *html.Node) bool { return matches(e, list) })
+	})
+	method("getElementById", func(call sobek.FunctionCall) sobek.Value {
+		id := call.Argument(0).String()
+		var found *html.Node
+		walk(n, func(e *html.Node) bool {
+			if value, ok := attribute(e, "id"); ok && value == id {
+				found = e
+				return false
+			}
+			return true
+		})
+		return d.wrap(found)
+	})
+
+	return obj
+}
+
+// selectors parses a selector argument, throwing a SyntaxError if it is invalid
+func (d *document) selectors(v sobek.Value, method string) []selector {
+	list, err := parseSelectors(v.String())
+	if err != nil {
+		panic(vm.NewSyntaxError(d.rt, "html", vm.CodeInvalidArgument, method+": "+err.Error()))
+	}
+	return list
+}
+
+// collect returns an array of the descendant elements of n for which keep
+// returns true
+func (d *document) collect(n *html.Node, keep func(*html.Node) bool) sobek.Value {
+	var nodes []any
+	walk(n, func(e *html.Node) bool {
+		if keep(e) {
+			nodes = append(nodes, d.wrap(e))
+		}
+		return true
+	})
+	return d.rt.NewArray(nodes...)
+}
+
+// walk calls visit for each descendant element of n in document order, until
+// visit returns false
+func walk(n *html.Node, visit func(*html.Node) bool) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && !visit(c) {
+			return false
+		}
+		if !walk(c, visit) {
+			return false
+		}
+	}
+	return true
+}
+
+// findElement returns the first descendant element of n named tag
+func findElement(n *html.Node, tag string) *html.Node {
+	var found *html.Node
+	walk(n, func(e *html.Node) bool {
+		if e.Data == tag {
+			found = e
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// textContent concatenates the text of n and its descendants
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode || n.Type == html.CommentNode {
+		return n.Data
+	}
+	var b strings.Builder
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.TextNode {
+				b.WriteString(c.Data)
+			}
+			visit(c)
+		}
+	}
+	visit(n)
+	return b.String()
+}
+
+// Cleanup performs any necessary cleanup
+func (h *HTMLModule) Cleanup() error {
+	// HTML module doesn't need cleanup
+	return nil
+}
+
+// IsEnabled checks if the module should be enabled based on configuration
+func (h *HTMLModule) IsEnabled(enabledModules map[string]bool) bool {
+	enabled, exists := enabledModules["html"]
+	return exists && enabled
+}
@@ -0,0 +1,312 @@
+package html
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// selector is one complex selector, such as "div.post > a[href]", as its
+// compound selectors from left to right
+type selector []compound
+
+// compound is a compound selector such as "a.link[href]", with the
+// combinator relating it to the compound before it
+type compound struct {
+	combinator byte // ' ' for descendant, '>' for child, 0 for the first
+	tag        string
+	id         string
+	classes    []string
+	attrs      []attrMatch
+}
+
+// attrMatch is an attribute selector such as [href^="https"]
+type attrMatch struct {
+	name  string
+	op    string // "", "=", "~=", "^=", "$=", "*=" or "|="
+	value string
+}
+
+// parseSelectors parses a comma-separated selector list. It supports type,
+// universal, id, class and attribute selectors with the descendant and child
+// combinators.
+func parseSelectors(text string) ([]selector, error) {
+	p := &selectorParser{text: text}
+	var list []selector
+	for {
+		sel, err := p.selector()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, sel)
+		p.skipSpace()
+		if p.done() {
+			return list, nil
+		}
+		if p.text[p.pos] != ',' {
+			return nil, p.errorf("unexpected %q", p.text[p.pos])
+		}
+		p.pos++
+	}
+}
+
+// selectorParser reads a selector list one character at a time
+type selectorParser struct {
+	text string
+	pos  int
+}
+
+func (p *selectorParser) done() bool {
+	return p.pos >= len(p.text)
+}
+
+func (p *selectorParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid selector %q: %s", p.text, fmt.Sprintf(format, args...))
+}
+
+func (p *selectorParser) skipSpace() bool {
+	start := p.pos
+	for !p.done() && strings.IndexByte(" \t\n\r\f", p.text[p.pos]) >= 0 {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+// selector reads compound selectors and combinators up to a comma or the end
+func (p *selectorParser) selector() (selector, error) {
+	var sel selector
+	p.skipSpace()
+	var combinator byte
+	for {
+		c, err := p.compound()
+		if err != nil {
+			return nil, err
+		}
+		c.combinator = combinator
+		sel = append(sel, c)
+
+		spaced := p.skipSpace()
+		if p.done() || p.text[p.pos] == ',' {
+			return sel, nil
+		}
+		switch {
+		case p.text[p.pos] == '>':
+			combinator = '>'
+			p.pos++
+			p.skipSpace()
+		case spaced:
+			combinator = ' '
+		default:
+			return nil, p.errorf("unexpected %q", p.text[p.pos])
+		}
+	}
+}
+
+// compound reads a compound selector
+func (p *selectorParser) compound() (compound, error) {
+	var c compound
+	start := p.pos
+	if !p.done() && p.text[p.pos] == '*' {
+		p.pos++
+	} else if name := p.name(); name != "" {
+		c.tag = strings.ToLower(name)
+	}
+	for !p.done() {
+		switch p.text[p.pos] {
+		case '#':
+			p.pos++
+			if c.id = p.name(); c.id == "" {
+				return c, p.errorf("expected an id after #")
+			}
+		case '.':
+			p.pos++
+			class := p.name()
+			if class == "" {
+				return c, p.errorf("expected a class name after .")
+			}
+			c.classes = append(c.classes, class)
+		case '[':
+			attr, err := p.attr()
+			if err != nil {
+				return c, err
+			}
+			c.attrs = append(c.attrs, attr)
+		case ':':
+			return c, p.errorf("pseudo-classes are not supported")
+		default:
+			if p.pos == start {
+				return c, p.errorf("unexpected %q", p.text[p.pos])
+			}
+			return c, nil
+		}
+	}
+	if p.pos == start {
+		return c, p.errorf("expected a selector")
+	}
+	return c, nil
+}
+
+// attr reads an attribute selector, starting at its [
+func (p *selectorParser) attr() (attrMatch, error) {
+	var a attrMatch
+	p.pos++ // [
+	p.skipSpace()
+	if a.name = strings.ToLower(p.name()); a.name == "" {
+		return a, p.errorf("expected an attribute name")
+	}
+	p.skipSpace()
+	if !p.done() && p.text[p.pos] == ']' {
+		p.pos++
+		return a, nil
+	}
+
+	for _, op := range []string{"=", "~=", "^=", "$=", "*=", "|="} {
+		if strings.HasPrefix(p.text[p.pos:], op) {
+			a.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if a.op == "" {
+		return a, p.errorf("expected ] or an attribute operator")
+	}
+	p.skipSpace()
+	if !p.done() && (p.text[p.pos] == '"' || p.text[p.pos] == '\'') {
+		quote := p.text[p.pos]
+		end := strings.IndexByte(p.text[p.pos+1:], quote)
+		if end < 0 {
+			return a, p.errorf("unterminated string")
+		}
+		a.value = p.text[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+	} else {
+		a.value = p.name()
+	}
+	p.skipSpace()
+	if p.done() || p.text[p.pos] != ']' {
+		return a, p.errorf("expected ]")
+	}
+	p.pos++
+	return a, nil
+}
+
+// name reads an identifier, returning "" if there is none
+func (p *selectorParser) name() string {
+	start := p.pos
+	for !p.done() {
+		ch := p.text[p.pos]
+		if ch == '-' || ch == '_' || ch >= 0x80 ||
+			(ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.text[start:p.pos]
+}
+
+// matches reports whether n matches any selector in list
+func matches(n *html.Node, list []selector) bool {
+	for _, sel := range list {
+		if sel.matches(n, len(sel)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether n matches the selector up to its i-th compound,
+// checking the compounds before it against n's ancestors
+func (sel selector) matches(n *html.Node, i int) bool {
+	if !sel[i].matches(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	switch sel[i].combinator {
+	case '>':
+		return n.Parent != nil && sel.matches(n.Parent, i-1)
+	default:
+		for p := n.Parent; p != nil; p = p.Parent {
+			if sel.matches(p, i-1) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// matches reports whether the element n matches the compound selector
+func (c compound) matches(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if c.tag != "" && n.Data != c.tag {
+		return false
+	}
+	if c.id != "" {
+		if id, ok := attribute(n, "id"); !ok || id != c.id {
+			return false
+		}
+	}
+	if len(c.classes) > 0 {
+		class, _ := attribute(n, "class")
+		fields := strings.Fields(class)
+		for _, want := range c.classes {
+			found := false
+			for _, f := range fields {
+				if f == want {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	for _, a := range c.attrs {
+		value, ok := attribute(n, a.name)
+		if !ok || !a.matches(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches reports whether an attribute's value satisfies the selector
+func (a attrMatch) matches(value string) bool {
+	switch a.op {
+	case "=":
+		return value == a.value
+	case "~=":
+		for _, f := range strings.Fields(value) {
+			if f == a.value {
+				return true
+			}
+		}
+		return false
+	case "^=":
+		return a.value != "" && strings.HasPrefix(value, a.value)
+	case "$=":
+		return a.value != "" && strings.HasSuffix(value, a.value)
+	case "*=":
+		return a.value != "" && strings.Contains(value, a.value)
+	case "|=":
+		return value == a.value || strings.HasPrefix(value, a.value+"-")
+	default:
+		return true
+	}
+}
+
+// attribute returns the value of n's attribute name
+func attribute(n *html.Node, name string) (string, bool) {
+	for _, attr := range n.Attr {
+		if attr.Namespace == "" && attr.Key == name {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
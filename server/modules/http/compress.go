@@ -0,0 +1,95 @@
+package http
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// minCompressSize is the smallest body worth compressing
+const minCompressSize = 1024
+
+// compressedTypes are content types whose bodies are already compressed
+var compressedTypes = map[string]bool{
+	"application/gzip":             true,
+	"application/x-gzip":           true,
+	"application/zip":              true,
+	"application/x-bzip2":          true,
+	"application/x-7z-compressed":  true,
+	"application/x-rar-compressed": true,
+	"application/pdf":              true,
+	"font/woff":                    true,
+	"font/woff2":                   true,
+}
+
+// negotiateEncoding picks gzip or deflate for a response body of size bytes,
+// or returns "" when the response should be sent as is
+func negotiateEncoding(r *http.Request, res *http.Response, size int) string {
+	if size < minCompressSize || r.Method == http.MethodHead {
+		return ""
+	}
+	if res.StatusCode == http.StatusNoContent || res.StatusCode == http.StatusNotModified {
+		return ""
+	}
+	if res.Header.Get("Content-Encoding") != "" || isCompressedType(res.Header.Get("Content-Type")) {
+		return ""
+	}
+
+	accepted := acceptedEncodings(r.Header.Get("Accept-Encoding"))
+	for _, encoding := range []string{"gzip", "deflate"} {
+		if accepted[encoding] {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// acceptedEncodings parses an Accept-Encoding header, leaving out encodings
+// the client refuses with q=0
+func acceptedEncodings(header string) map[string]bool {
+	accepted := make(map[string]bool)
+	for _, item := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(item, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				continue
+			}
+		}
+		accepted[name] = true
+	}
+	return accepted
+}
+
+// isCompressedType reports whether contentType is already compressed, like
+// images, audio and video other than SVG
+func isCompressedType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "image/svg+xml":
+		return false
+	case strings.HasPrefix(mediaType, "image/"),
+		strings.HasPrefix(mediaType, "audio/"),
+		strings.HasPrefix(mediaType, "video/"):
+		return true
+	}
+	return compressedTypes[mediaType]
+}
+
+// newCompressor wraps w with a writer for encoding
+func newCompressor(w io.Writer, encoding string) io.WriteCloser {
+	if encoding == "deflate" {
+		return zlib.NewWriter(w)
+	}
+	return gzip.NewWriter(w)
+}
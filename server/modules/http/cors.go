@@ -0,0 +1,115 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// defaultCORSMethods are allowed in preflight responses when no methods are configured
+var defaultCORSMethods = []string{"GET", "HEAD", "PUT", "PATCH", "POST", "DELETE"}
+
+// corsConfig holds the serve cors option
+type corsConfig struct {
+	origins     []string // Allowed origins, "*" allows any
+	methods     []string // Methods allowed in preflight responses
+	headers     []string // Request headers allowed in preflight responses, empty echoes the requested ones
+	credentials bool     // Whether cookies and auth headers may be sent
+}
+
+// parseCORS reads the cors option, either true for permissive defaults or an
+// object with origins, methods, headers and credentials
+func parseCORS(runtime *sobek.Runtime, v sobek.Value) *corsConfig {
+	if sobek.IsUndefined(v) || sobek.IsNull(v) || !v.ToBoolean() {
+		return nil
+	}
+	cfg := &corsConfig{origins: []string{"*"}, methods: defaultCORSMethods}
+	obj, ok := v.(*sobek.Object)
+	if !ok {
+		return cfg
+	}
+
+	if origins := stringList(runtime, obj.Get("origins"), "cors.origins"); origins != nil {
+		cfg.origins = origins
+	}
+	if methods := stringList(runtime, obj.Get("methods"), "cors.methods"); methods != nil {
+		cfg.methods = methods
+		for i, method := range methods {
+			cfg.methods[i] = strings.ToUpper(method)
+		}
+	}
+	cfg.headers = stringList(runtime, obj.Get("headers"), "cors.headers")
+	if v := obj.Get("credentials"); v != nil {
+		cfg.credentials = v.ToBoolean()
+	}
+	return cfg
+}
+
+// stringList converts a string or an array of strings, returning nil when v is unset
+func stringList(runtime *sobek.Runtime, v sobek.Value, name string) []string {
+	if v == nil || sobek.IsUndefined(v) || sobek.IsNull(v) {
+		return nil
+	}
+	if _, ok := v.Export().(string); ok {
+		return []string{v.String()}
+	}
+	var list []string
+	if err := runtime.ExportTo(v, &list); err != nil {
+		panic(vm.NewTypeError(runtime, "http", vm.CodeInvalidArgument, name+" must be a string or an array of strings"))
+	}
+	return list
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for origin, or ""
+// when the origin is not allowed
+func (c *corsConfig) allowOrigin(origin string) string {
+	for _, allowed := range c.origins {
+		if allowed == "*" {
+			if c.credentials {
+				// Browsers reject a wildcard for credentialed requests
+				return origin
+			}
+			return "*"
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// apply sets the CORS response headers for r and reports whether r was a
+// preflight request that has been answered
+func (c *corsConfig) apply(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	allowed := c.allowOrigin(origin)
+	if allowed != "" {
+		header.Set("Access-Control-Allow-Origin", allowed)
+		if c.credentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+	}
+
+	if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+		return false
+	}
+
+	if allowed != "" {
+		header.Set("Access-Control-Allow-Methods", strings.Join(c.methods, ", "))
+		if len(c.headers) > 0 {
+			header.Set("Access-Control-Allow-Headers", strings.Join(c.headers, ", "))
+		} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+			header.Set("Access-Control-Allow-Headers", requested)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
@@ -1,10 +1,12 @@
 package http
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"net/http"
 	"strings"
@@ -14,15 +16,59 @@ import (
 
 	"github.com/grafana/sobek"
 	"github.com/mark3labs/codebench-mcp/internal/logger"
+	"github.com/mark3labs/codebench-mcp/server/modules/internal/form"
+	"github.com/mark3labs/codebench-mcp/server/modules/internal/headers"
 	"github.com/mark3labs/codebench-mcp/server/vm"
 )
 
+// DefaultPort and DefaultHostname are where servers listen when neither the
+// script nor Options say otherwise
+const (
+	DefaultPort     = 8000
+	DefaultHostname = "127.0.0.1"
+)
+
+// AnyPort as Options.DefaultPort lets the OS pick a free port for servers
+// started without one; scripts read it from server.port
+const AnyPort = -1
+
+// Options configures an HTTP module
+type Options struct {
+	// DefaultPort is used when serve() is called without a port (defaults
+	// to DefaultPort; AnyPort picks a free port)
+	DefaultPort int
+	// DefaultHostname is the address servers bind to when the script doesn't
+	// give a hostname (defaults to DefaultHostname)
+	DefaultHostname string
+	// LoopbackOnly keeps servers off other interfaces: scripts asking for a
+	// non-loopback hostname get a TypeError, and servers that would bind all
+	// interfaces bind the default hostname, or 127.0.0.1 if it isn't loopback
+	LoopbackOnly bool
+}
+
+// isLoopback reports whether hostname only reaches the local machine
+func isLoopback(hostname string) bool {
+	if strings.EqualFold(hostname, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(hostname)
+	return ip != nil && ip.IsLoopback()
+}
+
 // HTTPModule provides HTTP server functionality
-type HTTPModule struct{}
+type HTTPModule struct {
+	opts Options
+}
 
 // NewHTTPModule creates a new HTTP module
 func NewHTTPModule() *HTTPModule {
-	return &HTTPModule{}
+	return NewHTTPModuleWithOptions(Options{})
+}
+
+// NewHTTPModuleWithOptions creates an HTTP module whose servers default to
+// the port and hostname in opts
+func NewHTTPModuleWithOptions(opts Options) *HTTPModule {
+	return &HTTPModule{opts: opts}
 }
 
 // Name returns the module name
@@ -48,14 +94,26 @@ func (h *HTTPModule) CreateModuleObject(runtime *sobek.Runtime) sobek.Value {
 func (h *HTTPModule) createServer(call sobek.FunctionCall, runtime *sobek.Runtime) sobek.Value {
 	serv := &httpServer{
 		rt:       runtime,
-		port:     8000,
-		hostname: "127.0.0.1",
+		port:     DefaultPort,
+		hostname: DefaultHostname,
 		ctx:      context.Background(),
-		server:   &http.Server{Addr: "127.0.0.1:8000"},
+		server:   &http.Server{},
+	}
+	switch {
+	case h.opts.DefaultPort == AnyPort:
+		serv.port = 0
+	case h.opts.DefaultPort > 0:
+		serv.port = h.opts.DefaultPort
+	}
+	if h.opts.DefaultHostname != "" && (!h.opts.LoopbackOnly || isLoopback(h.opts.DefaultHostname)) {
+		serv.hostname = h.opts.DefaultHostname
 	}
+	// A port without a hostname binds all interfaces, unless a default
+	// hostname is configured
+	bindAll := false
 
 	if len(call.Arguments) == 0 {
-		panic(runtime.NewTypeError("serve requires at least one argument"))
+		panic(vm.NewTypeError(runtime, "http", vm.CodeInvalidArgument, "serve requires at least one argument"))
 	}
 
 	var handler sobek.Value
@@ -65,46 +123,30 @@ func (h *HTTPModule) createServer(call sobek.FunctionCall, runtime *sobek.Runtim
 	case isNumber(opt):
 		port := opt.ToInteger()
 		if port <= 0 {
-			panic(runtime.NewTypeError("port must be a positive number"))
+			panic(vm.NewTypeError(runtime, "http", vm.CodeInvalidArgument, "port must be a positive number"))
 		}
 		serv.port = int(port)
-		serv.server.Addr = fmt.Sprintf(":%d", serv.port)
+		bindAll = h.opts.DefaultHostname == ""
 		handler = call.Argument(1)
+		if v := call.Argument(2); !sobek.IsUndefined(v) && !sobek.IsNull(v) {
+			serv.setOptions(v.ToObject(runtime))
+		}
 	case isFunc(opt):
 		handler = opt
 	default:
 		opts := opt.ToObject(runtime)
 		if v := opts.Get("port"); v != nil {
 			serv.port = int(v.ToInteger())
-			serv.server.Addr = fmt.Sprintf(":%d", serv.port)
+			bindAll = h.opts.DefaultHostname == ""
 		}
 		if v := opts.Get("hostname"); v != nil {
 			serv.hostname = v.String()
-			serv.server.Addr = fmt.Sprintf("%s:%d", serv.hostname, serv.port)
-		}
-		if v := opts.Get("maxHeaderSize"); v != nil {
-			serv.server.MaxHeaderBytes = int(v.ToInteger())
-		}
-		if v := opts.Get("keepAliveTimeout"); v != nil {
-			serv.server.IdleTimeout = time.Duration(v.ToInteger()) * time.Millisecond
-		}
-		if v := opts.Get("requestTimeout"); v != nil {
-			serv.server.ReadTimeout = time.Duration(v.ToInteger()) * time.Millisecond
-		}
-		if v := opts.Get("onError"); v != nil {
-			var ok bool
-			serv.onError, ok = sobek.AssertFunction(v)
-			if !ok {
-				panic(runtime.NewTypeError("onError must be a function"))
-			}
-		}
-		if v := opts.Get("onListen"); v != nil {
-			var ok bool
-			serv.onListen, ok = sobek.AssertFunction(v)
-			if !ok {
-				panic(runtime.NewTypeError("onListen must be a function"))
+			bindAll = false
+			if h.opts.LoopbackOnly && !isLoopback(serv.hostname) {
+				panic(vm.NewTypeError(runtime, "http", vm.CodeNotSupported, fmt.Sprintf("hostname %q is not allowed, servers may only listen on loopback", serv.hostname)))
 			}
 		}
+		serv.setOptions(opts)
 		if v := opts.Get("handler"); v != nil {
 			handler = v
 		}
@@ -117,7 +159,7 @@ func (h *HTTPModule) createServer(call sobek.FunctionCall, runtime *sobek.Runtim
 		var ok bool
 		serv.handler, ok = sobek.AssertFunction(handler)
 		if !ok {
-			panic(runtime.NewTypeError("handler must be a function"))
+			panic(vm.NewTypeError(runtime, "http", vm.CodeInvalidArgument, "handler must be a function"))
 		}
 	}
 	if serv.onError == nil {
@@ -151,10 +193,24 @@ func (h *HTTPModule) createServer(call sobek.FunctionCall, runtime *sobek.Runtim
 		}
 	}
 
+	if bindAll && !h.opts.LoopbackOnly {
+		serv.server.Addr = fmt.Sprintf(":%d", serv.port)
+	} else {
+		serv.server.Addr = net.JoinHostPort(serv.hostname, fmt.Sprint(serv.port))
+	}
+
 	serv.server.Handler = serv
 	serv.ref = vm.EnqueueJob(runtime)
 	ln := serv.listen()
 
+	// Stop serving when the VM goes away without the server being closed,
+	// e.g. when the execution times out or the runtime is terminated
+	vm.Cleanup(runtime, func() {
+		if !serv.closed.Load() {
+			_ = serv.close()
+		}
+	})
+
 	go func() {
 		vm.EnqueueJob(runtime)(func() error {
 			if serv.onListen != nil {
@@ -164,7 +220,13 @@ func (h *HTTPModule) createServer(call sobek.FunctionCall, runtime *sobek.Runtim
 			}
 			return nil
 		})
-		err := serv.server.Serve(ln)
+		var err error
+		if serv.secure {
+			// The certificate is already in TLSConfig
+			err = serv.server.ServeTLS(ln, "", "")
+		} else {
+			err = serv.server.Serve(ln)
+		}
 		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			vm.EnqueueJob(runtime)(func() error { return err })
 		}
@@ -181,18 +243,46 @@ func (h *HTTPModule) createServer(call sobek.FunctionCall, runtime *sobek.Runtim
 	// Add methods
 	serverObj.Set("close", func(call sobek.FunctionCall) sobek.Value {
 		if err := serv.close(); err != nil {
-			panic(runtime.NewGoError(err))
+			panic(vm.NewError(runtime, "http", vm.CodeOperationFailed, err))
 		}
 		return sobek.Undefined()
 	})
 
+	// stats() - returns request and error counts and the average latency
+	serverObj.Set("stats", func(call sobek.FunctionCall) sobek.Value {
+		return serv.metrics.stats(runtime)
+	})
+
 	serverObj.Set("shutdown", func(call sobek.FunctionCall) sobek.Value {
 		if err := serv.shutdown(); err != nil {
-			panic(runtime.NewGoError(err))
+			panic(vm.NewError(runtime, "http", vm.CodeOperationFailed, err))
 		}
 		return sobek.Undefined()
 	})
 
+	// addr() - returns the hostname and port the server is bound to
+	serverObj.Set("addr", func(call sobek.FunctionCall) sobek.Value {
+		return serv.addr()
+	})
+
+	// on(event, listener) - calls listener with each request for "request",
+	// and once the server stops for "close"
+	serverObj.Set("on", func(call sobek.FunctionCall) sobek.Value {
+		event := call.Argument(0).String()
+		if event != "request" && event != "close" {
+			panic(vm.NewTypeError(runtime, "http", vm.CodeInvalidArgument, fmt.Sprintf("unknown server event %q, expected \"request\" or \"close\"", event)))
+		}
+		listener, ok := sobek.AssertFunction(call.Argument(1))
+		if !ok {
+			panic(vm.NewTypeError(runtime, "http", vm.CodeInvalidArgument, "server.on: listener must be a function"))
+		}
+		if serv.listeners == nil {
+			serv.listeners = make(map[string][]sobek.Callable)
+		}
+		serv.listeners[event] = append(serv.listeners[event], listener)
+		return serverObj
+	})
+
 	return serverObj
 }
 
@@ -203,6 +293,28 @@ type httpServer struct {
 	port     int
 
 	handler, onError, onListen sobek.Callable
+	// onRequest runs before handler and answers the request itself unless it returns undefined
+	onRequest sobek.Callable
+
+	// compress gzips or deflates responses for clients that accept it
+	compress bool
+	// accessLog logs the method, path, status and duration of each request
+	accessLog bool
+	// cors answers preflight requests and adds Access-Control-* headers, nil disables it
+	cors *corsConfig
+	// rateLimit answers clients over their per-address budget with 429, nil disables it
+	rateLimit *rateLimiter
+	// secure serves over TLS with the certificate in server.TLSConfig
+	secure bool
+	// streamBody exposes request bodies as streams instead of reading them up front
+	streamBody bool
+	// handlerTimeout answers requests whose handler takes longer with 504, 0 waits forever
+	handlerTimeout time.Duration
+
+	metrics metrics
+
+	// listeners registered with server.on, by event; only used on the event loop
+	listeners map[string][]sobek.Callable
 
 	ctx    context.Context
 	closed atomic.Bool
@@ -210,11 +322,88 @@ type httpServer struct {
 	ref func(func() error)
 }
 
+// setOptions applies the serve options shared by every call form
+func (s *httpServer) setOptions(opts *sobek.Object) {
+	if v := opts.Get("maxHeaderSize"); v != nil {
+		s.server.MaxHeaderBytes = int(s.positiveOption(v, "maxHeaderSize"))
+	}
+	// Timeouts are given in milliseconds
+	if v := opts.Get("keepAliveTimeout"); v != nil {
+		s.server.IdleTimeout = time.Duration(s.positiveOption(v, "keepAliveTimeout")) * time.Millisecond
+	}
+	if v := opts.Get("requestTimeout"); v != nil {
+		s.server.ReadTimeout = time.Duration(s.positiveOption(v, "requestTimeout")) * time.Millisecond
+	}
+	if v := opts.Get("responseTimeout"); v != nil {
+		s.server.WriteTimeout = time.Duration(s.positiveOption(v, "responseTimeout")) * time.Millisecond
+	}
+	if v := opts.Get("handlerTimeout"); v != nil {
+		s.handlerTimeout = time.Duration(s.positiveOption(v, "handlerTimeout")) * time.Millisecond
+	}
+	if v := opts.Get("streamBody"); v != nil {
+		s.streamBody = v.ToBoolean()
+	}
+	if v := opts.Get("compress"); v != nil {
+		s.compress = v.ToBoolean()
+	}
+	if v := opts.Get("accessLog"); v != nil {
+		s.accessLog = v.ToBoolean()
+	}
+	if v := opts.Get("cors"); v != nil {
+		s.cors = parseCORS(s.rt, v)
+	}
+	if v := opts.Get("rateLimit"); v != nil {
+		s.rateLimit = parseRateLimit(s.rt, v)
+	}
+	if v := opts.Get("onError"); v != nil {
+		var ok bool
+		s.onError, ok = sobek.AssertFunction(v)
+		if !ok {
+			panic(vm.NewTypeError(s.rt, "http", vm.CodeInvalidArgument, "onError must be a function"))
+		}
+	}
+	if v := opts.Get("onListen"); v != nil {
+		var ok bool
+		s.onListen, ok = sobek.AssertFunction(v)
+		if !ok {
+			panic(vm.NewTypeError(s.rt, "http", vm.CodeInvalidArgument, "onListen must be a function"))
+		}
+	}
+	if v := opts.Get("onRequest"); v != nil {
+		var ok bool
+		s.onRequest, ok = sobek.AssertFunction(v)
+		if !ok {
+			panic(vm.NewTypeError(s.rt, "http", vm.CodeInvalidArgument, "onRequest must be a function"))
+		}
+	}
+	if v := opts.Get("tls"); v != nil {
+		s.server.TLSConfig = parseTLS(s.rt, v, s.hostname)
+		s.secure = s.server.TLSConfig != nil
+	}
+}
+
+// positiveOption returns the integer value of option name, throwing a
+// TypeError unless it is a finite number greater than zero
+func (s *httpServer) positiveOption(v sobek.Value, name string) int64 {
+	if !sobek.IsNumber(v) {
+		panic(vm.NewTypeError(s.rt, "http", vm.CodeInvalidArgument, name+" must be a positive number"))
+	}
+	f := v.ToFloat()
+	if math.IsNaN(f) || math.IsInf(f, 0) || f < 1 {
+		panic(vm.NewTypeError(s.rt, "http", vm.CodeInvalidArgument, name+" must be a positive number"))
+	}
+	return int64(f)
+}
+
 func (s *httpServer) url() string {
-	if s.port == 80 {
-		return "http://" + s.hostname
+	scheme, defaultPort := "http", 80
+	if s.secure {
+		scheme, defaultPort = "https", 443
+	}
+	if s.port == defaultPort {
+		return scheme + "://" + s.hostname
 	}
-	return fmt.Sprintf("http://%s:%d", s.hostname, s.port)
+	return fmt.Sprintf("%s://%s:%d", scheme, s.hostname, s.port)
 }
 
 func (s *httpServer) addr() sobek.Value {
@@ -227,12 +416,40 @@ func (s *httpServer) addr() sobek.Value {
 func (s *httpServer) listen() net.Listener {
 	ln, err := net.Listen("tcp", s.server.Addr)
 	if err != nil {
-		panic(s.rt.NewGoError(err))
+		panic(vm.NewError(s.rt, "http", vm.CodeOperationFailed, err))
+	}
+	if s.port == 0 {
+		// Report the port the OS picked
+		s.port = ln.Addr().(*net.TCPAddr).Port
 	}
 	return ln
 }
 
+// emit calls the listeners of event with args. Listener errors are logged so
+// they never affect the response or the server.
+func (s *httpServer) emit(event string, args ...sobek.Value) {
+	for _, listener := range s.listeners[event] {
+		if _, err := listener(sobek.Undefined(), args...); err != nil {
+			logger.Error("Server event listener failed", "event", event, "error", err)
+		}
+	}
+}
+
+// emitClose queues the close listeners on the event loop, ahead of releasing
+// the server's hold on it. Nothing runs once the loop has stopped.
+func (s *httpServer) emitClose() {
+	if len(s.listeners["close"]) > 0 {
+		vm.Post(s.rt, func() error {
+			s.emit("close")
+			return nil
+		})
+	}
+}
+
 func (s *httpServer) close() error {
+	if !s.closed.Load() {
+		s.emitClose()
+	}
 	s.closed.Store(true)
 	err := s.server.Close()
 	if s.ref != nil {
@@ -242,6 +459,9 @@ func (s *httpServer) close() error {
 }
 
 func (s *httpServer) shutdown() error {
+	if !s.closed.Load() {
+		s.emitClose()
+	}
 	s.closed.Store(true)
 	err := s.server.Shutdown(s.ctx)
 	if s.ref != nil {
@@ -255,46 +475,199 @@ func (s *httpServer) shutdown() error {
 
 // ServeHTTP implements http.Handler
 func (s *httpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	s.metrics.requests.Add(1)
+	defer func() { s.metrics.observe(time.Since(start)) }()
+
+	if s.accessLog {
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			logger.Info("http request", "method", r.Method, "path", r.URL.Path, "status", recorder.status, "duration", time.Since(start))
+		}()
+		w = recorder
+	}
+
+	if s.cors != nil && s.cors.apply(w, r) {
+		return
+	}
+	if s.rateLimit != nil && !s.rateLimit.allow(r) {
+		s.rateLimit.reject(w)
+		return
+	}
+
+	if s.handlerTimeout > 0 {
+		s.serveWithTimeout(w, r)
+		return
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(1)
 	vm.EnqueueJob(s.rt)(func() error {
-		result, err := s.handler(sobek.Undefined(), newRequest(s.rt, r))
-		if err != nil {
-			s.writeError(w, r, wg.Done, err)
-			return nil
+		s.handle(w, r, wg.Done)
+		return nil
+	})
+	wg.Wait()
+}
+
+// handle runs onRequest or the handler for r, calling done once the response
+// is written
+func (s *httpServer) handle(w http.ResponseWriter, r *http.Request, done func()) {
+	req := newRequest(s.rt, r, s.streamBody)
+	s.emit("request", req)
+	if s.onRequest != nil {
+		s.runHook(w, r, done, req)
+	} else {
+		s.runHandler(w, r, done, req)
+	}
+}
+
+// serveWithTimeout answers r with 504 Gateway Timeout if the handler hasn't
+// responded within handlerTimeout, interrupting it if it is still running
+func (s *httpServer) serveWithTimeout(w http.ResponseWriter, r *http.Request) {
+	dw := newDeadlineWriter()
+	finished := make(chan struct{})
+	var once sync.Once
+	done := func() {
+		once.Do(func() {
+			if dw.finish() {
+				close(finished)
+			}
+		})
+	}
+
+	vm.EnqueueJob(s.rt)(func() error {
+		dw.enter()
+		defer dw.leave(s.rt)
+		s.handle(dw, r, done)
+		return nil
+	})
+
+	timer := time.NewTimer(s.handlerTimeout)
+	defer timer.Stop()
+	select {
+	case <-finished:
+		dw.flush(w)
+	case <-timer.C:
+		if !dw.expire(s.rt) {
+			// The response completed as the timer fired
+			<-finished
+			dw.flush(w)
+			return
 		}
+		s.metrics.errors.Add(1)
+		logger.Error("Handler timed out", "method", r.Method, "url", r.URL.String(), "timeout", s.handlerTimeout)
+		http.Error(w, http.StatusText(http.StatusGatewayTimeout), http.StatusGatewayTimeout)
+	}
+}
 
-		// Handle promise result
-		if isPromise(result) {
-			s.handlePromise(w, r, wg.Done, result)
-			return nil
+// runHandler calls the main handler and writes the response it produces
+func (s *httpServer) runHandler(w http.ResponseWriter, r *http.Request, done func(), req sobek.Value) {
+	result, err := s.handler(sobek.Undefined(), req)
+	if err != nil {
+		s.writeError(w, r, done, err)
+		return
+	}
+
+	// Handle promise result
+	if isPromise(result) {
+		s.handlePromise(w, r, done, result)
+		return
+	}
+
+	if res, ok := toResponse(result); ok {
+		s.writeResponse(w, r, done, res)
+	} else {
+		s.writeError(w, r, done, errNotResponse)
+	}
+}
+
+// runHook calls onRequest, answering with its response if it returns one and
+// running the main handler if it returns undefined
+func (s *httpServer) runHook(w http.ResponseWriter, r *http.Request, done func(), req sobek.Value) {
+	result, err := s.onRequest(sobek.Undefined(), req)
+	if err != nil {
+		s.writeError(w, r, done, err)
+		return
+	}
+
+	settle := func(value sobek.Value) {
+		if sobek.IsUndefined(value) || sobek.IsNull(value) {
+			s.runHandler(w, r, done, req)
+		} else if res, ok := toResponse(value); ok {
+			s.writeResponse(w, r, done, res)
+		} else {
+			s.writeError(w, r, done, errNotResponse)
 		}
+	}
+	if !isPromise(result) {
+		settle(result)
+		return
+	}
 
-		if res, ok := toResponse(result); ok {
-			s.writeResponse(w, r, wg.Done, res)
+	object := result.(*sobek.Object)
+	then, _ := sobek.AssertFunction(object.Get("then"))
+	resolve := s.rt.ToValue(func(call sobek.FunctionCall) sobek.Value {
+		settle(call.Argument(0))
+		return sobek.Undefined()
+	})
+	reject := s.rt.ToValue(func(call sobek.FunctionCall) sobek.Value {
+		v := call.Argument(0)
+		if ex, ok := v.Export().(error); ok {
+			s.writeError(w, r, done, ex)
 		} else {
-			s.writeError(w, r, wg.Done, errNotResponse)
+			s.writeError(w, r, done, errors.New(v.String()))
 		}
-		return nil
+		return sobek.Undefined()
 	})
-	wg.Wait()
+	if _, err := then(object, resolve, reject); err != nil {
+		s.writeError(w, r, done, err)
+	}
 }
 
 func (s *httpServer) writeResponse(w http.ResponseWriter, r *http.Request, done func(), res *http.Response) {
 	defer done()
 
+	// WriteHeader panics on a status outside [100, 999], and one outside
+	// [100, 599] means nothing to clients either
+	if res.StatusCode < 100 || res.StatusCode > 599 {
+		logger.Error("Handler returned an invalid status", "status", res.StatusCode, "method", r.Method, "url", r.URL.String())
+		s.metrics.errors.Add(1)
+		http.Error(w, fmt.Sprintf("invalid response status %d", res.StatusCode), http.StatusInternalServerError)
+		return
+	}
+
 	header := w.Header()
 	for k, v := range res.Header {
 		header[http.CanonicalHeaderKey(k)] = v
 	}
+
+	var out io.Writer = w
+	var body io.Reader = res.Body
+	if s.compress {
+		data, err := io.ReadAll(res.Body)
+		if err != nil {
+			logger.Error("Failed to read response", "error", err, "method", r.Method, "url", r.URL.String())
+		}
+		body = bytes.NewReader(data)
+		header.Add("Vary", "Accept-Encoding")
+		if encoding := negotiateEncoding(r, res, len(data)); encoding != "" {
+			header.Set("Content-Encoding", encoding)
+			header.Del("Content-Length")
+			compressor := newCompressor(w, encoding)
+			defer compressor.Close()
+			out = compressor
+		}
+	}
 	w.WriteHeader(res.StatusCode)
 
-	if _, err := io.Copy(w, res.Body); err != nil {
+	if _, err := io.Copy(out, body); err != nil {
 		logger.Error("Failed to write response", "error", err, "method", r.Method, "url", r.URL.String())
 	}
 }
 
 func (s *httpServer) writeError(w http.ResponseWriter, r *http.Request, done func(), rawErr error) {
+	s.metrics.errors.Add(1)
+
 	var (
 		jsErr  *sobek.Object
 		result sobek.Value
@@ -354,6 +727,18 @@ EX:
 	done()
 }
 
+// statusRecorder remembers the status code written through it for the access log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records code before passing it on
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
 // handlePromise handles promise result
 func (s *httpServer) handlePromise(w http.ResponseWriter, r *http.Request, done func(), result sobek.Value) {
 	var err error
@@ -421,7 +806,7 @@ func isFunc(v sobek.Value) bool {
 }
 
 func isPromise(value sobek.Value) bool {
-	if obj := value.ToObject(nil); obj != nil {
+	if obj, ok := value.(*sobek.Object); ok {
 		if thenMethod := obj.Get("then"); thenMethod != nil && !sobek.IsUndefined(thenMethod) {
 			_, ok := sobek.AssertFunction(thenMethod)
 			return ok
@@ -431,52 +816,83 @@ func isPromise(value sobek.Value) bool {
 }
 
 // newRequest creates a JavaScript request object from http.Request
-func newRequest(runtime *sobek.Runtime, r *http.Request) sobek.Value {
+func newRequest(runtime *sobek.Runtime, r *http.Request, streamBody bool) sobek.Value {
 	reqObj := runtime.NewObject()
 	reqObj.Set("method", r.Method)
 	reqObj.Set("url", r.URL.Path)
 	reqObj.Set("path", r.URL.Path)
 
 	// Headers
-	headersObj := runtime.NewObject()
-	for key, values := range r.Header {
-		if len(values) > 0 {
-			headersObj.Set(key, values[0])
-		}
-	}
+	headersObj := headers.New(runtime, r.Header)
 	reqObj.Set("headers", headersObj)
 
-	// Read request body
+	// Read request body, or with streamBody expose it as a stream and only
+	// read what the script hasn't consumed when text(), json() or formData()
+	// ask for it
 	bodyStr := ""
-	if r.Body != nil {
-		bodyBytes, err := io.ReadAll(r.Body)
-		if err == nil {
-			bodyStr = string(bodyBytes)
+	body := func() string { return bodyStr }
+	if streamBody && r.Body != nil {
+		stream := &bodyStream{body: r.Body}
+		reqObj.Set("body", newBodyStream(runtime, stream))
+		var bodyErr error
+		var once sync.Once
+		body = func() string {
+			once.Do(func() {
+				data, err := stream.rest()
+				bodyStr, bodyErr = string(data), err
+			})
+			if bodyErr != nil {
+				panic(vm.NewError(runtime, "http", vm.CodeOperationFailed, bodyErr))
+			}
+			return bodyStr
+		}
+	} else {
+		if r.Body != nil {
+			bodyBytes, err := io.ReadAll(r.Body)
+			if err == nil {
+				bodyStr = string(bodyBytes)
+			}
+			// Close the original body and replace with a new reader for downstream use
+			r.Body.Close()
+			r.Body = io.NopCloser(strings.NewReader(bodyStr))
 		}
-		// Close the original body and replace with a new reader for downstream use
-		r.Body.Close()
-		r.Body = io.NopCloser(strings.NewReader(bodyStr))
+		reqObj.Set("body", bodyStr)
 	}
 	
-	reqObj.Set("body", bodyStr)
-	
 	// Add text() method for compatibility
 	reqObj.Set("text", func(call sobek.FunctionCall) sobek.Value {
-		return runtime.ToValue(bodyStr)
+		return runtime.ToValue(body())
 	})
 	
 	// Add json() method for convenience
 	reqObj.Set("json", func(call sobek.FunctionCall) sobek.Value {
+		bodyStr := body()
 		if bodyStr == "" {
 			return sobek.Null()
 		}
 		jsonVal, err := runtime.RunString("JSON.parse(" + runtime.ToValue(bodyStr).String() + ")")
 		if err != nil {
-			panic(runtime.NewGoError(err))
+			panic(vm.NewError(runtime, "http", vm.CodeOperationFailed, err))
 		}
 		return jsonVal
 	})
 
+	// Add formData() method, resolving to the parsed urlencoded or multipart fields
+	contentType := r.Header.Get("Content-Type")
+	reqObj.Set("formData", func(call sobek.FunctionCall) sobek.Value {
+		promise, resolve, reject := runtime.NewPromise()
+		fields, err := form.Parse(runtime, contentType, body())
+		switch {
+		case errors.Is(err, form.ErrUnsupported):
+			_ = reject(vm.NewTypeError(runtime, "http", vm.CodeNotSupported, err.Error()))
+		case err != nil:
+			_ = reject(vm.NewError(runtime, "http", vm.CodeInvalidArgument, err))
+		default:
+			_ = resolve(form.New(runtime, "http", fields))
+		}
+		return runtime.ToValue(promise)
+	})
+
 	return reqObj
 }
 
@@ -488,18 +904,13 @@ func newResponse(runtime *sobek.Runtime, resp *http.Response) sobek.Value {
 	responseObj.Set("ok", resp.StatusCode >= 200 && resp.StatusCode < 300)
 
 	// Headers object
-	headersObj := runtime.NewObject()
-	for key, values := range resp.Header {
-		if len(values) > 0 {
-			headersObj.Set(key, values[0])
-		}
-	}
+	headersObj := headers.New(runtime, resp.Header)
 	responseObj.Set("headers", headersObj)
 
 	// Read response body
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		panic(runtime.NewGoError(err))
+		panic(vm.NewError(runtime, "http", vm.CodeOperationFailed, err))
 	}
 	resp.Body.Close()
 
@@ -516,7 +927,16 @@ func newResponse(runtime *sobek.Runtime, resp *http.Response) sobek.Value {
 
 // toResponse converts a sobek.Value to *http.Response
 func toResponse(value sobek.Value) (*http.Response, bool) {
-	if obj := value.ToObject(nil); obj != nil {
+	// A bare string or number is the body of a plain text 200 response
+	if _, ok := value.Export().(string); ok || sobek.IsNumber(value) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+			Body:       io.NopCloser(strings.NewReader(value.String())),
+		}, true
+	}
+
+	if obj, ok := value.(*sobek.Object); ok {
 		// Check if it's our internal response object
 		if httpResp := obj.Get("__httpResponse"); httpResp != nil && !sobek.IsUndefined(httpResp) {
 			if resp, ok := httpResp.Export().(*http.Response); ok {
@@ -524,24 +944,47 @@ func toResponse(value sobek.Value) (*http.Response, bool) {
 			}
 		}
 
+		// Plain objects and arrays that don't describe a response are its JSON body
+		if isJSONValue(obj) && !isResponseLike(obj) {
+			header := make(http.Header)
+			body, ok := jsonBody(obj, header)
+			if !ok {
+				return nil, false
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     header,
+				Body:       io.NopCloser(strings.NewReader(body)),
+			}, true
+		}
+
 		// Handle standard Response objects
 		status := 200
 		if statusVal := obj.Get("status"); statusVal != nil && !sobek.IsUndefined(statusVal) {
 			status = int(statusVal.ToInteger())
 		}
 
-		headers := make(http.Header)
-		if headersVal := obj.Get("headers"); headersVal != nil && !sobek.IsUndefined(headersVal) {
-			headersObj := headersVal.ToObject(nil)
+		// A Headers object keeps every value of repeated headers, such as
+		// several Set-Cookie lines; a plain object has one value per name
+		header := make(http.Header)
+		if h, ok := headers.Unwrap(obj.Get("headers")); ok {
+			for key, values := range h {
+				header[key] = append([]string(nil), values...)
+			}
+		} else if headersObj, ok := obj.Get("headers").(*sobek.Object); ok {
 			for _, key := range headersObj.Keys() {
 				value := headersObj.Get(key).String()
-				headers.Set(key, value)
+				header.Set(key, value)
 			}
 		}
 
 		// Get body content
 		body := ""
-		if bodyVal := obj.Get("body"); bodyVal != nil && !sobek.IsUndefined(bodyVal) {
+		if bodyVal := obj.Get("body"); bodyVal != nil && isJSONValue(bodyVal) {
+			if body, ok = jsonBody(bodyVal.(*sobek.Object), header); !ok {
+				return nil, false
+			}
+		} else if bodyVal != nil && !sobek.IsUndefined(bodyVal) {
 			body = bodyVal.String()
 		} else if textMethod := obj.Get("text"); textMethod != nil && !sobek.IsUndefined(textMethod) {
 			if textFunc, ok := sobek.AssertFunction(textMethod); ok {
@@ -554,13 +997,53 @@ func toResponse(value sobek.Value) (*http.Response, bool) {
 
 		return &http.Response{
 			StatusCode: status,
-			Header:     headers,
+			Header:     header,
 			Body:       io.NopCloser(strings.NewReader(body)),
 		}, true
 	}
 	return nil, false
 }
 
+// isJSONValue reports whether v is a plain object or array to send as JSON
+func isJSONValue(v sobek.Value) bool {
+	obj, ok := v.(*sobek.Object)
+	if !ok {
+		return false
+	}
+	switch obj.ClassName() {
+	case "Object", "Array":
+		return true
+	}
+	return false
+}
+
+// isResponseLike reports whether obj describes a response rather than being
+// the body of one. Only a numeric status in the valid range makes it one, as
+// every Response has, so data that merely has body or headers keys is sent
+// as JSON.
+func isResponseLike(obj *sobek.Object) bool {
+	v := obj.Get("status")
+	if v == nil || !sobek.IsNumber(v) {
+		return false
+	}
+	status := v.ToFloat()
+	return status >= 100 && status <= 599 && status == float64(int(status))
+}
+
+// jsonBody serializes obj like JSON.stringify and defaults the content type
+// to application/json
+func jsonBody(obj *sobek.Object, header http.Header) (string, bool) {
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		logger.Error("Failed to serialize response body as JSON", "error", err)
+		return "", false
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "application/json")
+	}
+	return string(data), true
+}
+
 var (
 	internalServerError = []byte(http.StatusText(http.StatusInternalServerError))
 	errNotResponse      = errors.New("return value from handler must be a response or a promise resolving to a response")
@@ -0,0 +1,36 @@
+package http
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/grafana/sobek"
+)
+
+// metrics counts the requests a server has handled
+type metrics struct {
+	requests  atomic.Int64 // Requests received
+	errors    atomic.Int64 // Requests answered through the error path
+	completed atomic.Int64 // Requests fully answered
+	latency   atomic.Int64 // Total time spent answering completed requests, in nanoseconds
+}
+
+// observe records a request that took duration to answer
+func (m *metrics) observe(duration time.Duration) {
+	m.completed.Add(1)
+	m.latency.Add(int64(duration))
+}
+
+// stats returns the counters as an object with requests, errors and the
+// average latency in milliseconds
+func (m *metrics) stats(runtime *sobek.Runtime) sobek.Value {
+	average := 0.0
+	if completed := m.completed.Load(); completed > 0 {
+		average = float64(m.latency.Load()) / float64(completed) / float64(time.Millisecond)
+	}
+	return runtime.ToValue(map[string]any{
+		"requests":         m.requests.Load(),
+		"errors":           m.errors.Load(),
+		"averageLatencyMs": average,
+	})
+}
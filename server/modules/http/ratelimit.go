@@ -0,0 +1,100 @@
+package http
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitIdle is how long a client's limiter is kept after its last request
+const rateLimitIdle = time.Minute
+
+// rateLimiter is a token bucket per remote address
+type rateLimiter struct {
+	mu        sync.Mutex
+	limit     rate.Limit
+	burst     int
+	clients   map[string]*rateClient
+	lastSweep time.Time
+}
+
+// rateClient is the limiter of one remote address
+type rateClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// parseRateLimit reads the rateLimit option, an object with perSecond and an
+// optional burst that defaults to perSecond rounded up
+func parseRateLimit(runtime *sobek.Runtime, v sobek.Value) *rateLimiter {
+	if sobek.IsUndefined(v) || sobek.IsNull(v) {
+		return nil
+	}
+	opts := v.ToObject(runtime)
+
+	perSecond := 0.0
+	if v := opts.Get("perSecond"); v != nil && !sobek.IsUndefined(v) {
+		perSecond = v.ToFloat()
+	}
+	if !(perSecond > 0) || math.IsInf(perSecond, 0) {
+		panic(vm.NewTypeError(runtime, "http", vm.CodeInvalidArgument, "rateLimit.perSecond must be a positive number"))
+	}
+
+	burst := int(math.Ceil(perSecond))
+	if v := opts.Get("burst"); v != nil && !sobek.IsUndefined(v) {
+		burst = int(v.ToInteger())
+		if burst <= 0 {
+			panic(vm.NewTypeError(runtime, "http", vm.CodeInvalidArgument, "rateLimit.burst must be a positive number"))
+		}
+	}
+
+	return &rateLimiter{
+		limit:   rate.Limit(perSecond),
+		burst:   burst,
+		clients: make(map[string]*rateClient),
+	}
+}
+
+// allow reports whether a request from r's remote address may proceed
+func (l *rateLimiter) allow(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Drop limiters of clients that went quiet, at most once per idle period
+	if now.Sub(l.lastSweep) > rateLimitIdle {
+		for key, client := range l.clients {
+			if now.Sub(client.lastSeen) > rateLimitIdle {
+				delete(l.clients, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	client, ok := l.clients[host]
+	if !ok {
+		client = &rateClient{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[host] = client
+	}
+	client.lastSeen = now
+	return client.limiter.AllowN(now, 1)
+}
+
+// reject answers a request over the limit with 429 Too Many Requests
+func (l *rateLimiter) reject(w http.ResponseWriter) {
+	retry := time.Duration(float64(time.Second) / float64(l.limit))
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+	http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/modules/internal/streams"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// bodyChunkSize is the most bytes one read of a body stream returns
+const bodyChunkSize = 64 << 10
+
+// bodyStream reads a request body in chunks for a ReadableStream-like object
+type bodyStream struct {
+	mu     sync.Mutex // serializes reads of body
+	body   io.Reader
+	err    error // error ending the body, returned by every later read
+	locked bool  // a reader holds the stream
+}
+
+// next reads the next chunk, blocking until data arrives. It returns io.EOF
+// once the body is exhausted.
+func (b *bodyStream) next() ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return nil, b.err
+	}
+	buf := make([]byte, bodyChunkSize)
+	for {
+		n, err := b.body.Read(buf)
+		if err != nil {
+			b.err = err
+		}
+		if n > 0 {
+			return buf[:n], nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// rest reads what remains of the body
+func (b *bodyStream) rest() ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil && b.err != io.EOF {
+		return nil, b.err
+	}
+	data, err := io.ReadAll(b.body)
+	if err != nil {
+		b.err = err
+		return nil, err
+	}
+	b.err = io.EOF
+	return data, nil
+}
+
+// newBodyStream creates a ReadableStream-like object over b: getReader()
+// returns a reader whose read() resolves to { value, done } with each chunk
+// as a Uint8Array, reading from the connection off the event loop as the
+// script asks for data. pipeThrough() pipes it into e.g. a TextDecoderStream.
+func newBodyStream(runtime *sobek.Runtime, b *bodyStream) *sobek.Object {
+	stream := runtime.NewObject()
+
+	read := func(call sobek.FunctionCall) sobek.Value {
+		promise, resolve, reject := runtime.NewPromise()
+		enqueue := vm.EnqueueJob(runtime)
+		go func() {
+			chunk, err := b.next()
+			enqueue(func() error {
+				switch {
+				case errors.Is(err, io.EOF):
+					return resolve(map[string]any{"value": sobek.Undefined(), "done": true})
+				case err != nil:
+					return reject(vm.NewError(runtime, "http", vm.CodeOperationFailed, err))
+				}
+				value, err := runtime.New(runtime.Get("Uint8Array"), runtime.ToValue(runtime.NewArrayBuffer(chunk)))
+				if err != nil {
+					return reject(err)
+				}
+				return resolve(map[string]any{"value": value, "done": false})
+			})
+		}()
+		return runtime.ToValue(promise)
+	}
+
+	// getReader() - locks the stream to a reader with read(), cancel() and
+	// releaseLock()
+	stream.Set("getReader", func(call sobek.FunctionCall) sobek.Value {
+		if b.locked {
+			panic(vm.NewTypeError(runtime, "http", vm.CodeInvalidArgument, "body stream is already locked to a reader"))
+		}
+		b.locked = true
+		stream.Set("locked", true)
+
+		reader := runtime.NewObject()
+		reader.Set("read", read)
+		reader.Set("cancel", func(call sobek.FunctionCall) sobek.Value {
+			b.mu.Lock()
+			b.err = io.EOF
+			b.mu.Unlock()
+			return resolved(runtime, sobek.Undefined())
+		})
+		reader.Set("releaseLock", func(call sobek.FunctionCall) sobek.Value {
+			b.locked = false
+			stream.Set("locked", false)
+			return sobek.Undefined()
+		})
+		return reader
+	})
+	stream.Set("locked", false)
+	streams.SetPipeThrough(runtime, "http", stream)
+
+	return stream
+}
+
+// resolved returns a promise already fulfilled with value
+func resolved(runtime *sobek.Runtime, value sobek.Value) sobek.Value {
+	promise, resolve, _ := runtime.NewPromise()
+	_ = resolve(value)
+	return runtime.ToValue(promise)
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/grafana/sobek"
+)
+
+// errHandlerTimeout interrupts a handler still running when its request
+// times out
+var errHandlerTimeout = errors.New("handler timed out")
+
+// deadlineWriter buffers the response of a request whose handler runs under
+// handlerTimeout, so nothing reaches the client once the timeout response has
+// been written. It also tracks whether the handler is running on the event
+// loop, where a timeout has to interrupt it.
+type deadlineWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	status      int
+	body        bytes.Buffer
+	running     bool // the handler is running synchronously on the event loop
+	finished    bool // the response is complete
+	timedOut    bool // the timeout response was written instead
+	interrupted bool // the runtime was interrupted to stop the handler
+}
+
+func newDeadlineWriter() *deadlineWriter {
+	return &deadlineWriter{header: make(http.Header), status: http.StatusOK}
+}
+
+// Header implements http.ResponseWriter
+func (d *deadlineWriter) Header() http.Header {
+	return d.header
+}
+
+// WriteHeader implements http.ResponseWriter
+func (d *deadlineWriter) WriteHeader(status int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.status = status
+}
+
+// Write implements http.ResponseWriter, failing once the request timed out
+func (d *deadlineWriter) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	return d.body.Write(p)
+}
+
+// enter marks the handler as running on the event loop
+func (d *deadlineWriter) enter() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.running = true
+}
+
+// leave marks the handler as no longer running, clearing an interrupt that
+// fired too late to stop it so that it can't hit unrelated code
+func (d *deadlineWriter) leave(rt *sobek.Runtime) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.running = false
+	if d.interrupted {
+		rt.ClearInterrupt()
+	}
+}
+
+// finish marks the response as complete, reporting false if the request
+// already timed out
+func (d *deadlineWriter) finish() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.timedOut {
+		return false
+	}
+	d.finished = true
+	return true
+}
+
+// expire times the request out unless the response is already complete,
+// interrupting the handler if it is still running
+func (d *deadlineWriter) expire(rt *sobek.Runtime) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.finished {
+		return false
+	}
+	d.timedOut = true
+	if d.running {
+		d.interrupted = true
+		rt.Interrupt(errHandlerTimeout)
+	}
+	return true
+}
+
+// flush copies the buffered response to w
+func (d *deadlineWriter) flush(w http.ResponseWriter) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key, values := range d.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(d.status)
+	_, _ = w.Write(d.body.Bytes())
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// selfSignedValidity is how long generated certificates stay valid
+const selfSignedValidity = 24 * time.Hour
+
+// parseTLS reads the tls option, either { cert, key } holding PEM data or
+// file paths, or { selfSigned: true } for a generated certificate
+func parseTLS(runtime *sobek.Runtime, v sobek.Value, hostname string) *tls.Config {
+	if sobek.IsUndefined(v) || sobek.IsNull(v) {
+		return nil
+	}
+	opts := v.ToObject(runtime)
+
+	var cert tls.Certificate
+	var err error
+	if v := opts.Get("selfSigned"); v != nil && v.ToBoolean() {
+		cert, err = selfSignedCertificate(hostname)
+	} else {
+		certPEM := pemOption(runtime, opts.Get("cert"), "tls.cert")
+		keyPEM := pemOption(runtime, opts.Get("key"), "tls.key")
+		cert, err = tls.X509KeyPair(certPEM, keyPEM)
+	}
+	if err != nil {
+		panic(vm.NewError(runtime, "http", vm.CodeInvalidArgument, err))
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// pemOption returns PEM data given inline or as the path of a PEM file
+func pemOption(runtime *sobek.Runtime, v sobek.Value, name string) []byte {
+	if v == nil || sobek.IsUndefined(v) || sobek.IsNull(v) {
+		panic(vm.NewTypeError(runtime, "http", vm.CodeInvalidArgument, name+" is required unless selfSigned is set"))
+	}
+	value := v.String()
+	if strings.Contains(value, "-----BEGIN ") {
+		return []byte(value)
+	}
+	data, err := os.ReadFile(value)
+	if err != nil {
+		panic(vm.NewError(runtime, "http", vm.CodeInvalidArgument, err))
+	}
+	return data
+}
+
+// selfSignedCertificate generates an in-memory certificate for hostname and
+// the loopback addresses
+func selfSignedCertificate(hostname string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"codebench-mcp"}, CommonName: hostname},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	if ip := net.ParseIP(hostname); ip != nil {
+		template.IPAddresses = append(template.IPAddresses, ip)
+	} else if hostname != "" && hostname != "localhost" {
+		template.DNSNames = append(template.DNSNames, hostname)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	)
+}
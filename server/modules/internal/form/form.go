@@ -0,0 +1,193 @@
+// Package form parses urlencoded and multipart bodies into the FormData-like
+// objects shared by the fetch and http modules.
+package form
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// Field is a single parsed form field, either a string or a file
+type Field struct {
+	name  string
+	value sobek.Value
+}
+
+// Parse parses a urlencoded or multipart body into fields in the order they
+// were sent. A content type that is neither gives ErrUnsupported.
+func Parse(runtime *sobek.Runtime, contentType, body string) ([]Field, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		var fields []Field
+		for _, pair := range strings.Split(body, "&") {
+			if pair == "" {
+				continue
+			}
+			key, value, _ := strings.Cut(pair, "=")
+			if key, err = url.QueryUnescape(key); err != nil {
+				return nil, err
+			}
+			if value, err = url.QueryUnescape(value); err != nil {
+				return nil, err
+			}
+			fields = append(fields, Field{name: key, value: runtime.ToValue(value)})
+		}
+		return fields, nil
+
+	case "multipart/form-data":
+		boundary := params["boundary"]
+		if boundary == "" {
+			return nil, errors.New("multipart/form-data: missing boundary")
+		}
+		var fields []Field
+		reader := multipart.NewReader(strings.NewReader(body), boundary)
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return fields, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			data, err := io.ReadAll(part)
+			if err != nil {
+				return nil, err
+			}
+			name := part.FormName()
+			if name == "" {
+				continue
+			}
+			if filename := part.FileName(); filename != "" {
+				fields = append(fields, Field{name: name, value: newFile(runtime, data, filename, part.Header.Get("Content-Type"))})
+			} else {
+				fields = append(fields, Field{name: name, value: runtime.ToValue(string(data))})
+			}
+		}
+
+	default:
+		return nil, ErrUnsupported
+	}
+}
+
+// newFile creates a File for an uploaded file, or a File-like object when the
+// File global is not available
+func newFile(runtime *sobek.Runtime, data []byte, filename, contentType string) sobek.Value {
+	if ctor, ok := sobek.AssertConstructor(runtime.Get("File")); ok {
+		opts := runtime.NewObject()
+		opts.Set("type", contentType)
+		file, err := ctor(nil, runtime.NewArray(runtime.NewArrayBuffer(data)), runtime.ToValue(filename), opts)
+		if err != nil {
+			panic(err)
+		}
+		return file
+	}
+
+	file := runtime.NewObject()
+	file.Set("name", filename)
+	file.Set("type", contentType)
+	file.Set("size", len(data))
+	file.Set("text", func(call sobek.FunctionCall) sobek.Value {
+		return resolved(runtime, runtime.ToValue(string(data)))
+	})
+	file.Set("arrayBuffer", func(call sobek.FunctionCall) sobek.Value {
+		copied := make([]byte, len(data))
+		copy(copied, data)
+		return resolved(runtime, runtime.ToValue(runtime.NewArrayBuffer(copied)))
+	})
+	return file
+}
+
+// resolved returns a promise already fulfilled with value
+func resolved(runtime *sobek.Runtime, value sobek.Value) sobek.Value {
+	promise, resolve, _ := runtime.NewPromise()
+	_ = resolve(value)
+	return runtime.ToValue(promise)
+}
+
+// New creates a read-only FormData-like object over fields, whose errors are
+// attributed to module
+func New(runtime *sobek.Runtime, module string, fields []Field) *sobek.Object {
+	obj := runtime.NewObject()
+
+	// get(name) - returns the first value for name, or null
+	obj.Set("get", func(call sobek.FunctionCall) sobek.Value {
+		name := call.Argument(0).String()
+		for _, field := range fields {
+			if field.name == name {
+				return field.value
+			}
+		}
+		return sobek.Null()
+	})
+
+	// getAll(name) - returns every value for name
+	obj.Set("getAll", func(call sobek.FunctionCall) sobek.Value {
+		name := call.Argument(0).String()
+		values := []any{}
+		for _, field := range fields {
+			if field.name == name {
+				values = append(values, field.value)
+			}
+		}
+		return runtime.NewArray(values...)
+	})
+
+	// has(name) - reports whether a field named name was sent
+	obj.Set("has", func(call sobek.FunctionCall) sobek.Value {
+		name := call.Argument(0).String()
+		for _, field := range fields {
+			if field.name == name {
+				return runtime.ToValue(true)
+			}
+		}
+		return runtime.ToValue(false)
+	})
+
+	// forEach(callback, thisArg?) - calls callback(value, name, formData) per field
+	obj.Set("forEach", func(call sobek.FunctionCall) sobek.Value {
+		callback, ok := sobek.AssertFunction(call.Argument(0))
+		if !ok {
+			panic(vm.NewTypeError(runtime, module, vm.CodeInvalidArgument, "formData.forEach: callback must be a function"))
+		}
+		for _, field := range fields {
+			if _, err := callback(call.Argument(1), field.value, runtime.ToValue(field.name), obj); err != nil {
+				panic(err)
+			}
+		}
+		return sobek.Undefined()
+	})
+
+	// entries() - returns an iterator over [name, value] pairs
+	entries := func(call sobek.FunctionCall) sobek.Value {
+		items := make([]any, 0, len(fields))
+		for _, field := range fields {
+			items = append(items, runtime.NewArray(field.name, field.value))
+		}
+		arr := runtime.NewArray(items...)
+		values, _ := sobek.AssertFunction(arr.Get("values"))
+		iterator, err := values(arr)
+		if err != nil {
+			panic(err)
+		}
+		return iterator
+	}
+	obj.Set("entries", entries)
+	_ = obj.DefineDataPropertySymbol(sobek.SymIterator, runtime.ToValue(entries), sobek.FLAG_TRUE, sobek.FLAG_TRUE, sobek.FLAG_FALSE)
+
+	return obj
+}
+
+// ErrUnsupported is returned by Parse for a body that is not a form
+var ErrUnsupported = errors.New("formData: content type must be multipart/form-data or application/x-www-form-urlencoded")
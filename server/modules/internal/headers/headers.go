@@ -0,0 +1,242 @@
+// Package headers builds the Headers-like objects shared by the fetch and
+// http modules.
+package headers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+	"golang.org/x/net/http/httpguts"
+)
+
+// New creates a read-only Headers object for header, e.g. for the headers of
+// a fetched response. Each header is also an own enumerable property holding
+// its first value, so plain property access keeps working; the methods are
+// non-enumerable.
+func New(runtime *sobek.Runtime, header http.Header) *sobek.Object {
+	obj := newObject(runtime)
+	Init(runtime, obj, header, false)
+	return obj
+}
+
+// NewMutable creates a Headers object for header whose append, set and
+// delete methods modify header
+func NewMutable(runtime *sobek.Runtime, header http.Header) *sobek.Object {
+	obj := newObject(runtime)
+	Init(runtime, obj, header, true)
+	return obj
+}
+
+// newObject creates an object that passes instanceof Headers when the
+// Headers class is defined
+func newObject(runtime *sobek.Runtime) *sobek.Object {
+	obj := runtime.NewObject()
+	if ctor, ok := runtime.Get("Headers").(*sobek.Object); ok {
+		if proto, ok := ctor.Get("prototype").(*sobek.Object); ok {
+			obj.SetPrototype(proto)
+		}
+	}
+	return obj
+}
+
+// Init defines the Headers methods on obj, reading and, when mutable,
+// modifying header. Mutating a read-only object throws a TypeError.
+func Init(runtime *sobek.Runtime, obj *sobek.Object, header http.Header, mutable bool) {
+	for key, values := range header {
+		if len(values) > 0 {
+			obj.Set(key, values[0])
+		}
+	}
+	_ = obj.DefineDataProperty("__header", runtime.ToValue(header), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_FALSE)
+
+	define := func(name string, fn func(call sobek.FunctionCall) sobek.Value) {
+		_ = obj.DefineDataProperty(name, runtime.ToValue(fn), sobek.FLAG_TRUE, sobek.FLAG_TRUE, sobek.FLAG_FALSE)
+	}
+
+	// get(name) - returns all values for name joined with ", ", or null
+	define("get", func(call sobek.FunctionCall) sobek.Value {
+		values := header.Values(call.Argument(0).String())
+		if len(values) == 0 {
+			return sobek.Null()
+		}
+		return runtime.ToValue(strings.Join(values, ", "))
+	})
+
+	// getSetCookie() - returns every Set-Cookie value, which get() would join
+	define("getSetCookie", func(call sobek.FunctionCall) sobek.Value {
+		cookies := make([]any, 0, len(header.Values("Set-Cookie")))
+		for _, cookie := range header.Values("Set-Cookie") {
+			cookies = append(cookies, cookie)
+		}
+		return runtime.NewArray(cookies...)
+	})
+
+	// has(name) - reports whether the header is present
+	define("has", func(call sobek.FunctionCall) sobek.Value {
+		return runtime.ToValue(len(header.Values(call.Argument(0).String())) > 0)
+	})
+
+	// update runs a mutation of name, keeping its own property in sync
+	update := func(method string, call sobek.FunctionCall, minArgs int, mutate func(name, value string)) sobek.Value {
+		if !mutable {
+			panic(vm.NewTypeError(runtime, "fetch", vm.CodeNotSupported, "Headers."+method+": headers are immutable"))
+		}
+		if len(call.Arguments) < minArgs {
+			panic(vm.NewTypeError(runtime, "fetch", vm.CodeInvalidArgument, "Headers."+method+": not enough arguments"))
+		}
+		name := call.Argument(0).String()
+		if !httpguts.ValidHeaderFieldName(name) {
+			panic(vm.NewTypeError(runtime, "fetch", vm.CodeInvalidArgument, "Headers."+method+": invalid header name "+name))
+		}
+		value := ""
+		if minArgs > 1 {
+			value = strings.TrimSpace(call.Argument(1).String())
+			if !httpguts.ValidHeaderFieldValue(value) {
+				panic(vm.NewTypeError(runtime, "fetch", vm.CodeInvalidArgument, "Headers."+method+": invalid value for header "+name))
+			}
+		}
+		mutate(name, value)
+
+		key := http.CanonicalHeaderKey(name)
+		if values := header.Values(key); len(values) > 0 {
+			obj.Set(key, values[0])
+		} else {
+			obj.Delete(key)
+		}
+		return sobek.Undefined()
+	}
+
+	// append(name, value) - adds a value, keeping any existing ones
+	define("append", func(call sobek.FunctionCall) sobek.Value {
+		return update("append", call, 2, func(name, value string) { header.Add(name, value) })
+	})
+
+	// set(name, value) - replaces all values of the header
+	define("set", func(call sobek.FunctionCall) sobek.Value {
+		return update("set", call, 2, func(name, value string) { header.Set(name, value) })
+	})
+
+	// delete(name) - removes the header
+	define("delete", func(call sobek.FunctionCall) sobek.Value {
+		return update("delete", call, 1, func(name, value string) { header.Del(name) })
+	})
+
+	// forEach(callback, thisArg?) - calls callback(value, name, headers) per header
+	define("forEach", func(call sobek.FunctionCall) sobek.Value {
+		callback, ok := sobek.AssertFunction(call.Argument(0))
+		if !ok {
+			panic(vm.NewTypeError(runtime, "fetch", vm.CodeInvalidArgument, "headers.forEach: callback must be a function"))
+		}
+		for _, entry := range sortedEntries(header) {
+			if _, err := callback(call.Argument(1), runtime.ToValue(entry[1]), runtime.ToValue(entry[0]), obj); err != nil {
+				panic(err)
+			}
+		}
+		return sobek.Undefined()
+	})
+
+	// entries(), keys() and values() - iterate lower-cased names in sorted order
+	entries := func(call sobek.FunctionCall) sobek.Value {
+		return iterate(runtime, header, func(entry [2]string) any {
+			return runtime.NewArray(entry[0], entry[1])
+		})
+	}
+	define("entries", entries)
+	define("keys", func(call sobek.FunctionCall) sobek.Value {
+		return iterate(runtime, header, func(entry [2]string) any { return entry[0] })
+	})
+	define("values", func(call sobek.FunctionCall) sobek.Value {
+		return iterate(runtime, header, func(entry [2]string) any { return entry[1] })
+	})
+	_ = obj.DefineDataPropertySymbol(sobek.SymIterator, runtime.ToValue(entries), sobek.FLAG_TRUE, sobek.FLAG_TRUE, sobek.FLAG_FALSE)
+}
+
+// Unwrap returns the header behind a Headers object, keeping every value of
+// repeated headers
+func Unwrap(value sobek.Value) (http.Header, bool) {
+	obj, ok := value.(*sobek.Object)
+	if !ok {
+		return nil, false
+	}
+	if v := obj.Get("__header"); v != nil {
+		header, ok := v.Export().(http.Header)
+		return header, ok
+	}
+	return nil, false
+}
+
+// Parse converts a headers init value to a new header: a Headers object or
+// an array of [name, value] pairs, which can both repeat a header, or an
+// object of names to values. Undefined and null give an empty header.
+func Parse(runtime *sobek.Runtime, v sobek.Value) http.Header {
+	header := make(http.Header)
+	if v == nil || sobek.IsUndefined(v) || sobek.IsNull(v) {
+		return header
+	}
+	if h, ok := Unwrap(v); ok {
+		for key, values := range h {
+			header[key] = append([]string(nil), values...)
+		}
+		return header
+	}
+	obj := v.ToObject(runtime)
+	if obj.ClassName() == "Array" {
+		var pairs []sobek.Value
+		if err := runtime.ExportTo(obj, &pairs); err != nil {
+			panic(vm.NewTypeError(runtime, "fetch", vm.CodeInvalidArgument, "headers must be a sequence of [name, value] pairs"))
+		}
+		for _, pair := range pairs {
+			p, ok := pair.(*sobek.Object)
+			if !ok || p.ClassName() != "Array" || p.Get("length").ToInteger() != 2 {
+				panic(vm.NewTypeError(runtime, "fetch", vm.CodeInvalidArgument, "each header pair must have exactly two items"))
+			}
+			header.Add(p.Get("0").String(), p.Get("1").String())
+		}
+		return header
+	}
+	for _, key := range obj.Keys() {
+		value := obj.Get(key)
+		if _, ok := sobek.AssertFunction(value); ok {
+			continue // methods of a Headers-like object
+		}
+		header.Set(key, value.String())
+	}
+	return header
+}
+
+// sortedEntries returns [name, value] pairs with lower-cased names in sorted
+// order and repeated values combined, as the Fetch standard specifies.
+// Set-Cookie values are never combined, so each is its own entry.
+func sortedEntries(header http.Header) [][2]string {
+	entries := make([][2]string, 0, len(header))
+	for key, values := range header {
+		name := strings.ToLower(key)
+		if name == "set-cookie" {
+			for _, value := range values {
+				entries = append(entries, [2]string{name, value})
+			}
+		} else if len(values) > 0 {
+			entries = append(entries, [2]string{name, strings.Join(values, ", ")})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i][0] < entries[j][0] })
+	return entries
+}
+
+// iterate returns an array iterator over the mapped header entries
+func iterate(runtime *sobek.Runtime, header http.Header, mapEntry func(entry [2]string) any) sobek.Value {
+	var items []any
+	for _, entry := range sortedEntries(header) {
+		items = append(items, mapEntry(entry))
+	}
+	arr := runtime.NewArray(items...)
+	values, _ := sobek.AssertFunction(arr.Get("values"))
+	iterator, err := values(arr)
+	if err != nil {
+		panic(err)
+	}
+	return iterator
+}
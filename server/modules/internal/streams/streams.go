@@ -0,0 +1,343 @@
+// Package streams builds the ReadableStream- and WritableStream-like objects
+// that modules use for chunked data, and pipes them together.
+package streams
+
+import (
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// Step converts one written chunk, reporting false if there is nothing to
+// pass on to the readable side
+type Step func(chunk sobek.Value) (sobek.Value, bool)
+
+// Flush returns what is left once the writable side is closed, reporting
+// false if there is nothing
+type Flush func() (sobek.Value, bool)
+
+// transform is the state shared by the two sides of a transform stream
+type transform struct {
+	rt      *sobek.Runtime
+	module  string
+	step    Step
+	flush   Flush
+	queue   []sobek.Value // converted chunks not read yet
+	reads   []read        // reads waiting for a chunk
+	closed  bool
+	failure any // why the stream errored, nil if it didn't
+}
+
+// read is a pending read() of the readable side
+type read struct {
+	resolve, reject func(any) error
+}
+
+// NewTransform creates the readable and writable sides of a transform
+// stream, like those of TransformStream: chunks written to writable go
+// through step and can be read from readable with getReader(). Closing the
+// writer passes what flush returns and ends the readable side.
+func NewTransform(runtime *sobek.Runtime, module string, step Step, flush Flush) (readable, writable *sobek.Object) {
+	t := &transform{rt: runtime, module: module, step: step, flush: flush}
+	return t.newReadable(), t.newWritable()
+}
+
+// newReadable creates the readable side, whose reader resolves read() to
+// { value, done } with each converted chunk
+func (t *transform) newReadable() *sobek.Object {
+	runtime := t.rt
+	stream := runtime.NewObject()
+	locked := false
+
+	// getReader() - locks the stream to a reader with read(), cancel() and
+	// releaseLock()
+	stream.Set("getReader", func(call sobek.FunctionCall) sobek.Value {
+		if locked {
+			panic(vm.NewTypeError(runtime, t.module, vm.CodeInvalidArgument, "stream is already locked to a reader"))
+		}
+		locked = true
+		stream.Set("locked", true)
+
+		reader := runtime.NewObject()
+		reader.Set("read", func(call sobek.FunctionCall) sobek.Value {
+			promise, resolve, reject := runtime.NewPromise()
+			t.read(read{resolve: resolve, reject: reject})
+			return runtime.ToValue(promise)
+		})
+		reader.Set("cancel", func(call sobek.FunctionCall) sobek.Value {
+			t.queue = nil
+			t.end()
+			return resolved(runtime)
+		})
+		reader.Set("releaseLock", func(call sobek.FunctionCall) sobek.Value {
+			locked = false
+			stream.Set("locked", false)
+			return sobek.Undefined()
+		})
+		return reader
+	})
+	stream.Set("locked", false)
+	SetPipeThrough(runtime, t.module, stream)
+
+	return stream
+}
+
+// newWritable creates the writable side, whose writer passes each chunk given
+// to write() through step
+func (t *transform) newWritable() *sobek.Object {
+	runtime := t.rt
+	stream := runtime.NewObject()
+	locked := false
+
+	// getWriter() - locks the stream to a writer with write(), close(),
+	// abort() and releaseLock()
+	stream.Set("getWriter", func(call sobek.FunctionCall) sobek.Value {
+		if locked {
+			panic(vm.NewTypeError(runtime, t.module, vm.CodeInvalidArgument, "stream is already locked to a writer"))
+		}
+		locked = true
+		stream.Set("locked", true)
+
+		writer := runtime.NewObject()
+		writer.Set("write", func(call sobek.FunctionCall) sobek.Value {
+			if reason := t.writable(); reason != nil {
+				return rejected(runtime, reason)
+			}
+			var value sobek.Value
+			var ok bool
+			if reason := catch(func() { value, ok = t.step(call.Argument(0)) }); reason != nil {
+				t.fail(reason)
+				return rejected(runtime, reason)
+			}
+			if ok {
+				t.push(value)
+			}
+			return resolved(runtime)
+		})
+		writer.Set("close", func(call sobek.FunctionCall) sobek.Value {
+			if reason := t.writable(); reason != nil {
+				return rejected(runtime, reason)
+			}
+			var value sobek.Value
+			var ok bool
+			if reason := catch(func() { value, ok = t.flush() }); reason != nil {
+				t.fail(reason)
+				return rejected(runtime, reason)
+			}
+			if ok {
+				t.push(value)
+			}
+			t.end()
+			return resolved(runtime)
+		})
+		writer.Set("abort", func(call sobek.FunctionCall) sobek.Value {
+			if t.failure == nil && !t.closed {
+				t.fail(call.Argument(0))
+			}
+			return resolved(runtime)
+		})
+		writer.Set("releaseLock", func(call sobek.FunctionCall) sobek.Value {
+			locked = false
+			stream.Set("locked", false)
+			return sobek.Undefined()
+		})
+		writer.Set("ready", resolved(runtime))
+		return writer
+	})
+	stream.Set("locked", false)
+
+	return stream
+}
+
+// writable returns why nothing more can be written, or nil
+func (t *transform) writable() any {
+	if t.failure != nil {
+		return t.failure
+	}
+	if t.closed {
+		return vm.NewTypeError(t.rt, t.module, vm.CodeInvalidArgument, "stream is closed")
+	}
+	return nil
+}
+
+// push hands a converted chunk to the oldest waiting read, or queues it
+func (t *transform) push(value sobek.Value) {
+	if len(t.reads) > 0 {
+		r := t.reads[0]
+		t.reads = t.reads[1:]
+		_ = r.resolve(result(value, false))
+		return
+	}
+	t.queue = append(t.queue, value)
+}
+
+// read settles r with the next chunk, or leaves it waiting for one
+func (t *transform) read(r read) {
+	switch {
+	case len(t.queue) > 0:
+		value := t.queue[0]
+		t.queue = t.queue[1:]
+		_ = r.resolve(result(value, false))
+	case t.failure != nil:
+		_ = r.reject(t.failure)
+	case t.closed:
+		_ = r.resolve(result(sobek.Undefined(), true))
+	default:
+		t.reads = append(t.reads, r)
+	}
+}
+
+// end closes the stream, ending the waiting reads
+func (t *transform) end() {
+	t.closed = true
+	reads := t.reads
+	t.reads = nil
+	for _, r := range reads {
+		_ = r.resolve(result(sobek.Undefined(), true))
+	}
+}
+
+// fail errors the stream with reason, rejecting the waiting reads
+func (t *transform) fail(reason any) {
+	t.failure = reason
+	t.queue = nil
+	reads := t.reads
+	t.reads = nil
+	for _, r := range reads {
+		_ = r.reject(reason)
+	}
+}
+
+// SetPipeThrough adds pipeThrough(transform) to stream, a ReadableStream-like
+// object with getReader(). It writes every chunk read from stream to the
+// writable side of transform, e.g. a TextDecoderStream, and returns its
+// readable side.
+func SetPipeThrough(runtime *sobek.Runtime, module string, stream *sobek.Object) {
+	stream.Set("pipeThrough", func(call sobek.FunctionCall) sobek.Value {
+		pair, ok := call.Argument(0).(*sobek.Object)
+		if !ok {
+			panic(vm.NewTypeError(runtime, module, vm.CodeInvalidArgument, "pipeThrough: argument must have readable and writable sides"))
+		}
+		readable, ok1 := pair.Get("readable").(*sobek.Object)
+		writable, ok2 := pair.Get("writable").(*sobek.Object)
+		if !ok1 || !ok2 {
+			panic(vm.NewTypeError(runtime, module, vm.CodeInvalidArgument, "pipeThrough: argument must have readable and writable sides"))
+		}
+		reader := invoke(runtime, module, stream, "getReader").ToObject(runtime)
+		writer := invoke(runtime, module, writable, "getWriter").ToObject(runtime)
+		(&pipe{rt: runtime, module: module, reader: reader, writer: writer}).pump()
+		return readable
+	})
+}
+
+// pipe copies chunks from a reader to a writer, one at a time
+type pipe struct {
+	rt     *sobek.Runtime
+	module string
+	reader *sobek.Object
+	writer *sobek.Object
+}
+
+// pump reads the next chunk and writes it once read, until the reader is
+// done, then closes the writer. A failed read or write aborts the writer.
+func (p *pipe) pump() {
+	var next sobek.Value
+	if reason := catch(func() { next = p.call(p.reader, "read") }); reason != nil {
+		p.abort(reason)
+		return
+	}
+	p.then(next, func(result sobek.Value) {
+		chunk := result.ToObject(p.rt)
+		if chunk.Get("done").ToBoolean() {
+			if reason := catch(func() { p.call(p.writer, "close") }); reason != nil {
+				p.abort(reason)
+			}
+			return
+		}
+		var written sobek.Value
+		if reason := catch(func() { written = p.call(p.writer, "write", chunk.Get("value")) }); reason != nil {
+			p.abort(reason)
+			return
+		}
+		p.then(written, func(sobek.Value) { p.pump() })
+	})
+}
+
+// then calls onValue once promise fulfills, aborting the writer if it rejects
+func (p *pipe) then(promise sobek.Value, onValue func(sobek.Value)) {
+	onFulfilled := func(call sobek.FunctionCall) sobek.Value {
+		onValue(call.Argument(0))
+		return sobek.Undefined()
+	}
+	onRejected := func(call sobek.FunctionCall) sobek.Value {
+		p.abort(call.Argument(0))
+		return sobek.Undefined()
+	}
+	if reason := catch(func() {
+		p.call(promise.ToObject(p.rt), "then", p.rt.ToValue(onFulfilled), p.rt.ToValue(onRejected))
+	}); reason != nil {
+		p.abort(reason)
+	}
+}
+
+// abort aborts the writer with reason, ignoring a writer without abort()
+func (p *pipe) abort(reason any) {
+	if abort, ok := sobek.AssertFunction(p.writer.Get("abort")); ok {
+		_, _ = abort(p.writer, p.rt.ToValue(reason))
+	}
+}
+
+// call calls the method name of obj with args, throwing what it throws
+func (p *pipe) call(obj *sobek.Object, name string, args ...sobek.Value) sobek.Value {
+	return invoke(p.rt, p.module, obj, name, args...)
+}
+
+// invoke calls the method name of obj with args and returns its result,
+// throwing a TypeError for module if it isn't a method
+func invoke(runtime *sobek.Runtime, module string, obj *sobek.Object, name string, args ...sobek.Value) sobek.Value {
+	fn, ok := sobek.AssertFunction(obj.Get(name))
+	if !ok {
+		panic(vm.NewTypeError(runtime, module, vm.CodeInvalidArgument, "stream has no "+name+"() method"))
+	}
+	value, err := fn(obj, args...)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// catch runs fn, returning what it throws as a JavaScript value, or nil
+func catch(fn func()) (reason any) {
+	defer func() {
+		r := recover()
+		switch v := r.(type) {
+		case nil:
+		case *sobek.Exception:
+			reason = v.Value()
+		case *sobek.Object:
+			reason = v
+		default:
+			panic(r)
+		}
+	}()
+	fn()
+	return nil
+}
+
+// result creates the { value, done } result of a read
+func result(value sobek.Value, done bool) map[string]any {
+	return map[string]any{"value": value, "done": done}
+}
+
+// resolved returns a promise already fulfilled with undefined
+func resolved(runtime *sobek.Runtime) sobek.Value {
+	promise, resolve, _ := runtime.NewPromise()
+	_ = resolve(sobek.Undefined())
+	return runtime.ToValue(promise)
+}
+
+// rejected returns a promise already rejected with reason
+func rejected(runtime *sobek.Runtime, reason any) sobek.Value {
+	promise, _, reject := runtime.NewPromise()
+	_ = reject(reason)
+	return runtime.ToValue(promise)
+}
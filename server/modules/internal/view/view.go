@@ -0,0 +1,41 @@
+// Package view reads the bytes that typed arrays and DataViews look at, for
+// the modules that accept binary arguments.
+package view
+
+import (
+	"fmt"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// Bytes returns the bytes obj views in its underlying buffer, the range its
+// byteOffset and byteLength give, without copying them. It reports false if
+// obj has no ArrayBuffer as its buffer. A range outside the buffer, e.g. from
+// a plain object posing as a view, throws a RangeError for module.
+func Bytes(runtime *sobek.Runtime, module string, obj *sobek.Object) ([]byte, bool) {
+	v := obj.Get("buffer")
+	if v == nil {
+		return nil, false
+	}
+	buf, ok := v.Export().(sobek.ArrayBuffer)
+	if !ok {
+		return nil, false
+	}
+	data := buf.Bytes()
+	offset := integer(obj.Get("byteOffset"))
+	length := integer(obj.Get("byteLength"))
+	if offset < 0 || length < 0 || offset > int64(len(data)) || length > int64(len(data))-offset {
+		panic(vm.NewRangeError(runtime, module, vm.CodeInvalidArgument,
+			fmt.Sprintf("byteOffset %d and byteLength %d are outside a buffer of %d bytes", offset, length, len(data))))
+	}
+	return data[offset : offset+length], true
+}
+
+// integer converts an offset or length, treating a missing one as 0
+func integer(v sobek.Value) int64 {
+	if v == nil {
+		return 0
+	}
+	return v.ToInteger()
+}
@@ -0,0 +1,60 @@
+package kv
+
+import (
+	"sort"
+
+	"github.com/grafana/sobek"
+)
+
+// cloneValue deep-copies an exported JavaScript value, so the stored value is
+// a snapshot that no object or array still reachable from a script aliases
+func cloneValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		cloned := make(map[string]any, len(v))
+		for key, item := range v {
+			cloned[key] = cloneValue(item)
+		}
+		return cloned
+	case []any:
+		cloned := make([]any, len(v))
+		for i, item := range v {
+			cloned[i] = cloneValue(item)
+		}
+		return cloned
+	case []byte:
+		return append([]byte(nil), v...)
+	default:
+		// Strings, numbers, booleans and nil are immutable
+		return v
+	}
+}
+
+// toValue converts a stored value into new plain JavaScript objects and
+// arrays, so mutating what kv.get returned doesn't change the store. Object
+// keys come out sorted, since stored maps don't keep their order.
+func toValue(runtime *sobek.Runtime, value any) sobek.Value {
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		obj := runtime.NewObject()
+		for _, key := range keys {
+			obj.Set(key, toValue(runtime, v[key]))
+		}
+		return obj
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = toValue(runtime, item)
+		}
+		return runtime.NewArray(items...)
+	case []byte:
+		return runtime.ToValue(append([]byte(nil), v...))
+	default:
+		return runtime.ToValue(v)
+	}
+}
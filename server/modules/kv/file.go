@@ -0,0 +1,121 @@
+package kv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// fileStore is an implementation of Store that persists values to a JSON file
+// so they survive restarts. Every mutation rewrites the file atomically.
+type fileStore struct {
+	sync.Mutex
+	path  string
+	items map[string]any
+}
+
+// NewFileStore returns a Store persisted at path, loading existing values
+// if the file exists
+func NewFileStore(path string) (Store, error) {
+	s := &fileStore{
+		path:  path,
+		items: make(map[string]any),
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return s, nil
+	case err != nil:
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.items); err != nil {
+			return nil, fmt.Errorf("kv file %s: %w", path, err)
+		}
+	}
+	return s, nil
+}
+
+// Get returns the value for key and whether it exists
+func (s *fileStore) Get(_ context.Context, key string) (any, bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	value, exists := s.items[key]
+	return value, exists, nil
+}
+
+// Set stores value under key and persists the store
+func (s *fileStore) Set(_ context.Context, key string, value any) error {
+	s.Lock()
+	defer s.Unlock()
+
+	// Reject values that can't be persisted before touching the store
+	if _, err := json.Marshal(value); err != nil {
+		return fmt.Errorf("kv value for %q is not serializable: %w", key, err)
+	}
+	s.items[key] = value
+	return s.save()
+}
+
+// Delete removes key, reporting whether it existed, and persists the store
+func (s *fileStore) Delete(_ context.Context, key string) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, exists := s.items[key]; !exists {
+		return false, nil
+	}
+	delete(s.items, key)
+	return true, s.save()
+}
+
+// Keys returns all stored keys
+func (s *fileStore) Keys(_ context.Context) ([]string, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	keys := make([]string, 0, len(s.items))
+	for key := range s.items {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Clear removes all values and persists the empty store
+func (s *fileStore) Clear(_ context.Context) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.items = make(map[string]any)
+	return s.save()
+}
+
+// save writes the store to a temp file and renames it over the target,
+// so readers never observe a partially written file. Callers hold the lock.
+func (s *fileStore) save() error {
+	data, err := json.Marshal(s.items)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
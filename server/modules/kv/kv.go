@@ -1,19 +1,36 @@
 package kv
 
 import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
 	"github.com/grafana/sobek"
 	"github.com/mark3labs/codebench-mcp/server/vm"
 )
 
 // KVModule provides key-value storage per VM instance
 type KVModule struct {
-	store map[string]any // Per-VM instance storage
+	store Store // nil for the default in-memory store kept per VM
 }
 
-// NewKVModule creates a new KV module with isolated storage
+// NewKVModule creates a new KV module with isolated storage: each VM gets an
+// in-memory store of its own, which lives as long as the VM, so a persistent
+// VM keeps its values across the executions of its session
 func NewKVModule() *KVModule {
+	return &KVModule{}
+}
+
+// NewKVModuleWithStore creates a KV module backed by store, e.g. a persistent
+// store that survives restarts. Custom stores are shared by every VM.
+// A nil store falls back to the in-memory store.
+func NewKVModuleWithStore(store Store) *KVModule {
+	if store == nil {
+		return NewKVModule()
+	}
 	return &KVModule{
-		store: make(map[string]any),
+		store: store,
 	}
 }
 
@@ -35,7 +52,30 @@ func (kv *KVModule) GetGlobalName() string {
 
 // CreateGlobalObject creates the kv object for global access
 func (kv *KVModule) CreateGlobalObject(runtime *sobek.Runtime) sobek.Value {
+	return newKVObject(runtime, &namespaceStore{store: kv.storeFor(runtime)})
+}
+
+var symKV = sobek.NewSymbol(`Symbol.__kv__`)
+
+// storeFor returns the store the values of runtime are kept in
+func (kv *KVModule) storeFor(runtime *sobek.Runtime) Store {
+	if kv.store != nil {
+		return kv.store
+	}
+	global := runtime.GlobalObject()
+	if v := global.GetSymbol(symKV); v != nil {
+		return v.Export().(Store)
+	}
+	store := NewMemoryStore()
+	_ = global.SetSymbol(symKV, store)
+	return store
+}
+
+// newKVObject creates a kv object storing its values in store, the view of
+// the root or of a namespace of the module's store
+func newKVObject(runtime *sobek.Runtime, store *namespaceStore) *sobek.Object {
 	kvObj := runtime.NewObject()
+	ctx := context.Background()
 
 	// kv.get(key) - retrieve a value
 	kvObj.Set("get", func(call sobek.FunctionCall) sobek.Value {
@@ -43,11 +83,14 @@ func (kv *KVModule) CreateGlobalObject(runtime *sobek.Runtime) sobek.Value {
 			return sobek.Undefined()
 		}
 		key := call.Argument(0).String()
-		value, exists := kv.store[key]
+		value, exists, err := store.Get(ctx, key)
+		if err != nil {
+			panic(vm.NewError(runtime, "kv", vm.CodeOperationFailed, err))
+		}
 		if !exists {
 			return sobek.Undefined()
 		}
-		return runtime.ToValue(value)
+		return toValue(runtime, value)
 	})
 
 	// kv.set(key, value) - store a value
@@ -56,8 +99,11 @@ func (kv *KVModule) CreateGlobalObject(runtime *sobek.Runtime) sobek.Value {
 			return runtime.ToValue(false)
 		}
 		key := call.Argument(0).String()
-		value := call.Argument(1).Export()
-		kv.store[key] = value
+		// Store a snapshot, unaffected by later changes to the object passed
+		value := cloneValue(call.Argument(1).Export())
+		if err := store.Set(ctx, key, value); err != nil {
+			panic(vm.NewError(runtime, "kv", vm.CodeOperationFailed, err))
+		}
 		return runtime.ToValue(true)
 	})
 
@@ -67,26 +113,58 @@ func (kv *KVModule) CreateGlobalObject(runtime *sobek.Runtime) sobek.Value {
 			return runtime.ToValue(false)
 		}
 		key := call.Argument(0).String()
-		_, exists := kv.store[key]
-		if exists {
-			delete(kv.store, key)
-			return runtime.ToValue(true)
+		deleted, err := store.Delete(ctx, key)
+		if err != nil {
+			panic(vm.NewError(runtime, "kv", vm.CodeOperationFailed, err))
 		}
-		return runtime.ToValue(false)
+		return runtime.ToValue(deleted)
 	})
 
 	// kv.list() - list all keys
 	kvObj.Set("list", func(call sobek.FunctionCall) sobek.Value {
-		keys := make([]string, 0, len(kv.store))
-		for key := range kv.store {
-			keys = append(keys, key)
+		keys, err := store.Keys(ctx)
+		if err != nil {
+			panic(vm.NewError(runtime, "kv", vm.CodeOperationFailed, err))
+		}
+		return runtime.ToValue(keys)
+	})
+
+	// kv.keys(prefix?) - list keys, sorted and optionally filtered by prefix
+	kvObj.Set("keys", func(call sobek.FunctionCall) sobek.Value {
+		keys, err := matchingKeys(ctx, store, call.Argument(0))
+		if err != nil {
+			panic(vm.NewError(runtime, "kv", vm.CodeOperationFailed, err))
 		}
 		return runtime.ToValue(keys)
 	})
 
+	// kv.entries(prefix?) - list [key, value] pairs, sorted by key and
+	// optionally filtered by prefix
+	kvObj.Set("entries", func(call sobek.FunctionCall) sobek.Value {
+		keys, err := matchingKeys(ctx, store, call.Argument(0))
+		if err != nil {
+			panic(vm.NewError(runtime, "kv", vm.CodeOperationFailed, err))
+		}
+		entries := make([]any, 0, len(keys))
+		for _, key := range keys {
+			value, exists, err := store.Get(ctx, key)
+			if err != nil {
+				panic(vm.NewError(runtime, "kv", vm.CodeOperationFailed, err))
+			}
+			if !exists {
+				// Deleted since the keys were listed
+				continue
+			}
+			entries = append(entries, runtime.NewArray(key, toValue(runtime, value)))
+		}
+		return runtime.NewArray(entries...)
+	})
+
 	// kv.clear() - clear all data
 	kvObj.Set("clear", func(call sobek.FunctionCall) sobek.Value {
-		kv.store = make(map[string]any)
+		if err := store.Clear(ctx); err != nil {
+			panic(vm.NewError(runtime, "kv", vm.CodeOperationFailed, err))
+		}
 		return runtime.ToValue(true)
 	})
 
@@ -96,22 +174,63 @@ func (kv *KVModule) CreateGlobalObject(runtime *sobek.Runtime) sobek.Value {
 			return runtime.ToValue(false)
 		}
 		key := call.Argument(0).String()
-		_, exists := kv.store[key]
+		_, exists, err := store.Get(ctx, key)
+		if err != nil {
+			panic(vm.NewError(runtime, "kv", vm.CodeOperationFailed, err))
+		}
 		return runtime.ToValue(exists)
 	})
 
 	// kv.size() - get number of stored items
 	kvObj.Set("size", func(call sobek.FunctionCall) sobek.Value {
-		return runtime.ToValue(len(kv.store))
+		keys, err := store.Keys(ctx)
+		if err != nil {
+			panic(vm.NewError(runtime, "kv", vm.CodeOperationFailed, err))
+		}
+		return runtime.ToValue(len(keys))
+	})
+
+	// kv.namespace(name) - a kv object whose keys are isolated from the
+	// root and other namespaces, sharing the same store
+	kvObj.Set("namespace", func(call sobek.FunctionCall) sobek.Value {
+		if len(call.Arguments) == 0 {
+			panic(vm.NewTypeError(runtime, "kv", vm.CodeInvalidArgument, "kv.namespace() expects a name"))
+		}
+		name := call.Argument(0).String()
+		if name == "" || strings.ContainsRune(name, 0) {
+			panic(vm.NewTypeError(runtime, "kv", vm.CodeInvalidArgument, "kv.namespace() name must be non-empty and not contain NUL"))
+		}
+		return newKVObject(runtime, store.namespace(name))
 	})
 
 	return kvObj
 }
 
+// matchingKeys returns the sorted keys of store starting with prefix, or all
+// keys if prefix is undefined
+func matchingKeys(ctx context.Context, store Store, prefix sobek.Value) ([]string, error) {
+	keys, err := store.Keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if prefix != nil && !sobek.IsUndefined(prefix) && !sobek.IsNull(prefix) {
+		p := prefix.String()
+		matching := make([]string, 0, len(keys))
+		for _, key := range keys {
+			if strings.HasPrefix(key, p) {
+				matching = append(matching, key)
+			}
+		}
+		keys = matching
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
 // Cleanup performs any necessary cleanup
 func (kv *KVModule) Cleanup() error {
-	// Clear the store on cleanup
-	kv.store = nil
+	// Per-VM stores go away with their VM and custom stores are expected to
+	// outlive it, so there is nothing to clear
 	return nil
 }
 
@@ -120,3 +239,76 @@ func (kv *KVModule) IsEnabled(enabledModules map[string]bool) bool {
 	enabled, exists := enabledModules["kv"]
 	return exists && enabled
 }
+
+// Store interface for kv backends
+// Values are exported JavaScript values (strings, numbers, booleans, nil,
+// []any and map[string]any). Implementations must be safe for concurrent use.
+type Store interface {
+	Get(ctx context.Context, key string) (any, bool, error)
+	Set(ctx context.Context, key string, value any) error
+	Delete(ctx context.Context, key string) (bool, error)
+	Keys(ctx context.Context) ([]string, error)
+	Clear(ctx context.Context) error
+}
+
+// memoryStore is an implementation of Store that keeps values in memory
+type memoryStore struct {
+	sync.Mutex
+	items map[string]any
+}
+
+// Get returns the value for key and whether it exists
+func (s *memoryStore) Get(_ context.Context, key string) (any, bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	value, exists := s.items[key]
+	return value, exists, nil
+}
+
+// Set stores value under key
+func (s *memoryStore) Set(_ context.Context, key string, value any) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.items[key] = value
+	return nil
+}
+
+// Delete removes key, reporting whether it existed
+func (s *memoryStore) Delete(_ context.Context, key string) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	_, exists := s.items[key]
+	delete(s.items, key)
+	return exists, nil
+}
+
+// Keys returns all stored keys
+func (s *memoryStore) Keys(_ context.Context) ([]string, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	keys := make([]string, 0, len(s.items))
+	for key := range s.items {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Clear removes all values
+func (s *memoryStore) Clear(_ context.Context) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.items = make(map[string]any)
+	return nil
+}
+
+// NewMemoryStore returns a new Store that keeps values in memory
+func NewMemoryStore() Store {
+	return &memoryStore{
+		items: make(map[string]any),
+	}
+}
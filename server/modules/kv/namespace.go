@@ -0,0 +1,82 @@
+package kv
+
+import (
+	"context"
+	"strings"
+)
+
+// namespaceMarker starts the key prefix of a namespace. Keys of the
+// namespace "users" are stored as "\x00ns\x00users\x00<key>", and those of a
+// namespace nested in it get another marker after that prefix.
+const namespaceMarker = "\x00ns\x00"
+
+// namespaceStore is the view of a Store seen by the root kv object or by a
+// namespace. It only sees its own keys: those with its prefix that don't
+// belong to a namespace nested below it.
+type namespaceStore struct {
+	store  Store
+	prefix string // Empty for the root
+}
+
+// namespace returns the view of the namespace name nested in s
+func (s *namespaceStore) namespace(name string) *namespaceStore {
+	return &namespaceStore{
+		store:  s.store,
+		prefix: s.prefix + namespaceMarker + name + "\x00",
+	}
+}
+
+// owns reports whether the stored key belongs to s, returning the key as s
+// sees it
+func (s *namespaceStore) owns(stored string) (string, bool) {
+	key, ok := strings.CutPrefix(stored, s.prefix)
+	if !ok || strings.HasPrefix(key, namespaceMarker) {
+		return "", false
+	}
+	return key, true
+}
+
+// Get returns the value for key and whether it exists
+func (s *namespaceStore) Get(ctx context.Context, key string) (any, bool, error) {
+	return s.store.Get(ctx, s.prefix+key)
+}
+
+// Set stores value under key
+func (s *namespaceStore) Set(ctx context.Context, key string, value any) error {
+	return s.store.Set(ctx, s.prefix+key, value)
+}
+
+// Delete removes key, reporting whether it existed
+func (s *namespaceStore) Delete(ctx context.Context, key string) (bool, error) {
+	return s.store.Delete(ctx, s.prefix+key)
+}
+
+// Keys returns the keys of this namespace, without its prefix
+func (s *namespaceStore) Keys(ctx context.Context) ([]string, error) {
+	stored, err := s.store.Keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(stored))
+	for _, key := range stored {
+		if key, ok := s.owns(key); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// Clear removes the values of this namespace, leaving the root and other
+// namespaces untouched
+func (s *namespaceStore) Clear(ctx context.Context) error {
+	keys, err := s.Keys(ctx)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if _, err := s.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,321 @@
+package ndjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/modules/internal/streams"
+	"github.com/mark3labs/codebench-mcp/server/modules/internal/view"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// NDJSONModule provides newline-delimited JSON parsing and serialization
+type NDJSONModule struct{}
+
+// NewNDJSONModule creates a new ndjson module
+func NewNDJSONModule() *NDJSONModule {
+	return &NDJSONModule{}
+}
+
+// Name returns the module name
+func (n *NDJSONModule) Name() string {
+	return "ndjson"
+}
+
+// Setup initializes the ndjson module in the VM
+func (n *NDJSONModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) error {
+	// No setup needed - the module will be available via require()
+	return nil
+}
+
+// CreateModuleObject creates the ndjson object when required
+func (n *NDJSONModule) CreateModuleObject(runtime *sobek.Runtime) sobek.Value {
+	module := runtime.NewObject()
+
+	// parse(text) - parses one JSON value per line, skipping blank lines, and
+	// returns them as an array
+	module.Set("parse", func(call sobek.FunctionCall) sobek.Value {
+		if len(call.Arguments) == 0 {
+			panic(vm.NewTypeError(runtime, "ndjson", vm.CodeInvalidArgument, "parse: 1 argument required, but only 0 present"))
+		}
+		p := &parser{rt: runtime}
+		p.write([]byte(call.Argument(0).String()))
+		p.end()
+		return runtime.NewArray(p.values...)
+	})
+
+	// stringify(values) - serializes each item of values as one line of JSON,
+	// each followed by a newline
+	module.Set("stringify", func(call sobek.FunctionCall) sobek.Value {
+		arr, ok := call.Argument(0).(*sobek.Object)
+		if !ok || arr.ClassName() != "Array" {
+			panic(vm.NewTypeError(runtime, "ndjson", vm.CodeInvalidArgument, "stringify: values must be an array"))
+		}
+		var values []sobek.Value
+		if err := runtime.ExportTo(arr, &values); err != nil {
+			panic(vm.NewTypeError(runtime, "ndjson", vm.CodeInvalidArgument, "stringify: values must be an array"))
+		}
+		stringify, _ := sobek.AssertFunction(runtime.Get("JSON").ToObject(runtime).Get("stringify"))
+		var out strings.Builder
+		for i, value := range values {
+			line, err := stringify(sobek.Undefined(), value)
+			if err != nil {
+				panic(err)
+			}
+			if sobek.IsUndefined(line) {
+				panic(vm.NewTypeError(runtime, "ndjson", vm.CodeInvalidArgument, fmt.Sprintf("stringify: item %d is not JSON-serializable", i)))
+			}
+			out.WriteString(line.String())
+			out.WriteByte('\n')
+		}
+		return runtime.ToValue(out.String())
+	})
+
+	// parseStream(stream) - parses a ReadableStream-like source of text or
+	// byte chunks, such as a streamed request body, into a stream of values
+	module.Set("parseStream", func(call sobek.FunctionCall) sobek.Value {
+		source, ok := call.Argument(0).(*sobek.Object)
+		if !ok {
+			panic(vm.NewTypeError(runtime, "ndjson", vm.CodeInvalidArgument, "parseStream: stream must have a getReader() method"))
+		}
+		getReader, ok := sobek.AssertFunction(source.Get("getReader"))
+		if !ok {
+			panic(vm.NewTypeError(runtime, "ndjson", vm.CodeInvalidArgument, "parseStream: stream must have a getReader() method"))
+		}
+		return newValueStream(runtime, getReader, source)
+	})
+
+	return module
+}
+
+// parser splits input into lines and parses each complete line as JSON
+type parser struct {
+	rt      *sobek.Runtime
+	pending []byte // bytes after the last newline
+	line    int    // number of the last line parsed
+	values  []any
+}
+
+// write parses the complete lines in data, keeping a trailing partial line
+// for the next write. Lines are only decoded once complete, so a multi-byte
+// character split across chunks is kept intact.
+func (p *parser) write(data []byte) {
+	p.pending = append(p.pending, data...)
+	for {
+		i := bytes.IndexByte(p.pending, '\n')
+		if i < 0 {
+			return
+		}
+		p.parseLine(p.pending[:i])
+		p.pending = p.pending[i+1:]
+	}
+}
+
+// end parses a last line that has no trailing newline
+func (p *parser) end() {
+	if len(p.pending) > 0 {
+		p.parseLine(p.pending)
+		p.pending = nil
+	}
+}
+
+// parseLine parses one line, throwing a SyntaxError naming the line when it
+// isn't valid JSON
+func (p *parser) parseLine(line []byte) {
+	p.line++
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return
+	}
+	var raw json.RawMessage
+	if err := json.Unmarshal(line, &raw); err != nil {
+		panic(vm.NewSyntaxError(p.rt, "ndjson", vm.CodeInvalidArgument, fmt.Sprintf("line %d: %v", p.line, err)))
+	}
+	parse, _ := sobek.AssertFunction(p.rt.Get("JSON").ToObject(p.rt).Get("parse"))
+	value, err := parse(sobek.Undefined(), p.rt.ToValue(string(line)))
+	if err != nil {
+		panic(err)
+	}
+	p.values = append(p.values, value)
+}
+
+// newValueStream creates a ReadableStream-like object whose reader resolves
+// read() to { value, done } with each value parsed from source, and whose
+// pipeThrough() pipes those values into another stream
+func newValueStream(runtime *sobek.Runtime, getReader sobek.Callable, source *sobek.Object) *sobek.Object {
+	stream := runtime.NewObject()
+	locked := false
+
+	// getReader() - locks the stream to a reader with read(), cancel() and
+	// releaseLock()
+	stream.Set("getReader", func(call sobek.FunctionCall) sobek.Value {
+		if locked {
+			panic(vm.NewTypeError(runtime, "ndjson", vm.CodeInvalidArgument, "stream is already locked to a reader"))
+		}
+		sourceReader, err := getReader(source)
+		if err != nil {
+			panic(err)
+		}
+		r := &valueReader{rt: runtime, source: sourceReader.ToObject(runtime), parser: &parser{rt: runtime}}
+		locked = true
+		stream.Set("locked", true)
+
+		reader := runtime.NewObject()
+		reader.Set("read", func(call sobek.FunctionCall) sobek.Value {
+			promise, resolve, reject := runtime.NewPromise()
+			r.read(resolve, reject)
+			return runtime.ToValue(promise)
+		})
+		reader.Set("cancel", func(call sobek.FunctionCall) sobek.Value {
+			r.done = true
+			r.parser.values = nil
+			if cancel, ok := sobek.AssertFunction(r.source.Get("cancel")); ok {
+				result, err := cancel(r.source, call.Argument(0))
+				if err != nil {
+					panic(err)
+				}
+				return result
+			}
+			promise, resolve, _ := runtime.NewPromise()
+			_ = resolve(sobek.Undefined())
+			return runtime.ToValue(promise)
+		})
+		reader.Set("releaseLock", func(call sobek.FunctionCall) sobek.Value {
+			if release, ok := sobek.AssertFunction(r.source.Get("releaseLock")); ok {
+				if _, err := release(r.source); err != nil {
+					panic(err)
+				}
+			}
+			locked = false
+			stream.Set("locked", false)
+			return sobek.Undefined()
+		})
+		return reader
+	})
+	stream.Set("locked", false)
+	streams.SetPipeThrough(runtime, "ndjson", stream)
+
+	return stream
+}
+
+// valueReader reads chunks from a source reader until a value is parsed
+type valueReader struct {
+	rt     *sobek.Runtime
+	source *sobek.Object
+	parser *parser
+	done   bool // the source is exhausted or the reader was cancelled
+}
+
+// read settles one read() of the value stream, reading more chunks from the
+// source while no parsed value is waiting
+func (r *valueReader) read(resolve, reject func(any) error) {
+	if len(r.parser.values) > 0 {
+		value := r.parser.values[0]
+		r.parser.values = r.parser.values[1:]
+		_ = resolve(map[string]any{"value": value, "done": false})
+		return
+	}
+	if r.done {
+		_ = resolve(map[string]any{"value": sobek.Undefined(), "done": true})
+		return
+	}
+
+	readChunk, ok := sobek.AssertFunction(r.source.Get("read"))
+	if !ok {
+		_ = reject(vm.NewTypeError(r.rt, "ndjson", vm.CodeInvalidArgument, "parseStream: stream reader has no read() method"))
+		return
+	}
+	pending, err := readChunk(r.source)
+	if err != nil {
+		_ = reject(thrown(err))
+		return
+	}
+	then, ok := sobek.AssertFunction(pending.ToObject(r.rt).Get("then"))
+	if !ok {
+		_ = reject(vm.NewTypeError(r.rt, "ndjson", vm.CodeInvalidArgument, "parseStream: read() must return a promise"))
+		return
+	}
+	onChunk := func(call sobek.FunctionCall) sobek.Value {
+		if reason := r.consume(call.Argument(0)); reason != nil {
+			r.done = true
+			_ = reject(reason)
+			return sobek.Undefined()
+		}
+		r.read(resolve, reject)
+		return sobek.Undefined()
+	}
+	onError := func(call sobek.FunctionCall) sobek.Value {
+		r.done = true
+		_ = reject(call.Argument(0))
+		return sobek.Undefined()
+	}
+	if _, err := then(pending, r.rt.ToValue(onChunk), r.rt.ToValue(onError)); err != nil {
+		_ = reject(thrown(err))
+	}
+}
+
+// consume feeds one { value, done } result of the source reader to the
+// parser, returning what a bad line or chunk throws, or nil
+func (r *valueReader) consume(result sobek.Value) (reason any) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if err, ok := recovered.(error); ok {
+				recovered = thrown(err)
+			}
+			reason = recovered
+		}
+	}()
+
+	obj := result.ToObject(r.rt)
+	if obj.Get("done").ToBoolean() {
+		r.done = true
+		r.parser.end()
+		return nil
+	}
+	chunk, ok := chunkBytes(r.rt, obj.Get("value"))
+	if !ok {
+		return vm.NewTypeError(r.rt, "ndjson", vm.CodeInvalidArgument, "parseStream: chunks must be strings or Uint8Arrays")
+	}
+	r.parser.write(chunk)
+	return nil
+}
+
+// thrown returns the JavaScript value behind err when it is an exception
+func thrown(err error) any {
+	if exception, ok := err.(*sobek.Exception); ok {
+		return exception.Value()
+	}
+	return err
+}
+
+// chunkBytes returns the bytes of a string, ArrayBuffer or typed array chunk
+func chunkBytes(runtime *sobek.Runtime, value sobek.Value) ([]byte, bool) {
+	switch v := value.Export().(type) {
+	case string:
+		return []byte(v), true
+	case []byte:
+		return v, true
+	case sobek.ArrayBuffer:
+		return v.Bytes(), true
+	}
+	obj, ok := value.(*sobek.Object)
+	if !ok {
+		return nil, false
+	}
+	return view.Bytes(runtime, "ndjson", obj)
+}
+
+// Cleanup performs any necessary cleanup
+func (n *NDJSONModule) Cleanup() error {
+	// NDJSON module doesn't need cleanup
+	return nil
+}
+
+// IsEnabled checks if the module should be enabled based on configuration
+func (n *NDJSONModule) IsEnabled(enabledModules map[string]bool) bool {
+	enabled, exists := enabledModules["ndjson"]
+	return exists && enabled
+}
@@ -0,0 +1,141 @@
+package pdf
+
+import (
+	"bytes"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// PDFModule provides minimal PDF document generation
+type PDFModule struct{}
+
+// NewPDFModule creates a new PDF module
+func NewPDFModule() *PDFModule {
+	return &PDFModule{}
+}
+
+// Name returns the module name
+func (p *PDFModule) Name() string {
+	return "pdf"
+}
+
+// Setup initializes the PDF module in the VM
+func (p *PDFModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) error {
+	// No setup needed - the module will be available via require()
+	return nil
+}
+
+// CreateModuleObject creates the pdf object when required
+func (p *PDFModule) CreateModuleObject(runtime *sobek.Runtime) sobek.Value {
+	pdf := runtime.NewObject()
+
+	// create(options?) - creates a new document
+	pdf.Set("create", func(call sobek.FunctionCall) sobek.Value {
+		orientation, unit, size := "P", "mm", "A4"
+		if opt := call.Argument(0); !sobek.IsUndefined(opt) && !sobek.IsNull(opt) {
+			opts := opt.ToObject(runtime)
+			if v := opts.Get("orientation"); v != nil && !sobek.IsUndefined(v) {
+				orientation = v.String()
+			}
+			if v := opts.Get("unit"); v != nil && !sobek.IsUndefined(v) {
+				unit = v.String()
+			}
+			if v := opts.Get("size"); v != nil && !sobek.IsUndefined(v) {
+				size = v.String()
+			}
+		}
+
+		doc := fpdf.New(orientation, unit, size, "")
+		doc.SetFont("Helvetica", "", 12)
+		if doc.Err() {
+			panic(vm.NewError(runtime, "pdf", vm.CodeOperationFailed, doc.Error()))
+		}
+		return p.createDocumentObject(runtime, doc)
+	})
+
+	return pdf
+}
+
+// createDocumentObject wraps a document with its JavaScript methods
+func (p *PDFModule) createDocumentObject(runtime *sobek.Runtime, doc *fpdf.Fpdf) *sobek.Object {
+	obj := runtime.NewObject()
+
+	// ensurePage adds the first page lazily so text() works on a fresh document
+	ensurePage := func() {
+		if doc.PageNo() == 0 {
+			doc.AddPage()
+		}
+	}
+
+	// addPage() - starts a new page
+	obj.Set("addPage", func(call sobek.FunctionCall) sobek.Value {
+		doc.AddPage()
+		return obj
+	})
+
+	// setFont(family, style?, size?) - changes the current font
+	obj.Set("setFont", func(call sobek.FunctionCall) sobek.Value {
+		if len(call.Arguments) == 0 {
+			panic(vm.NewTypeError(runtime, "pdf", vm.CodeInvalidArgument, "setFont requires a font family"))
+		}
+		family := call.Argument(0).String()
+		style := ""
+		if v := call.Argument(1); !sobek.IsUndefined(v) {
+			style = v.String()
+		}
+		size, _ := doc.GetFontSize()
+		if v := call.Argument(2); !sobek.IsUndefined(v) {
+			size = v.ToFloat()
+		}
+		doc.SetFont(family, style, size)
+		if doc.Err() {
+			panic(vm.NewError(runtime, "pdf", vm.CodeOperationFailed, doc.Error()))
+		}
+		return obj
+	})
+
+	// text(str, { x, y }?) - writes text at a position or flows it at the cursor
+	obj.Set("text", func(call sobek.FunctionCall) sobek.Value {
+		ensurePage()
+		str := call.Argument(0).String()
+
+		if opt := call.Argument(1); !sobek.IsUndefined(opt) && !sobek.IsNull(opt) {
+			opts := opt.ToObject(runtime)
+			x, y := opts.Get("x"), opts.Get("y")
+			if x != nil && !sobek.IsUndefined(x) && y != nil && !sobek.IsUndefined(y) {
+				doc.Text(x.ToFloat(), y.ToFloat(), str)
+				return obj
+			}
+		}
+
+		_, lineHeight := doc.GetFontSize()
+		doc.MultiCell(0, lineHeight*1.5, str, "", "L", false)
+		return obj
+	})
+
+	// render() - returns the PDF bytes
+	obj.Set("render", func(call sobek.FunctionCall) sobek.Value {
+		ensurePage()
+		var buf bytes.Buffer
+		if err := doc.Output(&buf); err != nil {
+			panic(vm.NewError(runtime, "pdf", vm.CodeOperationFailed, err))
+		}
+		return runtime.ToValue(buf.Bytes())
+	})
+
+	return obj
+}
+
+// Cleanup performs any necessary cleanup
+func (p *PDFModule) Cleanup() error {
+	// PDF module doesn't need cleanup
+	return nil
+}
+
+// IsEnabled checks if the module should be enabled based on configuration
+func (p *PDFModule) IsEnabled(enabledModules map[string]bool) bool {
+	enabled, exists := enabledModules["pdf"]
+	return exists && enabled
+}
@@ -0,0 +1,78 @@
+package timers
+
+import (
+	"github.com/grafana/sobek"
+)
+
+// timerSignal splits the extra arguments of setTimeout or setInterval into
+// the callback arguments and an AbortSignal. A single extra argument that is
+// an object whose signal property is an AbortSignal is taken as the options
+// { signal } rather than passed to the callback.
+func timerSignal(runtime *sobek.Runtime, args []sobek.Value) ([]sobek.Value, *sobek.Object) {
+	if len(args) != 1 {
+		return args, nil
+	}
+	options, ok := args[0].(*sobek.Object)
+	if !ok {
+		return args, nil
+	}
+	signal, ok := options.Get("signal").(*sobek.Object)
+	if !ok || !isAbortSignal(signal) {
+		return args, nil
+	}
+	return nil, signal
+}
+
+// isAbortSignal reports whether obj looks like an AbortSignal. The timers
+// module doesn't depend on fetch, which defines AbortSignal, so any object
+// with aborted and addEventListener/removeEventListener qualifies.
+func isAbortSignal(obj *sobek.Object) bool {
+	if obj.Get("aborted") == nil {
+		return false
+	}
+	_, add := sobek.AssertFunction(obj.Get("addEventListener"))
+	_, remove := sobek.AssertFunction(obj.Get("removeEventListener"))
+	return add && remove
+}
+
+// stopOnAbort stops t when signal aborts, or right away if it already has.
+// It returns a function that removes the abort listener, which must run on
+// the event loop; see removeAbortListener.
+func stopOnAbort(runtime *sobek.Runtime, signal *sobek.Object, t *timer) func() {
+	if signal == nil {
+		return func() {}
+	}
+	if signal.Get("aborted").ToBoolean() {
+		t.stop()
+		return func() {}
+	}
+
+	listener := runtime.ToValue(func(call sobek.FunctionCall) sobek.Value {
+		t.stop()
+		return sobek.Undefined()
+	})
+	add, _ := sobek.AssertFunction(signal.Get("addEventListener"))
+	if _, err := add(signal, runtime.ToValue("abort"), listener); err != nil {
+		panic(err)
+	}
+	removed := false
+	return func() {
+		if removed {
+			return
+		}
+		removed = true
+		if remove, ok := sobek.AssertFunction(signal.Get("removeEventListener")); ok {
+			_, _ = remove(signal, runtime.ToValue("abort"), listener)
+		}
+	}
+}
+
+// removeAbortListener wraps the function returned by stopOnAbort as a job
+// for the event loop, to be enqueued once the timer has stopped, whether it
+// completed, was cleared or aborted
+func removeAbortListener(remove func()) func() error {
+	return func() error {
+		remove()
+		return nil
+	}
+}
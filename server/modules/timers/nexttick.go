@@ -0,0 +1,76 @@
+package timers
+
+import (
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// tick is a callback queued with process.nextTick
+type tick struct {
+	callback sobek.Callable
+	args     []sobek.Value
+}
+
+// setupProcess adds nextTick to the process global, creating it if needed
+func setupProcess(runtime *sobek.Runtime) {
+	var process *sobek.Object
+	if v := runtime.Get("process"); v != nil && !sobek.IsUndefined(v) && !sobek.IsNull(v) {
+		process = v.ToObject(runtime)
+	} else {
+		process = runtime.NewObject()
+		runtime.Set("process", process)
+	}
+
+	// process.nextTick(callback, ...args) - callbacks queued in the same turn
+	// run together in one microtask, scheduled by the first of them, so they
+	// run before promise reactions queued after that and before any timer.
+	// Unlike Node, reactions queued before the first of them still run first:
+	// the engine has no queue ahead of its microtasks to drain ticks from.
+	process.Set("nextTick", func(call sobek.FunctionCall) sobek.Value {
+		callback, ok := sobek.AssertFunction(call.Argument(0))
+		if !ok {
+			panic(vm.NewTypeError(runtime, "timers", vm.CodeInvalidArgument, "nextTick: first argument must be a function"))
+		}
+		var args []sobek.Value
+		if len(call.Arguments) > 1 {
+			// The arguments are only valid during this call
+			args = append([]sobek.Value(nil), call.Arguments[1:]...)
+		}
+
+		t := rtTimers(runtime)
+		t.ticks = append(t.ticks, tick{callback: callback, args: args})
+		if len(t.ticks) == 1 {
+			scheduleTicks(runtime, t)
+		}
+		return sobek.Undefined()
+	})
+}
+
+// scheduleTicks queues a microtask that runs the nextTick callbacks, including
+// those queued while it runs
+func scheduleTicks(runtime *sobek.Runtime, t *timers) {
+	vm.AddPending(runtime)
+	flush := func(sobek.FunctionCall) sobek.Value {
+		defer vm.RemovePending(runtime)
+		for len(t.ticks) > 0 {
+			// The running tick stays queued so ticks it adds join this flush
+			next := t.ticks[0]
+			_, err := next.callback(sobek.Undefined(), next.args...)
+			t.ticks = t.ticks[1:]
+			if err != nil {
+				// Drop the remaining callbacks and fail the run like a throwing timer
+				t.ticks = nil
+				vm.Post(runtime, func() error { return err })
+				break
+			}
+		}
+		return sobek.Undefined()
+	}
+
+	promise, resolve, _ := runtime.NewPromise()
+	then, _ := sobek.AssertFunction(runtime.ToValue(promise).ToObject(runtime).Get("then"))
+	if _, err := then(runtime.ToValue(promise), runtime.ToValue(flush)); err != nil {
+		panic(err)
+	}
+	_ = resolve(sobek.Undefined())
+}
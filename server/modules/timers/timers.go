@@ -1,6 +1,9 @@
 package timers
 
 import (
+	"errors"
+	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/grafana/sobek"
@@ -8,12 +11,31 @@ import (
 	"github.com/mark3labs/codebench-mcp/server/vm"
 )
 
-// TimersModule provides setTimeout, setInterval, clearTimeout, clearInterval
-type TimersModule struct{}
+// DefaultMaxTimers is the number of timers and intervals a VM may have active
+// at once unless configured otherwise
+const DefaultMaxTimers = 10000
+
+// errTooManyTimers is returned when a VM already has its maximum of active timers
+var errTooManyTimers = errors.New("too many active timers")
+
+// TimersModule provides setTimeout, setInterval, clearTimeout, clearInterval,
+// performance.now and process.nextTick
+type TimersModule struct {
+	maxTimers int
+}
 
 // NewTimersModule creates a new timers module
 func NewTimersModule() *TimersModule {
-	return &TimersModule{}
+	return NewTimersModuleWithLimit(0)
+}
+
+// NewTimersModuleWithLimit creates a timers module allowing at most maxTimers
+// active timers and intervals per VM. Zero or less uses DefaultMaxTimers.
+func NewTimersModuleWithLimit(maxTimers int) *TimersModule {
+	if maxTimers <= 0 {
+		maxTimers = DefaultMaxTimers
+	}
+	return &TimersModule{maxTimers: maxTimers}
 }
 
 // Name returns the module name
@@ -24,14 +46,16 @@ func (t *TimersModule) Name() string {
 // Setup initializes the timers module in the VM
 func (t *TimersModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) error {
 	logger.Debug("Setting up timers module")
+	rtTimers(runtime).max = t.maxTimers
 	
-	// setTimeout - standard implementation
+	// setTimeout - standard implementation. With setTimeout(fn, ms, { signal })
+	// aborting the signal clears the timeout.
 	runtime.Set("setTimeout", func(call sobek.FunctionCall) sobek.Value {
 		logger.Debug("setTimeout called", "args", len(call.Arguments))
 		
 		callback, ok := sobek.AssertFunction(call.Argument(0))
 		if !ok {
-			panic(runtime.NewTypeError("setTimeout: first argument must be a function"))
+			panic(vm.NewTypeError(runtime, "timers", vm.CodeInvalidArgument, "setTimeout: first argument must be a function"))
 		}
 
 		i := call.Argument(1).ToInteger()
@@ -45,18 +69,24 @@ func (t *TimersModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) erro
 		if len(call.Arguments) > 2 {
 			args = call.Arguments[2:]
 		}
+		args, signal := timerSignal(runtime, args)
 
+		logger.Debug("Creating timer")
+		t, err := rtTimers(runtime).new(delay, false)
+		if err != nil {
+			panic(vm.NewError(runtime, "timers", vm.CodeQuotaExceeded, fmt.Errorf("setTimeout: %w", err)))
+		}
 		logger.Debug("Getting enqueue function")
 		enqueue := vm.EnqueueJob(runtime)
-		logger.Debug("Creating timer")
-		t := rtTimers(runtime).new(delay, false)
 		logger.Debug("Timer created", "id", t.id)
 		vm.Cleanup(runtime, t.stop)
 		vm.AddPending(runtime) // Track this timer as a pending operation
+		removeListener := stopOnAbort(runtime, signal, t)
 		
 		task := func() error {
 			logger.Debug("Timer task executing", "id", t.id)
 			defer t.stop()
+			removeListener()
 			defer vm.RemovePending(runtime) // Remove pending operation when timer completes
 			_, err := callback(sobek.Undefined(), args...)
 			logger.Debug("Timer task completed", "id", t.id, "error", err)
@@ -72,10 +102,10 @@ func (t *TimersModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) erro
 				enqueue(task)
 				logger.Debug("Task enqueued", "id", t.id)
 			case <-t.done:
-				logger.Debug("Timer cancelled, enqueueing nothing", "id", t.id)
+				logger.Debug("Timer cancelled, removing abort listener", "id", t.id)
 				vm.RemovePending(runtime) // Remove pending operation when timer is cancelled
-				enqueue(nothing)
-				logger.Debug("Nothing enqueued", "id", t.id)
+				enqueue(removeAbortListener(removeListener))
+				logger.Debug("Abort listener removal enqueued", "id", t.id)
 			}
 			logger.Debug("Timer goroutine finished", "id", t.id)
 		}()
@@ -92,13 +122,17 @@ func (t *TimersModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) erro
 		return sobek.Undefined()
 	})
 
-	// setInterval - standard implementation
+	// setInterval - standard implementation, also accepting { signal } like
+	// setTimeout
+	// Ticks that fire while the previous callback is still queued or running
+	// are skipped rather than queued, so a slow callback never builds a backlog
+	// and the interval does not drift further behind under load.
 	runtime.Set("setInterval", func(call sobek.FunctionCall) sobek.Value {
 		logger.Debug("setInterval called", "args", len(call.Arguments))
 		
 		callback, ok := sobek.AssertFunction(call.Argument(0))
 		if !ok {
-			panic(runtime.NewTypeError("setInterval: first argument must be a function"))
+			panic(vm.NewTypeError(runtime, "timers", vm.CodeInvalidArgument, "setInterval: first argument must be a function"))
 		}
 
 		i := call.Argument(1).ToInteger()
@@ -112,12 +146,27 @@ func (t *TimersModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) erro
 		if len(call.Arguments) > 2 {
 			args = call.Arguments[2:]
 		}
+		args, signal := timerSignal(runtime, args)
 
+		t, err := rtTimers(runtime).new(delay, true)
+		if err != nil {
+			panic(vm.NewError(runtime, "timers", vm.CodeQuotaExceeded, fmt.Errorf("setInterval: %w", err)))
+		}
 		enqueue := vm.EnqueueJob(runtime)
-		t := rtTimers(runtime).new(delay, true)
 		vm.Cleanup(runtime, t.stop)
-		vm.AddPending(runtime) // Track this interval as a pending operation
+		// Track this interval as a pending operation, which the loop may stop
+		// once nothing else is left to wait for
+		removePending := vm.AddRepeating(runtime, t.stop)
+		removeListener := stopOnAbort(runtime, signal, t)
+		var inFlight atomic.Bool
 		task := func() error { 
+			defer inFlight.Store(false)
+			select {
+			case <-t.done:
+				// Cleared while this tick was queued
+				return nil
+			default:
+			}
 			logger.Debug("Interval task executing", "id", t.id)
 			_, err := callback(sobek.Undefined(), args...)
 			logger.Debug("Interval task completed", "id", t.id, "error", err)
@@ -130,14 +179,18 @@ func (t *TimersModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) erro
 			for {
 				select {
 				case <-t.timer:
+					if !inFlight.CompareAndSwap(false, true) {
+						logger.Debug("Interval fired while previous tick in flight, skipping", "id", t.id)
+						continue
+					}
 					logger.Debug("Interval fired, enqueueing task", "id", t.id)
 					enqueue(task)
 					logger.Debug("Interval task enqueued, getting new enqueue", "id", t.id)
 					enqueue = vm.EnqueueJob(runtime)
 				case <-t.done:
-					logger.Debug("Interval cancelled, enqueueing nothing", "id", t.id)
-					vm.RemovePending(runtime) // Remove pending operation when interval is cancelled
-					enqueue(nothing)
+					logger.Debug("Interval cancelled, removing abort listener", "id", t.id)
+					removePending() // Remove pending operation when interval is cancelled
+					enqueue(removeAbortListener(removeListener))
 					logger.Debug("Interval goroutine finished", "id", t.id)
 					return
 				}
@@ -155,6 +208,18 @@ func (t *TimersModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) erro
 		return sobek.Undefined()
 	})
 
+	// performance - high resolution time relative to VM creation, measured
+	// on Go's monotonic clock so it never goes backwards
+	origin := time.Now()
+	performance := runtime.NewObject()
+	performance.Set("timeOrigin", float64(origin.UnixNano())/float64(time.Millisecond))
+	performance.Set("now", func(call sobek.FunctionCall) sobek.Value {
+		return runtime.ToValue(float64(time.Since(origin)) / float64(time.Millisecond))
+	})
+	runtime.Set("performance", performance)
+
+	setupProcess(runtime)
+
 	logger.Debug("Timers module setup complete")
 	return nil
 }
@@ -198,9 +263,14 @@ func (t *timer) stop() {
 type timers struct {
 	id    int64
 	timer map[int64]*timer
+	max   int    // Maximum number of active timers, 0 for no limit
+	ticks []tick // Callbacks queued with process.nextTick
 }
 
-func (t *timers) new(delay time.Duration, repeat bool) *timer {
+func (t *timers) new(delay time.Duration, repeat bool) (*timer, error) {
+	if t.max > 0 && len(t.timer) >= t.max {
+		return nil, fmt.Errorf("%w (limit %d)", errTooManyTimers, t.max)
+	}
 	t.id++
 	id := t.id
 	logger.Debug("Creating new timer", "id", id, "delay", delay, "repeat", repeat)
@@ -228,7 +298,7 @@ func (t *timers) new(delay time.Duration, repeat bool) *timer {
 	}
 	t.timer[id] = n
 	logger.Debug("Timer created and stored", "id", id)
-	return n
+	return n, nil
 }
 
 func (t *timers) stop(id int64) {
@@ -254,8 +324,3 @@ func rtTimers(rt *sobek.Runtime) *timers {
 	logger.Debug("Using existing timers instance")
 	return v.Export().(*timers)
 }
-
-func nothing() error { 
-	logger.Debug("Nothing function called")
-	return nil 
-}
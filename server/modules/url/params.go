@@ -0,0 +1,110 @@
+package url
+
+import (
+	"net/url"
+	"strings"
+)
+
+// param is one name-value pair of a query
+type param struct {
+	name, value string
+}
+
+// searchParams is the list of name-value pairs behind a URLSearchParams.
+// Unlike url.Values it keeps the pairs in the order they were added, so
+// mutating them never reorders the rest of a query, e.g. of a signed URL.
+type searchParams struct {
+	pairs []param
+}
+
+// parseSearchParams parses an application/x-www-form-urlencoded query,
+// keeping pairs that don't decode as they are
+func parseSearchParams(query string) *searchParams {
+	p := &searchParams{}
+	for _, part := range strings.Split(query, "&") {
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		p.add(unescape(name), unescape(value))
+	}
+	return p
+}
+
+// unescape decodes a query component, or returns it as is if it is invalid
+func unescape(s string) string {
+	if decoded, err := url.QueryUnescape(s); err == nil {
+		return decoded
+	}
+	return s
+}
+
+// add appends a pair
+func (p *searchParams) add(name, value string) {
+	p.pairs = append(p.pairs, param{name: name, value: value})
+}
+
+// del removes every pair with name
+func (p *searchParams) del(name string) {
+	kept := p.pairs[:0]
+	for _, pair := range p.pairs {
+		if pair.name != name {
+			kept = append(kept, pair)
+		}
+	}
+	p.pairs = kept
+}
+
+// get returns the value of the first pair with name
+func (p *searchParams) get(name string) (string, bool) {
+	for _, pair := range p.pairs {
+		if pair.name == name {
+			return pair.value, true
+		}
+	}
+	return "", false
+}
+
+// getAll returns the values of the pairs with name, in order
+func (p *searchParams) getAll(name string) []string {
+	values := []string{}
+	for _, pair := range p.pairs {
+		if pair.name == name {
+			values = append(values, pair.value)
+		}
+	}
+	return values
+}
+
+// set replaces the value of the first pair with name and removes the others,
+// or appends a pair if there is none
+func (p *searchParams) set(name, value string) {
+	kept := p.pairs[:0]
+	found := false
+	for _, pair := range p.pairs {
+		if pair.name != name {
+			kept = append(kept, pair)
+		} else if !found {
+			found = true
+			kept = append(kept, param{name: name, value: value})
+		}
+	}
+	p.pairs = kept
+	if !found {
+		p.add(name, value)
+	}
+}
+
+// encode serializes the pairs in order
+func (p *searchParams) encode() string {
+	var b strings.Builder
+	for i, pair := range p.pairs {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(url.QueryEscape(pair.name))
+		b.WriteByte('=')
+		b.WriteString(url.QueryEscape(pair.value))
+	}
+	return b.String()
+}
@@ -1,11 +1,13 @@
 package url
 
 import (
+	"net"
 	"net/url"
 	"strings"
 
 	"github.com/grafana/sobek"
 	"github.com/mark3labs/codebench-mcp/server/vm"
+	"golang.org/x/net/idna"
 )
 
 // URLModule provides URL and URLSearchParams
@@ -28,7 +30,7 @@ func (u *URLModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) error {
 		obj := call.This
 
 		if len(call.Arguments) == 0 {
-			panic(runtime.NewTypeError("URL constructor requires a URL string"))
+			panic(vm.NewTypeError(runtime, "url", vm.CodeInvalidArgument, "URL constructor requires a URL string"))
 		}
 
 		urlStr := call.Argument(0).String()
@@ -44,7 +46,7 @@ func (u *URLModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) error {
 		if baseURL != "" {
 			base, err := url.Parse(baseURL)
 			if err != nil {
-				panic(runtime.NewTypeError("Invalid base URL: " + err.Error()))
+				panic(vm.NewTypeError(runtime, "url", vm.CodeInvalidArgument, "Invalid base URL: "+err.Error()))
 			}
 			parsedURL, err = base.Parse(urlStr)
 		} else {
@@ -52,7 +54,23 @@ func (u *URLModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) error {
 		}
 
 		if err != nil {
-			panic(runtime.NewTypeError("Invalid URL: " + err.Error()))
+			panic(vm.NewTypeError(runtime, "url", vm.CodeInvalidArgument, "Invalid URL: "+err.Error()))
+		}
+
+		// International domain names are exposed in their ASCII (punycode)
+		// form, as fetch and the web platform use them
+		unicodeHostname := parsedURL.Hostname()
+		if hostname := parsedURL.Hostname(); !isASCII(hostname) {
+			ascii, err := idna.Lookup.ToASCII(hostname)
+			if err != nil {
+				panic(vm.NewTypeError(runtime, "url", vm.CodeInvalidArgument, "Invalid URL: "+err.Error()))
+			}
+			if port := parsedURL.Port(); port != "" {
+				ascii = net.JoinHostPort(ascii, port)
+			}
+			parsedURL.Host = ascii
+		} else if unicode, err := idna.Punycode.ToUnicode(hostname); err == nil {
+			unicodeHostname = unicode
 		}
 
 		// Set properties
@@ -61,12 +79,15 @@ func (u *URLModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) error {
 		obj.Set("hostname", parsedURL.Hostname())
 		obj.Set("port", parsedURL.Port())
 		obj.Set("pathname", parsedURL.Path)
-		obj.Set("search", func() string {
+		setSearch := func() {
+			obj.Set("href", parsedURL.String())
 			if parsedURL.RawQuery != "" {
-				return "?" + parsedURL.RawQuery
+				obj.Set("search", "?"+parsedURL.RawQuery)
+			} else {
+				obj.Set("search", "")
 			}
-			return ""
-		}())
+		}
+		setSearch()
 		obj.Set("hash", func() string {
 			if parsedURL.Fragment != "" {
 				return "#" + parsedURL.Fragment
@@ -74,10 +95,15 @@ func (u *URLModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) error {
 			return ""
 		}())
 		obj.Set("host", parsedURL.Host)
+		obj.Set("unicodeHostname", unicodeHostname)
 		obj.Set("origin", parsedURL.Scheme+"://"+parsedURL.Host)
 
-		// searchParams property
-		searchParams := u.createURLSearchParams(runtime, parsedURL.Query())
+		// searchParams property, whose mutations are written back to the
+		// query so href and search stay in sync
+		searchParams := u.createURLSearchParams(runtime, parseSearchParams(parsedURL.RawQuery), func(params *searchParams) {
+			parsedURL.RawQuery = params.encode()
+			setSearch()
+		})
 		obj.Set("searchParams", searchParams)
 
 		// toString method
@@ -92,45 +118,76 @@ func (u *URLModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) error {
 	runtime.Set("URLSearchParams", func(call sobek.ConstructorCall) *sobek.Object {
 		obj := call.This
 
-		params := url.Values{}
+		params := &searchParams{}
 
 		if len(call.Arguments) > 0 {
 			arg := call.Argument(0)
 			if !sobek.IsUndefined(arg) && !sobek.IsNull(arg) {
-				// Parse from string
 				if sobek.IsString(arg) {
-					queryStr := arg.String()
-					if strings.HasPrefix(queryStr, "?") {
-						queryStr = queryStr[1:]
-					}
-					parsed, err := url.ParseQuery(queryStr)
-					if err == nil {
-						params = parsed
+					// Parse from string
+					params = parseSearchParams(strings.TrimPrefix(arg.String(), "?"))
+				} else if init, ok := arg.(*sobek.Object); ok {
+					if init.ClassName() == "Array" {
+						// Sequence of [name, value] pairs
+						var pairs []sobek.Value
+						if err := runtime.ExportTo(init, &pairs); err != nil {
+							panic(vm.NewTypeError(runtime, "url", vm.CodeInvalidArgument, "URLSearchParams init must be a sequence of pairs"))
+						}
+						for _, pair := range pairs {
+							p, ok := pair.(*sobek.Object)
+							if !ok || p.ClassName() != "Array" || p.Get("length").ToInteger() != 2 {
+								panic(vm.NewTypeError(runtime, "url", vm.CodeInvalidArgument, "Each URLSearchParams pair must have exactly two items"))
+							}
+							params.add(p.Get("0").String(), p.Get("1").String())
+						}
+					} else {
+						// Record of names to values
+						for _, key := range init.Keys() {
+							params.add(key, init.Get(key).String())
+						}
 					}
 				}
 			}
 		}
 
-		return u.setupURLSearchParams(runtime, obj, params)
+		return u.setupURLSearchParams(runtime, obj, params, nil)
 	})
 
 	return nil
 }
 
+// isASCII reports whether s contains only ASCII characters
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
 // createURLSearchParams creates a URLSearchParams object
-func (u *URLModule) createURLSearchParams(runtime *sobek.Runtime, params url.Values) sobek.Value {
+func (u *URLModule) createURLSearchParams(runtime *sobek.Runtime, params *searchParams, onChange func(*searchParams)) sobek.Value {
 	obj := runtime.NewObject()
-	return u.setupURLSearchParams(runtime, obj, params)
+	return u.setupURLSearchParams(runtime, obj, params, onChange)
 }
 
-// setupURLSearchParams sets up URLSearchParams methods
-func (u *URLModule) setupURLSearchParams(runtime *sobek.Runtime, obj *sobek.Object, params url.Values) *sobek.Object {
+// setupURLSearchParams sets up URLSearchParams methods. onChange, if set, is
+// called with the params after every mutation.
+func (u *URLModule) setupURLSearchParams(runtime *sobek.Runtime, obj *sobek.Object, params *searchParams, onChange func(*searchParams)) *sobek.Object {
+	changed := func() {
+		if onChange != nil {
+			onChange(params)
+		}
+	}
+
 	// append method
 	obj.Set("append", func(call sobek.FunctionCall) sobek.Value {
 		if len(call.Arguments) >= 2 {
 			key := call.Argument(0).String()
 			value := call.Argument(1).String()
-			params.Add(key, value)
+			params.add(key, value)
+			changed()
 		}
 		return sobek.Undefined()
 	})
@@ -139,7 +196,8 @@ func (u *URLModule) setupURLSearchParams(runtime *sobek.Runtime, obj *sobek.Obje
 	obj.Set("delete", func(call sobek.FunctionCall) sobek.Value {
 		if len(call.Arguments) >= 1 {
 			key := call.Argument(0).String()
-			params.Del(key)
+			params.del(key)
+			changed()
 		}
 		return sobek.Undefined()
 	})
@@ -148,8 +206,7 @@ func (u *URLModule) setupURLSearchParams(runtime *sobek.Runtime, obj *sobek.Obje
 	obj.Set("get", func(call sobek.FunctionCall) sobek.Value {
 		if len(call.Arguments) >= 1 {
 			key := call.Argument(0).String()
-			value := params.Get(key)
-			if value != "" {
+			if value, ok := params.get(key); ok {
 				return runtime.ToValue(value)
 			}
 		}
@@ -160,8 +217,7 @@ func (u *URLModule) setupURLSearchParams(runtime *sobek.Runtime, obj *sobek.Obje
 	obj.Set("getAll", func(call sobek.FunctionCall) sobek.Value {
 		if len(call.Arguments) >= 1 {
 			key := call.Argument(0).String()
-			values := params[key]
-			return runtime.ToValue(values)
+			return runtime.ToValue(params.getAll(key))
 		}
 		return runtime.ToValue([]string{})
 	})
@@ -170,7 +226,8 @@ func (u *URLModule) setupURLSearchParams(runtime *sobek.Runtime, obj *sobek.Obje
 	obj.Set("has", func(call sobek.FunctionCall) sobek.Value {
 		if len(call.Arguments) >= 1 {
 			key := call.Argument(0).String()
-			return runtime.ToValue(params.Has(key))
+			_, ok := params.get(key)
+			return runtime.ToValue(ok)
 		}
 		return runtime.ToValue(false)
 	})
@@ -180,30 +237,31 @@ func (u *URLModule) setupURLSearchParams(runtime *sobek.Runtime, obj *sobek.Obje
 		if len(call.Arguments) >= 2 {
 			key := call.Argument(0).String()
 			value := call.Argument(1).String()
-			params.Set(key, value)
+			params.set(key, value)
+			changed()
 		}
 		return sobek.Undefined()
 	})
 
 	// toString method
 	obj.Set("toString", func(call sobek.FunctionCall) sobek.Value {
-		return runtime.ToValue(params.Encode())
+		return runtime.ToValue(params.encode())
 	})
 
-	// keys method
+	// keys method - the name of every pair, in order
 	obj.Set("keys", func(call sobek.FunctionCall) sobek.Value {
-		var keys []string
-		for key := range params {
-			keys = append(keys, key)
+		keys := make([]string, 0, len(params.pairs))
+		for _, pair := range params.pairs {
+			keys = append(keys, pair.name)
 		}
 		return runtime.ToValue(keys)
 	})
 
-	// values method
+	// values method - the value of every pair, in order
 	obj.Set("values", func(call sobek.FunctionCall) sobek.Value {
-		var values []string
-		for _, vals := range params {
-			values = append(values, vals...)
+		values := make([]string, 0, len(params.pairs))
+		for _, pair := range params.pairs {
+			values = append(values, pair.value)
 		}
 		return runtime.ToValue(values)
 	})
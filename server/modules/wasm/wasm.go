@@ -0,0 +1,253 @@
+package wasm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/modules/internal/view"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// WASMModule provides the WebAssembly global, running modules with wazero.
+// It is not enabled by default since each compiled module carries its own
+// wazero runtime.
+type WASMModule struct{}
+
+// NewWASMModule creates a new wasm module
+func NewWASMModule() *WASMModule {
+	return &WASMModule{}
+}
+
+// Name returns the module name
+func (w *WASMModule) Name() string {
+	return "wasm"
+}
+
+// wasmModule is a compiled WebAssembly.Module, exported to JS as an opaque object
+type wasmModule struct {
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+}
+
+// Setup initializes the WebAssembly global in the VM
+func (w *WASMModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) error {
+	webAssembly := runtime.NewObject()
+
+	// validate(bytes) - reports whether bytes is a valid WebAssembly module
+	webAssembly.Set("validate", func(call sobek.FunctionCall) sobek.Value {
+		code := toBytes(runtime, call.Argument(0), "validate")
+		ctx := context.Background()
+		r := wazero.NewRuntime(ctx)
+		defer r.Close(ctx)
+		_, err := r.CompileModule(ctx, code)
+		return runtime.ToValue(err == nil)
+	})
+
+	// compile(bytes) - returns a promise resolving to a WebAssembly.Module
+	webAssembly.Set("compile", func(call sobek.FunctionCall) sobek.Value {
+		code := toBytes(runtime, call.Argument(0), "compile")
+		r, ctx := newRuntime(runtime)
+		promise, resolve, reject := runtime.NewPromise()
+		enqueue := vm.EnqueueJob(runtime)
+		go func() {
+			mod, err := compile(ctx, r, code)
+			enqueue(func() error {
+				if err != nil {
+					return reject(vm.NewError(runtime, "wasm", vm.CodeOperationFailed, err))
+				}
+				return resolve(runtime.ToValue(mod))
+			})
+		}()
+		return runtime.ToValue(promise)
+	})
+
+	// instantiate(bytes) - returns a promise resolving to { module, instance };
+	// instantiate(module) - returns a promise resolving to the instance.
+	// Imports are not supported, so modules importing anything fail to instantiate.
+	webAssembly.Set("instantiate", func(call sobek.FunctionCall) sobek.Value {
+		mod, isModule := call.Argument(0).Export().(*wasmModule)
+		var code []byte
+		var r wazero.Runtime
+		ctx := vm.Context(runtime)
+		if !isModule {
+			code = toBytes(runtime, call.Argument(0), "instantiate")
+			r, ctx = newRuntime(runtime)
+		}
+
+		promise, resolve, reject := runtime.NewPromise()
+		enqueue := vm.EnqueueJob(runtime)
+		go func() {
+			var err error
+			if !isModule {
+				mod, err = compile(ctx, r, code)
+			}
+			var instance api.Module
+			if err == nil {
+				// Anonymous, so a module can be instantiated more than once
+				instance, err = mod.runtime.InstantiateModule(ctx, mod.compiled, wazero.NewModuleConfig().WithName(""))
+			}
+			enqueue(func() error {
+				if err != nil {
+					return reject(vm.NewError(runtime, "wasm", vm.CodeOperationFailed, err))
+				}
+				instanceObj := newInstance(runtime, mod.compiled, instance)
+				if isModule {
+					return resolve(instanceObj)
+				}
+				result := runtime.NewObject()
+				result.Set("module", mod)
+				result.Set("instance", instanceObj)
+				return resolve(result)
+			})
+		}()
+		return runtime.ToValue(promise)
+	})
+
+	runtime.Set("WebAssembly", webAssembly)
+	return nil
+}
+
+// newRuntime creates a wazero runtime for compiling a module, which is closed
+// when the VM's run finishes and stops running code once the run's context is
+// done. It returns the runtime with that context.
+func newRuntime(runtime *sobek.Runtime) (wazero.Runtime, context.Context) {
+	ctx := vm.Context(runtime)
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true))
+	vm.Cleanup(runtime, func() {
+		_ = r.Close(context.Background())
+	})
+	return r, ctx
+}
+
+// compile compiles code in r
+func compile(ctx context.Context, r wazero.Runtime, code []byte) (*wasmModule, error) {
+	compiled, err := r.CompileModule(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return &wasmModule{runtime: r, compiled: compiled}, nil
+}
+
+// newInstance creates the JS instance object, with an exports object holding
+// the exported functions and memories
+func newInstance(runtime *sobek.Runtime, compiled wazero.CompiledModule, instance api.Module) *sobek.Object {
+	exports := runtime.NewObject()
+	for name, def := range compiled.ExportedFunctions() {
+		exports.Set(name, exportedFunction(runtime, instance.ExportedFunction(name), def))
+	}
+	for name := range compiled.ExportedMemories() {
+		memory := instance.ExportedMemory(name)
+		memoryObj := runtime.NewObject()
+		// buffer reflects the memory as it is now, so re-read it after growing
+		getter := runtime.ToValue(func(call sobek.FunctionCall) sobek.Value {
+			data, _ := memory.Read(0, memory.Size())
+			return runtime.ToValue(runtime.NewArrayBuffer(data))
+		})
+		_ = memoryObj.DefineAccessorProperty("buffer", getter, nil, sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+		exports.Set(name, memoryObj)
+	}
+
+	instanceObj := runtime.NewObject()
+	instanceObj.Set("exports", exports)
+	return instanceObj
+}
+
+// exportedFunction wraps an exported WebAssembly function, converting its
+// arguments and results between JS numbers and WebAssembly values
+func exportedFunction(runtime *sobek.Runtime, fn api.Function, def api.FunctionDefinition) func(call sobek.FunctionCall) sobek.Value {
+	params := def.ParamTypes()
+	results := def.ResultTypes()
+	return func(call sobek.FunctionCall) sobek.Value {
+		args := make([]uint64, len(params))
+		for i, typ := range params {
+			arg := call.Argument(i)
+			switch typ {
+			case api.ValueTypeI32:
+				args[i] = api.EncodeI32(int32(arg.ToInteger()))
+			case api.ValueTypeI64:
+				args[i] = api.EncodeI64(arg.ToInteger())
+			case api.ValueTypeF32:
+				args[i] = api.EncodeF32(float32(arg.ToFloat()))
+			case api.ValueTypeF64:
+				args[i] = api.EncodeF64(arg.ToFloat())
+			default:
+				panic(vm.NewTypeError(runtime, "wasm", vm.CodeNotSupported,
+					fmt.Sprintf("%s: parameter type %s is not supported", def.Name(), api.ValueTypeName(typ))))
+			}
+		}
+
+		ctx := vm.Context(runtime)
+		values, err := fn.Call(ctx, args...)
+		if err != nil {
+			if ctx.Err() != nil {
+				panic(vm.NewError(runtime, "wasm", vm.CodeAborted, err))
+			}
+			panic(vm.NewError(runtime, "wasm", vm.CodeOperationFailed, err))
+		}
+
+		converted := make([]any, len(values))
+		for i, value := range values {
+			switch results[i] {
+			case api.ValueTypeI32:
+				converted[i] = int64(api.DecodeI32(value))
+			case api.ValueTypeI64:
+				converted[i] = int64(value)
+			case api.ValueTypeF32:
+				converted[i] = float64(api.DecodeF32(value))
+			case api.ValueTypeF64:
+				converted[i] = api.DecodeF64(value)
+			default:
+				converted[i] = int64(value)
+			}
+		}
+		switch len(converted) {
+		case 0:
+			return sobek.Undefined()
+		case 1:
+			return runtime.ToValue(converted[0])
+		default:
+			return runtime.NewArray(converted...)
+		}
+	}
+}
+
+// toBytes returns the bytes of an ArrayBuffer, typed array or Buffer argument
+func toBytes(runtime *sobek.Runtime, value sobek.Value, method string) []byte {
+	if value != nil {
+		switch v := value.Export().(type) {
+		case []byte:
+			return v
+		case sobek.ArrayBuffer:
+			return v.Bytes()
+		}
+		if obj, ok := value.(*sobek.Object); ok {
+			// Buffer objects keep their bytes in __data__
+			if v := obj.Get("__data__"); v != nil {
+				if data, ok := v.Export().([]byte); ok {
+					return data
+				}
+			}
+			// Typed arrays and DataViews expose their underlying buffer
+			if data, ok := view.Bytes(runtime, "wasm", obj); ok {
+				return data
+			}
+		}
+	}
+	panic(vm.NewTypeError(runtime, "wasm", vm.CodeInvalidArgument,
+		method+": argument must be an ArrayBuffer, typed array or Buffer"))
+}
+
+// Cleanup performs any necessary cleanup
+func (w *WASMModule) Cleanup() error {
+	// Runtimes are closed when each VM's run finishes
+	return nil
+}
+
+// IsEnabled checks if the module should be enabled based on configuration
+func (w *WASMModule) IsEnabled(enabledModules map[string]bool) bool {
+	enabled, exists := enabledModules["wasm"]
+	return exists && enabled
+}
@@ -0,0 +1,292 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+)
+
+// WorkerModule provides the Worker global, which runs code in a sibling VM
+// on its own goroutine
+type WorkerModule struct{}
+
+// NewWorkerModule creates a new worker module
+func NewWorkerModule() *WorkerModule {
+	return &WorkerModule{}
+}
+
+// Name returns the module name
+func (w *WorkerModule) Name() string {
+	return "worker"
+}
+
+// worker relays messages between a Worker object and its sibling VM. The
+// worker runs its code, then each message it receives, as separate runs of
+// its event loop. The parent's event loop stays open while one of those runs
+// is outstanding, so an idle worker does not keep the parent running.
+type worker struct {
+	runtime *sobek.Runtime // The parent runtime
+	loop    *vm.EventLoop  // The parent event loop
+	obj     *sobek.Object  // The Worker object in the parent
+	child   *vm.VM
+
+	mu    sync.Mutex
+	inbox []string      // Serialized messages waiting for the worker
+	wake  chan struct{} // Signals the worker goroutine that inbox has messages
+	done  chan struct{} // Closed once the worker is terminated
+	once  sync.Once
+
+	// Only used on the parent event loop
+	outstanding int  // Worker runs the parent loop is waiting for
+	terminated  bool // Set once terminate is called or the parent run finishes
+}
+
+// Setup initializes the worker module in the VM
+func (w *WorkerModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) error {
+	// new Worker(code) - runs code in a new VM, where postMessage(data) sends
+	// data to the Worker's onmessage and onmessage receives what the Worker's
+	// postMessage sends. Data is passed as JSON.
+	runtime.Set("Worker", func(call sobek.ConstructorCall) *sobek.Object {
+		code := call.Argument(0)
+		if !sobek.IsString(code) {
+			panic(vm.NewTypeError(runtime, "worker", vm.CodeInvalidArgument, "Worker: first argument must be a string of code"))
+		}
+		child, err := manager.CreateVM(vm.Context(runtime))
+		if err != nil {
+			panic(vm.NewError(runtime, "worker", vm.CodeOperationFailed, err))
+		}
+
+		wk := &worker{
+			runtime: runtime,
+			loop:    vm.Loop(runtime),
+			obj:     call.This,
+			child:   child,
+			wake:    make(chan struct{}, 1),
+			done:    make(chan struct{}),
+		}
+		wk.setupChild()
+
+		obj := call.This
+		obj.Set("onmessage", sobek.Null())
+		obj.Set("onerror", sobek.Null())
+		obj.Set("postMessage", func(call sobek.FunctionCall) sobek.Value {
+			wk.postMessage(serialize(runtime, call.Argument(0)))
+			return sobek.Undefined()
+		})
+		obj.Set("terminate", func(call sobek.FunctionCall) sobek.Value {
+			wk.terminate()
+			return sobek.Undefined()
+		})
+
+		// Workers don't outlive the run that created them
+		vm.Cleanup(runtime, func() {
+			wk.terminated = true
+			wk.close()
+		})
+
+		wk.begin()
+		go wk.run(code.String())
+		return nil
+	})
+	return nil
+}
+
+// setupChild defines the worker's global scope: self, postMessage and a
+// console writing to the parent's console
+func (w *worker) setupChild() {
+	rt := w.child.Runtime()
+	rt.Set("self", rt.GlobalObject())
+
+	rt.Set("postMessage", func(call sobek.FunctionCall) sobek.Value {
+		data := serialize(rt, call.Argument(0))
+		w.loop.Post(func() error {
+			if w.terminated {
+				return nil
+			}
+			handler, ok := sobek.AssertFunction(w.obj.Get("onmessage"))
+			if !ok {
+				return nil
+			}
+			_, err := handler(w.obj, messageEvent(w.runtime, data))
+			return err
+		})
+		return sobek.Undefined()
+	})
+
+	console := rt.NewObject()
+	for _, method := range []string{"log", "info", "warn", "error", "debug"} {
+		console.Set(method, func(call sobek.FunctionCall) sobek.Value {
+			parts := make([]string, len(call.Arguments))
+			for i, arg := range call.Arguments {
+				parts[i] = fmt.Sprintf("%v", arg.Export())
+			}
+			message := strings.Join(parts, " ")
+			w.loop.Post(func() error {
+				parentConsole := w.runtime.Get("console")
+				if w.terminated || parentConsole == nil || sobek.IsUndefined(parentConsole) {
+					return nil
+				}
+				if log, ok := sobek.AssertFunction(parentConsole.ToObject(w.runtime).Get(method)); ok {
+					_, err := log(parentConsole, w.runtime.ToValue(message))
+					return err
+				}
+				return nil
+			})
+			return sobek.Undefined()
+		})
+	}
+	rt.Set("console", console)
+}
+
+// run executes the worker's code and then the messages sent to it, until the
+// worker is terminated
+func (w *worker) run(code string) {
+	_, err := w.child.RunString(code)
+	w.finish(err)
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.wake:
+		}
+		for {
+			w.mu.Lock()
+			if len(w.inbox) == 0 {
+				w.mu.Unlock()
+				break
+			}
+			data := w.inbox[0]
+			w.inbox = w.inbox[1:]
+			w.mu.Unlock()
+
+			w.finish(w.child.Run(func() error {
+				rt := w.child.Runtime()
+				handler, ok := sobek.AssertFunction(rt.Get("onmessage"))
+				if !ok {
+					return nil
+				}
+				_, err := handler(rt.GlobalObject(), messageEvent(rt, data))
+				return err
+			}))
+		}
+	}
+}
+
+// begin makes the parent event loop wait for one more worker run
+func (w *worker) begin() {
+	w.outstanding++
+	w.loop.AddPending()
+}
+
+// finish reports the end of a worker run to the parent event loop. An error
+// goes to the Worker's onerror, or fails the parent run if there is none.
+func (w *worker) finish(err error) {
+	var message string
+	if err != nil {
+		message = err.Error()
+		var ex *sobek.Exception
+		if errors.As(err, &ex) {
+			message = ex.Value().String()
+		}
+	}
+
+	w.loop.Post(func() error {
+		if w.terminated {
+			return nil
+		}
+		w.outstanding--
+		w.loop.RemovePending()
+		if err == nil {
+			return nil
+		}
+
+		if onerror, ok := sobek.AssertFunction(w.obj.Get("onerror")); ok {
+			event := w.runtime.NewObject()
+			event.Set("message", message)
+			_, err := onerror(w.obj, event)
+			return err
+		}
+		return fmt.Errorf("worker: %s", message)
+	})
+}
+
+// postMessage queues serialized data for the worker
+func (w *worker) postMessage(data string) {
+	if w.terminated {
+		return
+	}
+	w.begin()
+	w.mu.Lock()
+	w.inbox = append(w.inbox, data)
+	w.mu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// terminate stops the worker, dropping its queued messages, and releases the
+// parent event loop
+func (w *worker) terminate() {
+	if w.terminated {
+		return
+	}
+	w.terminated = true
+	for ; w.outstanding > 0; w.outstanding-- {
+		w.loop.RemovePending()
+	}
+	w.close()
+}
+
+// close stops the worker goroutine and closes the worker VM
+func (w *worker) close() {
+	w.once.Do(func() {
+		close(w.done)
+		w.child.Close()
+	})
+}
+
+// serialize converts a message to JSON, or "" for undefined
+func serialize(rt *sobek.Runtime, value sobek.Value) string {
+	if sobek.IsUndefined(value) {
+		return ""
+	}
+	stringify, _ := sobek.AssertFunction(rt.Get("JSON").ToObject(rt).Get("stringify"))
+	data, err := stringify(sobek.Undefined(), value)
+	if err != nil || sobek.IsUndefined(data) {
+		panic(vm.NewNamedError(rt, "worker", vm.CodeDataClone, "DataCloneError", "postMessage: data must be JSON-serializable"))
+	}
+	return data.String()
+}
+
+// messageEvent creates the event passed to onmessage, with the message
+// parsed into rt as its data
+func messageEvent(rt *sobek.Runtime, data string) *sobek.Object {
+	value := sobek.Undefined()
+	if data != "" {
+		parse, _ := sobek.AssertFunction(rt.Get("JSON").ToObject(rt).Get("parse"))
+		if parsed, err := parse(sobek.Undefined(), rt.ToValue(data)); err == nil {
+			value = parsed
+		}
+	}
+	event := rt.NewObject()
+	event.Set("data", value)
+	return event
+}
+
+// Cleanup performs any necessary cleanup
+func (w *WorkerModule) Cleanup() error {
+	// Workers are closed when the run that created them finishes
+	return nil
+}
+
+// IsEnabled checks if the module should be enabled based on configuration
+func (w *WorkerModule) IsEnabled(enabledModules map[string]bool) bool {
+	enabled, exists := enabledModules["worker"]
+	return exists && enabled
+}
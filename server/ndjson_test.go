@@ -0,0 +1,54 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNDJSON_ParseAndStringify(t *testing.T) {
+	handler := NewJSHandler()
+
+	text := runCode(t, handler, `
+		const ndjson = require('ndjson');
+		const events = ndjson.parse('{"id":1,"level":"info"}\n\n{"id":2,"level":"error","tags":["db"]}\r\n{"id":3}');
+		console.log("count:", events.length);
+		console.log("levels:", events.map((e) => e.level).join(","));
+		console.log("tags:", events[1].tags[0]);
+		console.log("array:", Array.isArray(events));
+		console.log("stringify:", JSON.stringify(ndjson.stringify([{ a: 1 }, "b", null])));
+		try {
+			ndjson.parse('{"ok":true}\n{broken');
+		} catch (e) {
+			console.log("error:", e.name, e.message.includes("line 2"));
+		}
+
+		// A source whose chunks split lines, as a streamed body would
+		const chunks = ['{"n":1}\n{"n"', ':2}\n', new TextEncoder().encode('{"n":3}')];
+		const source = {
+			getReader() {
+				return {
+					read: () => Promise.resolve(chunks.length ? { value: chunks.shift(), done: false } : { value: undefined, done: true }),
+				};
+			},
+		};
+		const reader = ndjson.parseStream(source).getReader();
+		const streamed = [];
+		const pump = () => reader.read().then(({ value, done }) => {
+			if (done) {
+				console.log("streamed:", streamed.join(","));
+				return;
+			}
+			streamed.push(value.n);
+			return pump();
+		});
+		pump();
+	`)
+	assert.Contains(t, text, "count: 3")
+	assert.Contains(t, text, "levels: info,error,")
+	assert.Contains(t, text, "tags: db")
+	assert.Contains(t, text, "array: true")
+	assert.Contains(t, text, `stringify: "{\"a\":1}\n\"b\"\nnull\n"`)
+	assert.Contains(t, text, "error: SyntaxError true")
+	assert.Contains(t, text, "streamed: 1,2,3")
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/codebench-mcp/internal/logger"
+)
+
+// moduleOptionKinds is the schema of ModuleConfig.ModuleOptions: the options
+// each module accepts and the kind of value they take
+var moduleOptionKinds = map[string]map[string]string{
+	"fetch": {
+		"timeout":         "duration",
+		"headers":         "headers",
+		"safeMethodsOnly": "bool",
+		"maxResponseSize": "int",
+	},
+	"timers": {
+		"maxTimers": "int",
+	},
+	"console": {
+		"maxOutputSize": "int",
+		"asResult":      "bool",
+	},
+}
+
+// moduleOptions are the validated options of one module
+type moduleOptions map[string]any
+
+// parseModuleOptions validates options against moduleOptionKinds, converting
+// each value to its kind. Unknown modules or options and invalid values are
+// logged and ignored, so a typo never prevents the server from starting.
+func parseModuleOptions(options map[string]any) map[string]moduleOptions {
+	parsed := make(map[string]moduleOptions)
+	for module, raw := range options {
+		kinds, ok := moduleOptionKinds[module]
+		if !ok {
+			logger.Warn("Ignoring options for a module without options", "module", module)
+			continue
+		}
+		values, ok := raw.(map[string]any)
+		if !ok {
+			logger.Warn("Ignoring module options that are not a map", "module", module)
+			continue
+		}
+		parsed[module] = make(moduleOptions)
+		for name, value := range values {
+			kind, ok := kinds[name]
+			if !ok {
+				logger.Warn("Ignoring unknown module option", "module", module, "option", name, "known", optionNames(kinds))
+				continue
+			}
+			converted, err := convertOption(kind, value)
+			if err != nil {
+				logger.Warn("Ignoring invalid module option", "module", module, "option", name, "error", err)
+				continue
+			}
+			parsed[module][name] = converted
+		}
+	}
+	return parsed
+}
+
+// optionNames returns the sorted option names of a module's schema
+func optionNames(kinds map[string]string) string {
+	names := make([]string, 0, len(kinds))
+	for name := range kinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// convertOption converts value to kind: a "duration" is a time.Duration, a
+// number of milliseconds or a string such as "10s"; an "int" is a
+// non-negative whole number; "headers" is a map of strings
+func convertOption(kind string, value any) (any, error) {
+	switch kind {
+	case "duration":
+		switch v := value.(type) {
+		case time.Duration:
+			if v < 0 {
+				return nil, fmt.Errorf("negative duration %s", v)
+			}
+			return v, nil
+		case string:
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, err
+			}
+			if d < 0 {
+				return nil, fmt.Errorf("negative duration %s", d)
+			}
+			return d, nil
+		}
+		ms, err := convertOption("int", value)
+		if err != nil {
+			return nil, err
+		}
+		return time.Duration(ms.(int)) * time.Millisecond, nil
+	case "int":
+		var n float64
+		switch v := value.(type) {
+		case int:
+			n = float64(v)
+		case int64:
+			n = float64(v)
+		case float64:
+			n = v
+		default:
+			return nil, fmt.Errorf("expected a number, got %T", value)
+		}
+		if n < 0 || n != math.Trunc(n) {
+			return nil, fmt.Errorf("expected a non-negative whole number, got %v", n)
+		}
+		return int(n), nil
+	case "bool":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected a boolean, got %T", value)
+		}
+		return b, nil
+	case "headers":
+		switch v := value.(type) {
+		case map[string]string:
+			return v, nil
+		case map[string]any:
+			headers := make(map[string]string, len(v))
+			for key, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("header %s: expected a string, got %T", key, item)
+				}
+				headers[key] = s
+			}
+			return headers, nil
+		}
+		return nil, fmt.Errorf("expected a map of strings, got %T", value)
+	}
+	return nil, fmt.Errorf("unknown option kind %s", kind)
+}
+
+// applyModuleOptions copies the module options over the equivalent
+// ModuleConfig fields, so the rest of the handler only reads the config
+func applyModuleOptions(config ModuleConfig, options map[string]moduleOptions) ModuleConfig {
+	if fetch, ok := options["fetch"]; ok {
+		if v, ok := fetch["timeout"]; ok {
+			config.FetchTimeout = v.(time.Duration)
+		}
+		if v, ok := fetch["headers"]; ok {
+			headers := make(map[string]string)
+			for key, value := range config.FetchHeaders {
+				headers[key] = value
+			}
+			for key, value := range v.(map[string]string) {
+				headers[key] = value
+			}
+			config.FetchHeaders = headers
+		}
+		if v, ok := fetch["safeMethodsOnly"]; ok {
+			config.FetchSafeMethodsOnly = v.(bool)
+		}
+		if v, ok := fetch["maxResponseSize"]; ok {
+			config.FetchMaxResponseSize = int64(v.(int))
+		}
+	}
+	if timers, ok := options["timers"]; ok {
+		if v, ok := timers["maxTimers"]; ok {
+			config.MaxTimers = v.(int)
+		}
+	}
+	if console, ok := options["console"]; ok {
+		if v, ok := console["maxOutputSize"]; ok {
+			config.MaxOutputSize = v.(int)
+		}
+		if v, ok := console["asResult"]; ok {
+			config.ConsoleAsResult = v.(bool)
+		}
+	}
+	return config
+}
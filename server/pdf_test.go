@@ -0,0 +1,36 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPDFModule_OnePageDocument(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const pdf = require('pdf');
+			const doc = pdf.create();
+			doc.setFont('Helvetica', 'B', 16);
+			doc.text('Quarterly report');
+			doc.text('Page footer', { x: 10, y: 280 });
+			const bytes = doc.render();
+			console.log("size > 0:", bytes.length > 0);
+			[0, 1, 2, 3, 4].map((i) => String.fromCharCode(bytes[i])).join('');
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "size > 0: true")
+	assert.Contains(t, text, "Result: %PDF-")
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/codebench-mcp/server/vm"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// DefaultSessionIdleTimeout is how long an unused persistent VM is kept
+const DefaultSessionIdleTimeout = 15 * time.Minute
+
+// persistentVM is a VM kept between executions, so globals defined by one
+// script are visible to the next
+type persistentVM struct {
+	mu       sync.Mutex // Held for the duration of an execution
+	vm       *vm.VM     // nil until the first execution, or after a discarded one
+	started  time.Time  // When the session was created
+	lastUsed time.Time  // When the last execution finished
+	removed  bool       // Set once deleted from persistentVMs, so it is never used again
+}
+
+// close closes the VM, waiting for a running execution to finish
+func (p *persistentVM) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.removed = true
+	if p.vm != nil {
+		p.vm.Close()
+		p.vm = nil
+	}
+}
+
+// vmKey identifies a persistent VM, either by the sessionId given to
+// executeJS or, with PersistentGlobals, by the MCP session
+type vmKey struct {
+	mcpSession string
+	sessionID  string
+}
+
+// acquireVM returns the VM for an execution and a function releasing it once
+// the execution is over. Without a sessionID or PersistentGlobals every
+// execution gets a fresh VM and global scope, so nothing a script defines
+// outlives it. Otherwise executions with the same key run one at a time in a
+// shared VM. Passing false to release discards a persistent VM, e.g. after a
+// timeout.
+func (h *JSHandler) acquireVM(ctx, execCtx context.Context, sessionID string) (*vm.VM, func(keep bool), error) {
+	var key vmKey
+	switch {
+	case sessionID != "":
+		key.sessionID = sessionID
+	case h.config.PersistentGlobals:
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			key.mcpSession = session.SessionID()
+		}
+	default:
+		v, err := h.vmManager.CreateVM(execCtx)
+		if err != nil {
+			return nil, nil, err
+		}
+		return v, func(bool) { v.Close() }, nil
+	}
+
+	now := time.Now()
+	h.expireIdleVMs(now)
+	var p *persistentVM
+	for {
+		entry, _ := h.persistentVMs.LoadOrStore(key, &persistentVM{started: now})
+		p = entry.(*persistentVM)
+		p.mu.Lock()
+		if !p.removed {
+			break
+		}
+		// Expired or closed between loading and locking it; start over with
+		// the entry that replaces it
+		p.mu.Unlock()
+	}
+
+	if p.vm == nil {
+		v, err := h.vmManager.CreateVM(execCtx)
+		if err != nil {
+			p.mu.Unlock()
+			return nil, nil, err
+		}
+		p.vm = v
+	} else {
+		p.vm.SetContext(execCtx)
+	}
+
+	v := p.vm
+	return v, func(keep bool) {
+		if !keep {
+			v.Close()
+			p.vm = nil
+		}
+		p.lastUsed = time.Now()
+		p.mu.Unlock()
+	}, nil
+}
+
+// expireIdleVMs closes the persistent VMs unused for longer than the session
+// idle timeout, skipping those running an execution
+func (h *JSHandler) expireIdleVMs(now time.Time) {
+	timeout := h.config.SessionIdleTimeout
+	if timeout <= 0 {
+		timeout = DefaultSessionIdleTimeout
+	}
+	h.persistentVMs.Range(func(key, entry any) bool {
+		p := entry.(*persistentVM)
+		if !p.mu.TryLock() {
+			return true
+		}
+		defer p.mu.Unlock()
+		if !p.lastUsed.IsZero() && now.Sub(p.lastUsed) > timeout {
+			if p.vm != nil {
+				p.vm.Close()
+				p.vm = nil
+			}
+			p.removed = true
+			h.persistentVMs.CompareAndDelete(key, entry)
+		}
+		return true
+	})
+}
+
+// closePersistentVM closes the persistent VM kept for an MCP session
+func (h *JSHandler) closePersistentVM(mcpSession string) {
+	if entry, ok := h.persistentVMs.LoadAndDelete(vmKey{mcpSession: mcpSession}); ok {
+		entry.(*persistentVM).close()
+	}
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runCode executes code with handler and returns the result text
+func runCode(t *testing.T, handler *JSHandler, code string) string {
+	t.Helper()
+	return runInSession(t, handler, "", code)
+}
+
+// runInSession executes code with handler in the session with sessionID, if
+// not empty, and returns the result text
+func runInSession(t *testing.T, handler *JSHandler, sessionID, code string) string {
+	t.Helper()
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{"code": code}
+	if sessionID != "" {
+		request.Params.Arguments.(map[string]any)["sessionId"] = sessionID
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	return result.Content[0].(mcp.TextContent).Text
+}
+
+func TestGlobals_IsolatedBetweenExecutions(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{EnabledModules: []string{}})
+
+	runCode(t, handler, `
+		var counter = 1;
+		globalThis.shared = "leaked";
+		function helper() { return 42; }
+		Array.prototype.extra = 1;
+	`)
+
+	text := runCode(t, handler, `[typeof counter, typeof shared, typeof helper, typeof [].extra].join(" ")`)
+	assert.Contains(t, text, "Result: undefined undefined undefined undefined")
+}
+
+func TestGlobals_PersistentAcrossExecutions(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules:    []string{"timers"},
+		PersistentGlobals: true,
+		ExecutionTimeout:  500 * time.Millisecond,
+	})
+
+	runCode(t, handler, `
+		var counter = 1;
+		function increment() { return ++counter; }
+	`)
+	assert.Contains(t, runCode(t, handler, `increment()`), "Result: 2")
+	assert.Contains(t, runCode(t, handler, `
+		new Promise((resolve) => setTimeout(() => resolve(increment()), 10))
+			.then((value) => console.log("async:", value));
+	`), "async: 3")
+	assert.Contains(t, runCode(t, handler, `counter`), "Result: 3")
+
+	// A timed out execution discards the VM and its globals
+	assert.Contains(t, runCode(t, handler, `while (true) {}`), "timeout")
+	assert.Contains(t, runCode(t, handler, `typeof counter`), "Result: undefined")
+}
+
+func TestSession_StatePreservedAcrossCalls(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{EnabledModules: []string{}})
+
+	runInSession(t, handler, "a", `
+		var answer = 42;
+		function double(x) { return x * 2; }
+	`)
+	assert.Contains(t, runInSession(t, handler, "a", `double(answer)`), "Result: 84")
+
+	// Other sessions and calls without a session do not see it
+	assert.Contains(t, runInSession(t, handler, "b", `typeof answer`), "Result: undefined")
+	assert.Contains(t, runCode(t, handler, `typeof double`), "Result: undefined")
+}
+
+func TestSession_KVSurvivesUnrelatedExecutions(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{EnabledModules: []string{"kv"}})
+
+	runInSession(t, handler, "s1", `kv.set("a", 1)`)
+
+	// An unrelated execution has a kv of its own, and closing its VM leaves
+	// the session's values alone
+	assert.Contains(t, runCode(t, handler, `String(kv.get("a"))`), "Result: undefined")
+	assert.Contains(t, runInSession(t, handler, "s1", `kv.get("a")`), "Result: 1")
+}
+
+func TestSession_ExpiresWhenIdle(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules:     []string{},
+		SessionIdleTimeout: 50 * time.Millisecond,
+	})
+
+	runInSession(t, handler, "idle", `var kept = true;`)
+	assert.Contains(t, runInSession(t, handler, "idle", `typeof kept`), "Result: boolean")
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Contains(t, runInSession(t, handler, "idle", `typeof kept`), "Result: undefined")
+}
+
+func TestSession_AcquireRetriesRemovedEntry(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{EnabledModules: []string{}})
+	runInSession(t, handler, "racy", `var kept = true;`)
+
+	// Hold the entry while another execution loads it, then remove it as
+	// expiry would before letting that execution lock it
+	key := vmKey{sessionID: "racy"}
+	entry, ok := handler.persistentVMs.Load(key)
+	require.True(t, ok)
+	old := entry.(*persistentVM)
+	old.mu.Lock()
+
+	done := make(chan string)
+	go func() {
+		done <- runInSession(t, handler, "racy", `typeof kept`)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	old.vm.Close()
+	old.vm = nil
+	old.removed = true
+	handler.persistentVMs.CompareAndDelete(key, entry)
+	old.mu.Unlock()
+
+	assert.Contains(t, <-done, "Result: undefined")
+	current, ok := handler.persistentVMs.Load(key)
+	require.True(t, ok)
+	assert.NotSame(t, old, current)
+}
@@ -0,0 +1,131 @@
+// Package quota tracks per-session module usage against configured limits.
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrExceeded is returned when an operation would exceed a session quota
+var ErrExceeded = errors.New("quota exceeded")
+
+// Limits configures per-session quotas. Zero means unlimited.
+type Limits struct {
+	// FetchCalls is the maximum number of fetch calls
+	FetchCalls int
+	// CacheEntries is the maximum number of distinct cache keys stored and
+	// not yet expired
+	CacheEntries int
+}
+
+// Enabled reports whether any limit is set
+func (l Limits) Enabled() bool {
+	return l.FetchCalls > 0 || l.CacheEntries > 0
+}
+
+// Usage counts the operations of one session. It is safe for concurrent use
+// by executions sharing the session.
+type Usage struct {
+	mu         sync.Mutex
+	limits     Limits
+	fetchCalls int
+	cacheKeys  map[cacheKey]time.Time // when each key expires, zero for never
+}
+
+// cacheKey is a key stored in a cache, told apart from the same key in the
+// cache of another VM by the scope the cache module passes for it
+type cacheKey struct {
+	scope any
+	key   string
+}
+
+// NewUsage creates an empty usage tracker for limits
+func NewUsage(limits Limits) *Usage {
+	return &Usage{
+		limits:    limits,
+		cacheKeys: make(map[cacheKey]time.Time),
+	}
+}
+
+// CountFetch records a fetch call, failing if it exceeds the fetch quota
+func (u *Usage) CountFetch() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.limits.FetchCalls > 0 && u.fetchCalls >= u.limits.FetchCalls {
+		return fmt.Errorf("%w: fetch is limited to %d calls per session", ErrExceeded, u.limits.FetchCalls)
+	}
+	u.fetchCalls++
+	return nil
+}
+
+// AddCacheEntry records a cache key being stored for ttl, or without expiry
+// if ttl is 0, failing if a new key exceeds the cache entry quota.
+// Overwriting a counted key is always allowed. Expired keys no longer count.
+// scope identifies the store the key is in, nil for a shared store; it must
+// be comparable, e.g. a pointer.
+func (u *Usage) AddCacheEntry(scope any, key string, ttl time.Duration) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	entry := cacheKey{scope: scope, key: key}
+	now := time.Now()
+	var expires time.Time
+	if ttl > 0 {
+		expires = now.Add(ttl)
+	}
+	if _, ok := u.cacheKeys[entry]; ok {
+		u.cacheKeys[entry] = expires
+		return nil
+	}
+	if u.limits.CacheEntries > 0 && len(u.cacheKeys) >= u.limits.CacheEntries {
+		u.expireCacheEntries(now)
+		if len(u.cacheKeys) >= u.limits.CacheEntries {
+			return fmt.Errorf("%w: cache is limited to %d entries per session", ErrExceeded, u.limits.CacheEntries)
+		}
+	}
+	u.cacheKeys[entry] = expires
+	return nil
+}
+
+// expireCacheEntries releases the quota held by keys whose TTL has passed
+func (u *Usage) expireCacheEntries(now time.Time) {
+	for entry, expires := range u.cacheKeys {
+		if !expires.IsZero() && now.After(expires) {
+			delete(u.cacheKeys, entry)
+		}
+	}
+}
+
+// RemoveCacheEntry releases the quota held by a deleted cache key of scope
+func (u *Usage) RemoveCacheEntry(scope any, key string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.cacheKeys, cacheKey{scope: scope, key: key})
+}
+
+// ReleaseCacheScope releases the quota held by every key of scope, once the
+// store it identifies is gone
+func (u *Usage) ReleaseCacheScope(scope any) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for entry := range u.cacheKeys {
+		if entry.scope == scope {
+			delete(u.cacheKeys, entry)
+		}
+	}
+}
+
+type usageKey struct{}
+
+// NewContext returns a context carrying usage
+func NewContext(ctx context.Context, usage *Usage) context.Context {
+	return context.WithValue(ctx, usageKey{}, usage)
+}
+
+// FromContext returns the usage carried by ctx, or nil if there is none
+func FromContext(ctx context.Context) *Usage {
+	usage, _ := ctx.Value(usageKey{}).(*Usage)
+	return usage
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/codebench-mcp/server/quota"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuota_FetchCallsAcrossExecutions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer ts.Close()
+
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"fetch"},
+		Quotas:         quota.Limits{FetchCalls: 3},
+	})
+
+	run := func(code string) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "executeJS"
+		request.Params.Arguments = map[string]any{"code": code}
+		result, err := handler.handleExecuteJS(context.Background(), request)
+		require.NoError(t, err)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	// The first execution uses two of the three calls
+	text := run(fmt.Sprintf(`fetch(%[1]q); fetch(%[1]q).text();`, ts.URL))
+	assert.Contains(t, text, "Result: ok")
+
+	// The second execution in the same session only gets one more
+	text = run(fmt.Sprintf(`
+		fetch(%[1]q);
+		try {
+			fetch(%[1]q);
+			"allowed";
+		} catch (e) {
+			e.message;
+		}
+	`, ts.URL))
+	assert.Contains(t, text, "quota exceeded: fetch is limited to 3 calls per session")
+}
+
+func TestQuota_CacheEntries(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"cache"},
+		Quotas:         quota.Limits{CacheEntries: 2},
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const cache = require('cache');
+			cache.set("a", "1");
+			cache.set("b", "2");
+			cache.set("a", "updated");
+			let third;
+			try { cache.set("c", "3"); third = "allowed"; } catch (e) { third = "rejected"; }
+			cache.del("b");
+			cache.set("c", "3");
+			third + " " + cache.get("c");
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: rejected 3")
+}
+
+func TestQuota_ExpiredCacheEntriesReleased(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"cache", "timers"},
+		Quotas:         quota.Limits{CacheEntries: 1},
+		SharedCache:    true,
+	})
+
+	text := runCode(t, handler, `
+		const cache = require('cache');
+		cache.set("a", "1", 20);
+		let early;
+		try { cache.set("b", "2"); early = "allowed"; } catch (e) { early = "rejected"; }
+		setTimeout(() => {
+			cache.set("b", "2");
+			console.log(early, String(cache.get("a")), cache.get("b"));
+		}, 50);
+	`)
+	assert.Contains(t, text, "rejected undefined 2")
+}
+
+func TestQuota_PerVMCacheEntriesReleasedWithVM(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"cache"},
+		Quotas:         quota.Limits{CacheEntries: 1},
+	})
+
+	setB := `
+		try { require('cache').set("b", "2"); "allowed"; } catch (e) { e.message; }
+	`
+	runCode(t, handler, `require('cache').set("a", "1")`)
+	assert.Contains(t, runCode(t, handler, `String(require('cache').get("a"))`), "Result: undefined")
+	// The first VM and its item are gone, so its key no longer counts
+	assert.Contains(t, runCode(t, handler, setB), "Result: allowed")
+
+	// A session's VM keeps its items, and their quota, between calls
+	runInSession(t, handler, "s", `require('cache').set("a", "1")`)
+	assert.Contains(t, runInSession(t, handler, "s", setB), "quota exceeded")
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/internal/logger"
+)
+
+// panicError is a Go panic recovered while running code, e.g. from a bug in
+// a module's native function
+type panicError struct {
+	value any
+}
+
+func (e *panicError) Error() string {
+	if v, ok := e.value.(sobek.Value); ok {
+		return fmt.Sprintf("internal error: %s", v.String())
+	}
+	return fmt.Sprintf("internal error: %v", e.value)
+}
+
+// isPanic reports whether err ended a run because it panicked
+func isPanic(err error) bool {
+	var p *panicError
+	return errors.As(err, &p)
+}
+
+// recoverPanic turns a panic of the calling goroutine into an error passed
+// to report, so the execution fails instead of the whole server. It must be
+// deferred directly.
+func recoverPanic(report func(err error)) {
+	if r := recover(); r != nil {
+		logger.Error("Recovered panic while running code", "panic", r, "stack", string(debug.Stack()))
+		report(&panicError{value: r})
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/sobek"
+	"github.com/mark3labs/codebench-mcp/server/vm"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// faultyModule has a native function with a bug: explode(n) indexes past
+// the end of a slice, panicking with a Go runtime error
+type faultyModule struct{}
+
+func (faultyModule) Name() string { return "faulty" }
+
+func (faultyModule) Setup(runtime *sobek.Runtime, manager *vm.VMManager) error {
+	runtime.Set("explode", func(call sobek.FunctionCall) sobek.Value {
+		items := []int{1, 2, 3}
+		return runtime.ToValue(items[call.Argument(0).ToInteger()])
+	})
+	return nil
+}
+
+func (faultyModule) Cleanup() error { return nil }
+
+func (faultyModule) IsEnabled(enabledModules map[string]bool) bool {
+	return enabledModules["faulty"]
+}
+
+func TestRecover_ModulePanicFailsExecution(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{EnabledModules: []string{"faulty", "timers"}})
+	require.NoError(t, handler.vmManager.RegisterModule(faultyModule{}))
+
+	for _, code := range []string{
+		`explode(1) + explode(10)`,
+		// A panic in a job run by the event loop is reported the same way
+		`setTimeout(() => explode(10), 1); "scheduled"`,
+	} {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"code": code}
+		result, err := handler.handleExecuteJS(context.Background(), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "internal error: runtime error: index out of range")
+		assert.Contains(t, text, "Category: internal")
+	}
+
+	// Background server code reports the panic too
+	result, err := handler.handleServerCode(context.Background(), `explode(10)`)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "internal error: runtime error: index out of range")
+	assert.Empty(t, handler.listRuntimes())
+
+	// The handler keeps running executions
+	assert.Contains(t, runCode(t, handler, `explode(2)`), "Result: 3")
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/codebench-mcp/server/vm"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// sizeTimeout bounds waiting for a busy server VM to estimate its size
+const sizeTimeout = time.Second
+
+// backgroundServer is a VM running server code in the background
+type backgroundServer struct {
+	id      string
+	vm      *vm.VM
+	started time.Time
+}
+
+// runtimeInfo describes an active runtime in listRuntimes results
+type runtimeInfo struct {
+	ID          string `json:"id"`
+	Kind        string `json:"kind"` // "server" or "session"
+	UptimeMs    int64  `json:"uptimeMs"`
+	MemoryBytes int64  `json:"memoryEstimateBytes,omitempty"` // Omitted while the runtime is busy
+}
+
+// runtimeID returns the id listRuntimes reports for a persistent VM
+func (k vmKey) runtimeID() string {
+	if k.sessionID != "" {
+		return "session:" + k.sessionID
+	}
+	return "globals:" + k.mcpSession
+}
+
+// listRuntimes returns the background servers and persistent VMs, sorted by id
+func (h *JSHandler) listRuntimes() []runtimeInfo {
+	now := time.Now()
+	var runtimes []runtimeInfo
+
+	h.vmMutex.Lock()
+	servers := append([]*backgroundServer(nil), h.runningVMs...)
+	h.vmMutex.Unlock()
+	for _, s := range servers {
+		runtimes = append(runtimes, runtimeInfo{
+			ID:          s.id,
+			Kind:        "server",
+			UptimeMs:    now.Sub(s.started).Milliseconds(),
+			MemoryBytes: estimateOnLoop(s.vm),
+		})
+	}
+
+	h.persistentVMs.Range(func(key, entry any) bool {
+		p := entry.(*persistentVM)
+		info := runtimeInfo{
+			ID:       key.(vmKey).runtimeID(),
+			Kind:     "session",
+			UptimeMs: now.Sub(p.started).Milliseconds(),
+		}
+		// A session running an execution is listed without a size
+		if p.mu.TryLock() {
+			if p.vm != nil {
+				info.MemoryBytes = p.vm.EstimateSize()
+			}
+			p.mu.Unlock()
+		}
+		runtimes = append(runtimes, info)
+		return true
+	})
+
+	sort.Slice(runtimes, func(i, j int) bool { return runtimes[i].ID < runtimes[j].ID })
+	return runtimes
+}
+
+// estimateOnLoop estimates the size of a VM whose event loop is running, from a
+// job on that loop, or returns 0 if the loop does not get to it in time
+func estimateOnLoop(v *vm.VM) int64 {
+	result := make(chan int64, 1)
+	v.Post(func() error {
+		result <- v.EstimateSize()
+		return nil
+	})
+	select {
+	case size := <-result:
+		return size
+	case <-time.After(sizeTimeout):
+		return 0
+	}
+}
+
+// terminateRuntime closes the background server or persistent VM with id,
+// reporting whether it existed
+func (h *JSHandler) terminateRuntime(id string) bool {
+	if strings.HasPrefix(id, "server:") {
+		h.vmMutex.Lock()
+		var server *backgroundServer
+		for i, s := range h.runningVMs {
+			if s.id == id {
+				server = s
+				h.runningVMs = append(h.runningVMs[:i], h.runningVMs[i+1:]...)
+				break
+			}
+		}
+		h.vmMutex.Unlock()
+		if server == nil {
+			return false
+		}
+		server.vm.Close()
+		return true
+	}
+
+	var key vmKey
+	switch {
+	case strings.HasPrefix(id, "session:"):
+		key.sessionID = strings.TrimPrefix(id, "session:")
+	case strings.HasPrefix(id, "globals:"):
+		key.mcpSession = strings.TrimPrefix(id, "globals:")
+	default:
+		return false
+	}
+	entry, ok := h.persistentVMs.LoadAndDelete(key)
+	if !ok {
+		return false
+	}
+	entry.(*persistentVM).close()
+	return true
+}
+
+// isTerminated reports whether err ended a run because its VM was closed
+func isTerminated(err error) bool {
+	return errors.Is(err, vm.ErrClosed)
+}
+
+// isOverBudget reports whether err ended a run because it exceeded its CPU
+// budget
+func isOverBudget(err error) bool {
+	return errors.Is(err, vm.ErrCPUBudgetExceeded)
+}
+
+func (h *JSHandler) handleListRuntimes(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	runtimes := h.listRuntimes()
+	if runtimes == nil {
+		runtimes = []runtimeInfo{}
+	}
+	data, err := json.MarshalIndent(runtimes, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: string(data),
+			},
+		},
+		StructuredContent: map[string]any{"runtimes": runtimes},
+	}, nil
+}
+
+func (h *JSHandler) handleTerminateRuntime(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	id, err := request.RequireString("id")
+	if err != nil {
+		return nil, err
+	}
+
+	if !h.terminateRuntime(id) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("No active runtime with id %q", id),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("Terminated runtime %s", id),
+			},
+		},
+	}, nil
+}
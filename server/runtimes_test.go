@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runtimeIDs returns the ids reported by listRuntimes
+func runtimeIDs(t *testing.T, handler *JSHandler) map[string]runtimeInfo {
+	t.Helper()
+	result, err := handler.handleListRuntimes(context.Background(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	ids := make(map[string]runtimeInfo)
+	for _, info := range result.StructuredContent.(map[string]any)["runtimes"].([]runtimeInfo) {
+		ids[info.ID] = info
+	}
+	return ids
+}
+
+// terminate calls terminateRuntime with id
+func terminate(t *testing.T, handler *JSHandler, id string) *mcp.CallToolResult {
+	t.Helper()
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "terminateRuntime"
+	request.Params.Arguments = map[string]any{"id": id}
+	result, err := handler.handleTerminateRuntime(context.Background(), request)
+	require.NoError(t, err)
+	return result
+}
+
+func TestRuntimes_ListAndTerminateSession(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{EnabledModules: []string{}})
+
+	runInSession(t, handler, "work", `var data = "x".repeat(10000);`)
+
+	ids := runtimeIDs(t, handler)
+	require.Contains(t, ids, "session:work")
+	assert.Equal(t, "session", ids["session:work"].Kind)
+	assert.Greater(t, ids["session:work"].MemoryBytes, int64(10000))
+
+	result := terminate(t, handler, "session:work")
+	assert.False(t, result.IsError)
+	assert.NotContains(t, runtimeIDs(t, handler), "session:work")
+	assert.Contains(t, runInSession(t, handler, "work", `typeof data`), "Result: undefined")
+
+	result = terminate(t, handler, "session:missing")
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "No active runtime")
+}
+
+func TestRuntimes_ListAndTerminateServer(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{EnabledModules: []string{"http"}})
+	t.Cleanup(handler.Cleanup)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	require.NoError(t, ln.Close())
+
+	runCode(t, handler, fmt.Sprintf(`
+		const serve = require('http/server');
+		serve({ port: %d, hostname: "127.0.0.1" }, () => "ok");
+	`, port))
+
+	ids := runtimeIDs(t, handler)
+	require.Contains(t, ids, "server:1")
+	assert.Equal(t, "server", ids["server:1"].Kind)
+	assert.Positive(t, ids["server:1"].MemoryBytes)
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/", port)
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.False(t, terminate(t, handler, "server:1").IsError)
+	assert.NotContains(t, runtimeIDs(t, handler), "server:1")
+
+	// The server stops listening with its runtime
+	assert.Eventually(t, func() bool {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err != nil
+	}, 2*time.Second, 20*time.Millisecond)
+}
+
+func TestRuntimes_CleanupClosesRunningVMs(t *testing.T) {
+	store := newStubKVStore()
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"http", "kv"},
+		KVStore:        store,
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	require.NoError(t, ln.Close())
+
+	runCode(t, handler, fmt.Sprintf(`
+		const serve = require('http/server');
+		serve({ port: %d, hostname: "127.0.0.1" }, () => "ok");
+		runtime.onExit(() => kv.set('server', 'closed'));
+	`, port))
+	runInSession(t, handler, "work", `runtime.onExit(() => kv.set('session', 'closed'));`)
+	require.Len(t, runtimeIDs(t, handler), 2)
+
+	// What the command does on SIGINT or SIGTERM
+	handler.Cleanup()
+
+	assert.Empty(t, runtimeIDs(t, handler))
+	url := fmt.Sprintf("http://127.0.0.1:%d/", port)
+	assert.Eventually(t, func() bool {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err != nil
+	}, 2*time.Second, 20*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		return store.items["server"] == "closed" && store.items["session"] == "closed"
+	}, 2*time.Second, 20*time.Millisecond)
+}
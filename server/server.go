@@ -2,6 +2,8 @@ package server
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -15,14 +17,21 @@ import (
 	"github.com/mark3labs/codebench-mcp/internal/logger"
 	"github.com/mark3labs/codebench-mcp/server/modules/buffer"
 	"github.com/mark3labs/codebench-mcp/server/modules/cache"
+	"github.com/mark3labs/codebench-mcp/server/modules/chart"
 	"github.com/mark3labs/codebench-mcp/server/modules/console"
 	"github.com/mark3labs/codebench-mcp/server/modules/crypto"
 	"github.com/mark3labs/codebench-mcp/server/modules/encoding"
 	"github.com/mark3labs/codebench-mcp/server/modules/fetch"
+	"github.com/mark3labs/codebench-mcp/server/modules/html"
 	"github.com/mark3labs/codebench-mcp/server/modules/http"
 	"github.com/mark3labs/codebench-mcp/server/modules/kv"
+	"github.com/mark3labs/codebench-mcp/server/modules/ndjson"
+	"github.com/mark3labs/codebench-mcp/server/modules/pdf"
 	"github.com/mark3labs/codebench-mcp/server/modules/timers"
 	"github.com/mark3labs/codebench-mcp/server/modules/url"
+	"github.com/mark3labs/codebench-mcp/server/modules/wasm"
+	"github.com/mark3labs/codebench-mcp/server/modules/worker"
+	"github.com/mark3labs/codebench-mcp/server/quota"
 	"github.com/mark3labs/codebench-mcp/server/vm"
 )
 
@@ -32,47 +41,262 @@ type ModuleConfig struct {
 	EnabledModules   []string
 	DisabledModules  []string
 	ExecutionTimeout time.Duration
+	// DisableWarmup skips pre-initializing module state at construction,
+	// deferring that cost to the first execution
+	DisableWarmup bool
+	// CacheBackend stores items for the cache module (defaults to in-memory)
+	CacheBackend cache.Cache
+	// SharedCache makes the default in-memory cache shared by every VM, so
+	// items set by one execution are visible to unrelated ones. By default
+	// each VM has its own cache, like kv. CacheBackend is always shared.
+	SharedCache bool
+	// FetchCache caches fetch GET responses in memory while their
+	// Cache-Control max-age says they are fresh. Requests with credentials
+	// and private responses are never cached.
+	FetchCache bool
+	// FetchHeaders are sent with every fetch request, e.g. tracing headers.
+	// Headers passed to fetch take precedence. The User-Agent defaults to
+	// codebench-mcp/<version>.
+	FetchHeaders map[string]string
+	// FetchSafeMethodsOnly restricts fetch to GET and HEAD requests
+	FetchSafeMethodsOnly bool
+	// FetchMocks, if not nil, makes fetch serve canned responses keyed by
+	// "URL" or "METHOD URL" instead of using the network, for testing code
+	// that calls fetch; unmatched requests fail with a network error
+	FetchMocks map[string]fetch.MockResponse
+	// FetchTimeout bounds each fetch request (defaults to fetch.DefaultTimeout)
+	FetchTimeout time.Duration
+	// FetchMaxResponseSize caps the bytes of a fetch response body, failing
+	// larger responses (defaults to fetch.DefaultMaxResponseSize)
+	FetchMaxResponseSize int64
+	// TeeConsole also forwards console.* output from executed code to the
+	// internal logger, prefixed with ConsoleLogPrefix (default "[js]")
+	TeeConsole       bool
+	ConsoleLogPrefix string
+	// HTTPDefaultPort is the port of http servers started without one
+	// (defaults to http.DefaultPort; http.AnyPort lets the OS pick a free
+	// port). HTTPDefaultHostname is the address they bind to when the script
+	// gives none (defaults to http.DefaultHostname).
+	HTTPDefaultPort     int
+	HTTPDefaultHostname string
+	// HTTPLoopbackOnly keeps http servers on loopback: non-loopback hostnames
+	// from scripts are rejected, and servers never bind all interfaces
+	HTTPLoopbackOnly bool
+	// KVStore stores values for the kv module (defaults to in-memory)
+	KVStore kv.Store
+	// Quotas limit module usage per MCP session, across all executions in it
+	Quotas quota.Limits
+	// MaxTimers caps the timers and intervals active at once in an execution
+	// (defaults to timers.DefaultMaxTimers)
+	MaxTimers int
+	// MaxOutputSize caps the bytes of output captured per execution, after
+	// which it is truncated (defaults to console.DefaultMaxOutput)
+	MaxOutputSize int
+	// ConsoleAsResult uses the console output as the result of scripts that
+	// do not return a value
+	ConsoleAsResult bool
+	// PersistentGlobals keeps one VM per MCP session, so globals and functions
+	// defined by a script stay available to later executions like in a REPL.
+	// By default every execution starts from a fresh global scope.
+	PersistentGlobals bool
+	// SessionIdleTimeout is how long a persistent VM, kept for a sessionId or
+	// with PersistentGlobals, survives without executions (defaults to
+	// DefaultSessionIdleTimeout)
+	SessionIdleTimeout time.Duration
+	// Deterministic makes executions reproducible: Math.random is seeded with
+	// the call's seed argument (DeterministicSeed by default) and Date.now()
+	// and new Date() always return DeterministicTime (defaults to
+	// DefaultDeterministicTime)
+	Deterministic     bool
+	DeterministicSeed int64
+	DeterministicTime time.Time
+	// CPUBudget caps the time an execution may spend running JavaScript, not
+	// counting waits for timers or other async work, so runaway loops are
+	// stopped long before ExecutionTimeout (0 = no budget)
+	CPUBudget time.Duration
+	// IdleTimeout ends executions whose event loop has only waited for
+	// intervals for this long, by clearing those intervals, so a script that
+	// leaves one running returns promptly rather than at ExecutionTimeout
+	// (0 = wait for the intervals)
+	IdleTimeout time.Duration
+	// MaxConcurrentExecutions caps the executeJS calls running at once; calls
+	// beyond it are rejected with a server busy error (0 = unlimited)
+	MaxConcurrentExecutions int
+	// ModuleOptions configures modules by name, taking precedence over the
+	// equivalent fields above. The options each module accepts are:
+	//
+	//	"fetch":   "timeout" (time.Duration, milliseconds or a string like "10s"),
+	//	           "headers" (map of strings, merged over FetchHeaders),
+	//	           "safeMethodsOnly" (bool),
+	//	           "maxResponseSize" (number of bytes, like FetchMaxResponseSize)
+	//	"timers":  "maxTimers" (number)
+	//	"console": "maxOutputSize" (number of bytes), "asResult" (bool)
+	//
+	// Unknown modules or options and invalid values are logged and ignored.
+	ModuleOptions map[string]any
+}
+
+// executionResult is the structured content of a completed execution
+type executionResult struct {
+	Output       string `json:"output"`                 // Captured console and streamed output
+	Result       any    `json:"result,omitempty"`       // The returned value, or the output with ConsoleAsResult
+	ResultSource string `json:"resultSource,omitempty"` // "return" or "console"
+}
+
+// Categories of failed executions, so agents can tell whether to fix the
+// code or retry
+const (
+	categorySyntax           = "syntax"             // The code could not be compiled
+	categoryRuntime          = "runtime"            // The code threw an uncaught error
+	categoryTimeout          = "timeout"            // The execution timeout expired
+	categoryCPUBudget        = "cpu_budget"         // The CPU budget was spent
+	categoryModuleNotEnabled = "module_not_enabled" // require() named a disabled module
+	categoryTerminated       = "terminated"         // The runtime was terminated
+	categoryInternal         = "internal"           // The server failed to run the code
+)
+
+// executionFailure is the structured content of a failed execution
+type executionFailure struct {
+	Category string `json:"category"`
+	Error    string `json:"error"`
+	Output   string `json:"output"`
 }
 
 type JSHandler struct {
 	vmManager    *vm.VMManager
 	config       ModuleConfig
-	runningVMs   []*vm.VM
+	runningVMs   []*backgroundServer
+	nextServerID int
 	vmMutex      sync.Mutex
+	sessions     sync.Map // session ID -> *quota.Usage
+	persistentVMs sync.Map // vmKey -> *persistentVM
+	executions   chan struct{} // One slot per running execution, nil if unlimited
 }
 
 func NewJSHandler() *JSHandler {
 	return NewJSHandlerWithConfig(ModuleConfig{
-		EnabledModules:   []string{"http", "fetch", "timers", "buffer", "kv", "crypto", "encoding", "url", "cache"},
+		EnabledModules:   []string{"http", "fetch", "timers", "buffer", "kv", "crypto", "encoding", "url", "cache", "chart", "pdf", "ndjson", "worker"},
 		ExecutionTimeout: 5 * time.Minute,
 	})
 }
 
 func NewJSHandlerWithConfig(config ModuleConfig) *JSHandler {
+	config = applyModuleOptions(config, parseModuleOptions(config.ModuleOptions))
+
 	// Create VM manager with enabled modules
 	enabledModules := config.EnabledModules
 	if len(enabledModules) == 0 && len(config.DisabledModules) == 0 {
 		// Enable all modules by default if none specified
-		enabledModules = []string{"http", "fetch", "timers", "buffer", "kv", "crypto", "encoding", "url", "cache"}
+		enabledModules = []string{"http", "fetch", "timers", "buffer", "kv", "crypto", "encoding", "url", "cache", "chart", "pdf", "ndjson", "worker"}
 	}
 
 	vmManager := vm.NewVMManager(enabledModules)
 
+	cacheModule := cache.NewCacheModuleWithOptions(cache.Options{
+		Backend: config.CacheBackend,
+		PerVM:   !config.SharedCache,
+	})
+	fetchOptions := fetch.Options{
+		Headers:         fetchHeaders(config.FetchHeaders),
+		SafeMethodsOnly: config.FetchSafeMethodsOnly,
+		Timeout:         config.FetchTimeout,
+		Mocks:           config.FetchMocks,
+		MaxResponseSize: config.FetchMaxResponseSize,
+	}
+	if config.FetchCache {
+		// The fetch HTTP cache has a store of its own, so scripts can't read
+		// or plant responses through the cache module
+		fetchOptions.Cache = cache.NewCache()
+	}
+	fetchModule := fetch.NewFetchModuleWithOptions(fetchOptions)
+
 	// Register all available modules (except console which is handled per-execution)
-	vmManager.RegisterModule(kv.NewKVModule())
-	vmManager.RegisterModule(timers.NewTimersModule())
-	vmManager.RegisterModule(fetch.NewFetchModule())
+	vmManager.RegisterModule(kv.NewKVModuleWithStore(config.KVStore))
+	vmManager.RegisterModule(timers.NewTimersModuleWithLimit(config.MaxTimers))
+	vmManager.RegisterModule(fetchModule)
 	vmManager.RegisterModule(buffer.NewBufferModule())
-	vmManager.RegisterModule(http.NewHTTPModule())
+	vmManager.RegisterModule(http.NewHTTPModuleWithOptions(http.Options{
+		DefaultPort:     config.HTTPDefaultPort,
+		DefaultHostname: config.HTTPDefaultHostname,
+		LoopbackOnly:    config.HTTPLoopbackOnly,
+	}))
 	vmManager.RegisterModule(crypto.NewCryptoModule())
 	vmManager.RegisterModule(encoding.NewEncodingModule())
 	vmManager.RegisterModule(url.NewURLModule())
-	vmManager.RegisterModule(cache.NewCacheModule())
+	vmManager.RegisterModule(cacheModule)
+	vmManager.RegisterModule(chart.NewChartModule())
+	vmManager.RegisterModule(pdf.NewPDFModule())
+	vmManager.RegisterModule(ndjson.NewNDJSONModule())
+	vmManager.RegisterModule(html.NewHTMLModule())
+	vmManager.RegisterModule(wasm.NewWASMModule())
+	vmManager.RegisterModule(worker.NewWorkerModule())
+
+	// Pay one-time initialization costs now rather than on the first call
+	if !config.DisableWarmup {
+		if err := vmManager.Warmup(); err != nil {
+			logger.Warn("Module warmup failed", "error", err)
+		}
+	}
 
-	return &JSHandler{
+	h := &JSHandler{
 		vmManager: vmManager,
 		config:    config,
 	}
+	if config.MaxConcurrentExecutions > 0 {
+		h.executions = make(chan struct{}, config.MaxConcurrentExecutions)
+	}
+	return h
+}
+
+// fetchHeaders returns the default fetch headers, with the configured headers
+// overriding the codebench-mcp User-Agent
+func fetchHeaders(configured map[string]string) map[string]string {
+	headers := map[string]string{"User-Agent": "codebench-mcp/" + Version}
+	for key, value := range configured {
+		if strings.EqualFold(key, "User-Agent") {
+			key = "User-Agent"
+		}
+		headers[key] = value
+	}
+	return headers
+}
+
+// sessionUsage returns the quota usage of the MCP session in ctx, or nil if no
+// quotas are configured. Calls without a session share one usage.
+func (h *JSHandler) sessionUsage(ctx context.Context) *quota.Usage {
+	if !h.config.Quotas.Enabled() {
+		return nil
+	}
+	sessionID := ""
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		sessionID = session.SessionID()
+	}
+	usage, _ := h.sessions.LoadOrStore(sessionID, quota.NewUsage(h.config.Quotas))
+	return usage.(*quota.Usage)
+}
+
+// withSessionUsage attaches usage to ctx so modules can enforce quotas
+func (h *JSHandler) withSessionUsage(ctx context.Context, usage *quota.Usage) context.Context {
+	if usage == nil {
+		return ctx
+	}
+	return quota.NewContext(ctx, usage)
+}
+
+// newConsoleModule creates the console for an execution, capturing into output
+func (h *JSHandler) newConsoleModule(output *strings.Builder) *console.ConsoleModule {
+	var consoleModule *console.ConsoleModule
+	if h.config.TeeConsole {
+		prefix := h.config.ConsoleLogPrefix
+		if prefix == "" {
+			prefix = "[js]"
+		}
+		consoleModule = console.NewConsoleModuleWithTee(output, prefix)
+	} else {
+		consoleModule = console.NewConsoleModule(output)
+	}
+	consoleModule.SetMaxOutput(h.config.MaxOutputSize)
+	return consoleModule
 }
 
 func (h *JSHandler) handleExecuteJS(
@@ -84,6 +308,26 @@ func (h *JSHandler) handleExecuteJS(
 		return nil, err
 	}
 
+	// Reject the call rather than queue it when all execution slots are
+	// taken, so a burst of calls can't exhaust the server
+	if h.executions != nil {
+		select {
+		case h.executions <- struct{}{}:
+			defer func() { <-h.executions }()
+		default:
+			logger.Debug("Rejecting execution, server busy", "limit", cap(h.executions))
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Server busy: %d executions are already running, try again later", cap(h.executions)),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
 	logger.Debug("Executing JavaScript code", "length", len(code))
 
 	// Check if this looks like HTTP server code
@@ -98,7 +342,8 @@ func (h *JSHandler) handleExecuteJS(
 	} else {
 		logger.Debug("Running regular JavaScript code")
 		// For regular code, run synchronously
-		return h.handleRegularCode(ctx, code)
+		seed := int64(request.GetFloat("seed", float64(h.config.DeterministicSeed)))
+		return h.handleRegularCode(ctx, code, request.GetString("sessionId", ""), seed, progressNotifier(ctx, request))
 	}
 }
 
@@ -111,10 +356,21 @@ func (h *JSHandler) handleServerCode(ctx context.Context, code string) (*mcp.Cal
 	errorChan := make(chan error, 1)
 
 	// Run the server code in a goroutine that stays alive
+	sessionUsage := h.sessionUsage(ctx)
 	go func() {
+		// A panic is reported like an execution error, after discarding the VM
+		discard := func() {}
+		defer recoverPanic(func(err error) {
+			discard()
+			select {
+			case errorChan <- err:
+			default:
+			}
+		})
+
 		// Create VM with custom logger for console output
 		// Use background context so VM doesn't get cancelled when request finishes
-		vmCtx := context.Background()
+		vmCtx := h.withSessionUsage(context.Background(), sessionUsage)
 		vm, err := h.vmManager.CreateVM(vmCtx)
 		if err != nil {
 			logger.Debug("Failed to create VM", "error", err)
@@ -122,30 +378,43 @@ func (h *JSHandler) handleServerCode(ctx context.Context, code string) (*mcp.Cal
 			return
 		}
 
-		// Track this VM for cleanup
+		// Track this VM for cleanup and listRuntimes
 		h.vmMutex.Lock()
-		h.runningVMs = append(h.runningVMs, vm)
+		h.nextServerID++
+		h.runningVMs = append(h.runningVMs, &backgroundServer{
+			id:      fmt.Sprintf("server:%d", h.nextServerID),
+			vm:      vm,
+			started: time.Now(),
+		})
 		h.vmMutex.Unlock()
 
-		// Setup console module to capture output
-		consoleModule := console.NewConsoleModule(&output)
-		consoleModule.Setup(vm.Runtime())
-
-		// Execute the JavaScript code
-		_, err = vm.RunString(code)
-		if err != nil {
-			logger.Error("Server execution error", "error", err)
-			errorChan <- err
-			// Remove from tracking and close VM on error
+		// Remove from tracking and close VM on error
+		discard = func() {
 			h.vmMutex.Lock()
-			for i, trackedVM := range h.runningVMs {
-				if trackedVM == vm {
+			for i, tracked := range h.runningVMs {
+				if tracked.vm == vm {
 					h.runningVMs = append(h.runningVMs[:i], h.runningVMs[i+1:]...)
 					break
 				}
 			}
 			h.vmMutex.Unlock()
 			vm.Close()
+		}
+
+		// Setup console module to capture output
+		consoleModule := h.newConsoleModule(&output)
+		consoleModule.Setup(vm.Runtime())
+
+		// Execute the JavaScript code
+		_, err = vm.RunString(code)
+		if err != nil {
+			if isTerminated(err) {
+				logger.Debug("Server runtime terminated")
+			} else {
+				logger.Error("Server execution error", "error", err)
+			}
+			errorChan <- err
+			discard()
 			return
 		}
 
@@ -191,12 +460,44 @@ func (h *JSHandler) handleServerCode(ctx context.Context, code string) (*mcp.Cal
 	}
 }
 
-func (h *JSHandler) handleRegularCode(ctx context.Context, code string) (*mcp.CallToolResult, error) {
+// progressNotifier returns a function that sends streamed output chunks to the
+// client as progress notifications, or nil if the client didn't ask for progress
+func progressNotifier(ctx context.Context, request mcp.CallToolRequest) func(progress int, message string) {
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return nil
+	}
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return nil
+	}
+	token := request.Params.Meta.ProgressToken
+	return func(progress int, message string) {
+		err := srv.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+			"progressToken": token,
+			"progress":      progress,
+			"message":       message,
+		})
+		if err != nil {
+			logger.Debug("Failed to send progress notification", "error", err)
+		}
+	}
+}
+
+func (h *JSHandler) handleRegularCode(ctx context.Context, code, sessionID string, seed int64, progress func(progress int, message string)) (*mcp.CallToolResult, error) {
 	// Capture console output
 	var output strings.Builder
 
-	// Create VM instance for this execution
-	vm, err := h.vmManager.CreateVM(ctx)
+	// Execute the JavaScript code with configurable timeout
+	timeout := h.config.ExecutionTimeout
+	if timeout == 0 {
+		timeout = 5 * time.Minute // Default fallback
+	}
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	execCtx = h.withSessionUsage(execCtx, h.sessionUsage(ctx))
+
+	// Get the VM for this execution, interrupted when the timeout expires
+	vm, release, err := h.acquireVM(ctx, execCtx, sessionID)
 	if err != nil {
 		logger.Debug("Failed to create VM", "error", err)
 		return &mcp.CallToolResult{
@@ -206,28 +507,49 @@ func (h *JSHandler) handleRegularCode(ctx context.Context, code string) (*mcp.Ca
 					Text: fmt.Sprintf("Failed to create VM: %v", err),
 				},
 			},
-			IsError: true,
+			StructuredContent: executionFailure{Category: categoryInternal, Error: err.Error()},
+			IsError:           true,
 		}, nil
 	}
-	defer vm.Close()
+	// A persistent VM is only kept when the execution finished in time and
+	// within its CPU budget, as an interrupted one has its event loop stopped,
+	// and didn't panic, which leaves the runtime in an unknown state
+	overBudget, panicked := false, false
+	defer func() { release(execCtx.Err() == nil && !overBudget && !panicked) }()
+	vm.SetCPUBudget(h.config.CPUBudget)
+	vm.SetIdleTimeout(h.config.IdleTimeout)
+	if h.config.Deterministic {
+		h.makeDeterministic(vm, seed)
+	}
 
 	// Setup console module to capture output
-	consoleModule := console.NewConsoleModule(&output)
+	consoleModule := h.newConsoleModule(&output)
 	consoleModule.Setup(vm.Runtime())
 
-	// Execute the JavaScript code with configurable timeout
-	timeout := h.config.ExecutionTimeout
-	if timeout == 0 {
-		timeout = 5 * time.Minute // Default fallback
+	// If requested, send each line of output to the client as progress as
+	// soon as it is written, so long executions report as they go
+	if progress != nil {
+		lines := 0
+		consoleModule.SetListener(func(line string) {
+			lines++
+			progress(lines, line)
+		})
 	}
-	execCtx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
+
+	// Stream generator scripts: each yielded chunk is captured like console
+	// output, and so streamed with it
+	vm.SetYieldHandler(func(chunk sobek.Value) {
+		consoleModule.WriteLine(fmt.Sprintf("%v", chunk.Export()))
+	})
 
 	// Execute in a goroutine to respect timeout
 	resultChan := make(chan sobek.Value, 1)
 	errorChan := make(chan error, 1)
+	done := make(chan struct{})
 
 	go func() {
+		defer close(done)
+		defer recoverPanic(func(err error) { errorChan <- err })
 		result, err := vm.RunString(code)
 		if err != nil {
 			errorChan <- err
@@ -236,62 +558,146 @@ func (h *JSHandler) handleRegularCode(ctx context.Context, code string) (*mcp.Ca
 		}
 	}()
 
-	select {
-	case <-execCtx.Done():
+	timeoutResult := func() *mcp.CallToolResult {
+		// The expired context interrupts the VM; wait briefly for it to stop so
+		// the output captured so far, e.g. streamed chunks, can be read safely
+		captured := ""
+		select {
+		case <-done:
+			captured = output.String()
+		case <-time.After(time.Second):
+		}
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
 					Type: "text",
-					Text: fmt.Sprintf("JavaScript execution timeout\n\nOutput:\n%s", output.String()),
+					Text: fmt.Sprintf("JavaScript execution timeout\nCategory: %s\n\nOutput:\n%s", categoryTimeout, captured),
 				},
 			},
+			StructuredContent: executionFailure{
+				Category: categoryTimeout,
+				Error:    "JavaScript execution timeout",
+				Output:   captured,
+			},
 			IsError: true,
-		}, nil
+		}
+	}
+
+	select {
+	case <-execCtx.Done():
+		return timeoutResult(), nil
 	case err := <-errorChan:
+		if execCtx.Err() != nil {
+			return timeoutResult(), nil
+		}
+		overBudget = isOverBudget(err)
+		panicked = isPanic(err)
+		category := errorCategory(vm.Runtime(), err)
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
 					Type: "text",
-					Text: fmt.Sprintf("JavaScript execution error: %v\n\nOutput:\n%s", err, output.String()),
+					Text: fmt.Sprintf("JavaScript execution error: %v\nCategory: %s\n\nOutput:\n%s%s", err, category, output.String(), debugTimings(vm)),
 				},
 			},
+			StructuredContent: executionFailure{
+				Category: category,
+				Error:    err.Error(),
+				Output:   output.String(),
+			},
 			IsError: true,
 		}, nil
 	case result := <-resultChan:
 		// Get the result value
 		var resultStr string
+		structured := executionResult{Output: output.String()}
 		if result != nil && !sobek.IsUndefined(result) && !sobek.IsNull(result) {
 			exported := result.Export()
 			if exported != nil {
 				resultStr = fmt.Sprintf("Result: %v\n", exported)
+				structured.Result = jsonValue(exported)
+				structured.ResultSource = "return"
 			}
 		}
+		if structured.ResultSource == "" && h.config.ConsoleAsResult && structured.Output != "" {
+			// The output already is the text, so no Result line is added
+			structured.Result = strings.TrimSuffix(structured.Output, "\n")
+			structured.ResultSource = "console"
+		}
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
 					Type: "text",
-					Text: fmt.Sprintf("%s%s", output.String(), resultStr),
+					Text: fmt.Sprintf("%s%s%s", structured.Output, resultStr, debugTimings(vm)),
 				},
 			},
+			StructuredContent: structured,
 		}, nil
 	}
 }
 
+// errorCategory classifies the error a run failed with. It reads the thrown
+// value, so the runtime must no longer be running.
+func errorCategory(rt *sobek.Runtime, err error) string {
+	var syntaxErr *sobek.CompilerSyntaxError
+	var exception *sobek.Exception
+	switch {
+	case errors.As(err, &syntaxErr):
+		return categorySyntax
+	case isOverBudget(err):
+		return categoryCPUBudget
+	case isTerminated(err):
+		return categoryTerminated
+	case isPanic(err):
+		return categoryInternal
+	case errors.As(err, &exception):
+		if obj, ok := exception.Value().(*sobek.Object); ok {
+			if code := obj.Get("code"); code != nil && code.String() == vm.CodeModuleDisabled {
+				return categoryModuleNotEnabled
+			}
+		}
+	}
+	return categoryRuntime
+}
+
+// jsonValue returns v if it can be encoded as JSON, otherwise its string form
+func jsonValue(v any) any {
+	if _, err := json.Marshal(v); err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return v
+}
+
+// debugTimings formats the execution phase breakdown when debug mode is on
+func debugTimings(v *vm.VM) string {
+	if !logger.DebugEnabled {
+		return ""
+	}
+	t := v.Timings()
+	return fmt.Sprintf("Timings: compile=%s run=%s drain=%s\n", t.Compile, t.Run, t.Drain)
+}
+
 func (h *JSHandler) getAvailableModules() []string {
 	return h.vmManager.GetEnabledModules()
 }
 
-// Cleanup shuts down all running VMs
+// Cleanup shuts down all running VMs: background servers, which stop
+// listening, and persistent session VMs. Their exit hooks run as they close.
 func (h *JSHandler) Cleanup() {
 	h.vmMutex.Lock()
-	defer h.vmMutex.Unlock()
-	
 	logger.Debug("Cleaning up running VMs", "count", len(h.runningVMs))
-	for _, vm := range h.runningVMs {
-		vm.Close()
+	for _, server := range h.runningVMs {
+		server.vm.Close()
 	}
 	h.runningVMs = nil
+	h.vmMutex.Unlock()
+
+	h.persistentVMs.Range(func(key, entry any) bool {
+		h.persistentVMs.Delete(key)
+		entry.(*persistentVM).close()
+		return true
+	})
 }
 
 func NewJSServer() (*server.MCPServer, error) {
@@ -301,11 +707,27 @@ func NewJSServer() (*server.MCPServer, error) {
 }
 
 func NewJSServerWithConfig(config ModuleConfig) (*server.MCPServer, error) {
+	s, _, err := NewJSServerWithHandler(config)
+	return s, err
+}
+
+// NewJSServerWithHandler creates the MCP server like NewJSServerWithConfig,
+// also returning its handler so the caller can shut down the runtimes still
+// running, e.g. background servers, with Cleanup before exiting
+func NewJSServerWithHandler(config ModuleConfig) (*server.MCPServer, *JSHandler, error) {
 	h := NewJSHandlerWithConfig(config)
 
+	// Forget a session's quota usage and persistent VM once it disconnects
+	hooks := &server.Hooks{}
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		h.sessions.Delete(session.SessionID())
+		h.closePersistentVM(session.SessionID())
+	})
+
 	s := server.NewMCPServer(
 		"codebench-mcp",
 		Version,
+		server.WithHooks(hooks),
 	)
 
 	// Build detailed description with module information
@@ -319,9 +741,38 @@ func NewJSServerWithConfig(config ModuleConfig) (*server.MCPServer, error) {
 			mcp.Description("Complete JavaScript source code to execute in a modern runtime environment. This parameter accepts a full JavaScript program including variable declarations, function definitions, control flow statements, and module imports via require(). The code will be executed in a sandboxed environment with access to enabled modules. Supports modern JavaScript syntax (ES2020+) including arrow functions, destructuring, template literals, and promises. Use require() for module imports (e.g., 'const serve = require(\"http/server\")') rather than ES6 import statements. Note: Top-level async/await is not supported - wrap async code in an async function and call it (e.g., '(async () => { await fetch(...); })()' or define and call an async function). The execution context includes a console object for output, and any returned values will be displayed along with console output. For HTTP servers, they will run in the background without blocking execution completion."),
 			mcp.Required(),
 		),
+		mcp.WithString("sessionId",
+			mcp.Description("Optional session identifier. Executions with the same sessionId share one runtime, so variables and functions defined in one call are available in the next. Sessions expire after a period without executions. Omit it for a fresh, isolated runtime."),
+		),
+		mcp.WithNumber("seed",
+			mcp.Description("Optional seed for Math.random when the server runs in deterministic mode, where the same seed gives the same random numbers and Date is frozen. Ignored otherwise."),
+		),
 	), h.handleExecuteJS)
 
-	return s, nil
+	s.AddTool(mcp.NewTool(
+		"explainJS",
+		mcp.WithDescription("Report which modules and capabilities a script references, without running it: the names passed to require() and the module globals it uses (fetch, setTimeout, crypto, ...), and which of those modules are not enabled. The scan is static, so names built at run time are not seen."),
+		mcp.WithString("code",
+			mcp.Description("The JavaScript source code to scan, as it would be passed to executeJS"),
+			mcp.Required(),
+		),
+	), h.handleExplainJS)
+
+	s.AddTool(mcp.NewTool(
+		"listRuntimes",
+		mcp.WithDescription("List the active runtimes: background HTTP servers and executeJS sessions, with their id, uptime and an estimate of their memory use."),
+	), h.handleListRuntimes)
+
+	s.AddTool(mcp.NewTool(
+		"terminateRuntime",
+		mcp.WithDescription("Terminate an active runtime listed by listRuntimes, stopping its servers and discarding its state."),
+		mcp.WithString("id",
+			mcp.Description("The id of the runtime, as reported by listRuntimes"),
+			mcp.Required(),
+		),
+	), h.handleTerminateRuntime)
+
+	return s, h, nil
 }
 
 func buildToolDescription(enabledModules []string) string {
@@ -329,7 +780,8 @@ func buildToolDescription(enabledModules []string) string {
 
 	description.WriteString("Execute JavaScript code with Node.js-like APIs powered by a modern runtime. ")
 	description.WriteString("Supports modern JavaScript (ES2020+), CommonJS modules via require(), promises, and comprehensive JavaScript APIs. ")
-	description.WriteString("ES6 import statements are not supported in direct execution - use require() instead.\n\n")
+	description.WriteString("ES6 import statements are not supported in direct execution - use require() instead. ")
+	description.WriteString("If the code evaluates to a generator function, each yielded value is streamed as output.\n\n")
 
 	if len(enabledModules) == 0 {
 		description.WriteString("No modules are currently enabled. Only basic JavaScript execution is available.")
@@ -342,14 +794,20 @@ func buildToolDescription(enabledModules []string) string {
 	moduleDescriptions := map[string]string{
 		"http":     "HTTP server creation and management (const serve = require('http/server'))",
 		"fetch":    "Modern fetch API with Request, Response, Headers, FormData (available globally)",
-		"timers":   "setTimeout, setInterval, clearTimeout, clearInterval (available globally)",
+		"timers":   "setTimeout, setInterval, clearTimeout, clearInterval, performance.now, process.nextTick (available globally)",
 		"buffer":   "Buffer, Blob, File APIs for binary data handling (available globally)",
-		"crypto":   "Cryptographic functions (hashing, encryption, HMAC) (const crypto = require('crypto'))",
+		"crypto":   "Cryptographic functions (hashing, encryption, HMAC) (const crypto = require('crypto'); Web Crypto crypto.subtle.digest, crypto.randomUUID and crypto.getRandomValues are available globally)",
 		"cache":    "In-memory caching with TTL support (const cache = require('cache'))",
-		"kv":       "Key-value store per VM instance with get, set, delete, list (available globally)",
+		"kv":       "Key-value store per VM instance with get, set, delete, list, keys(prefix), entries(prefix), namespace(name) for isolated key spaces (available globally)",
 		"console":  "Console logging with structured output (available globally)",
-		"encoding": "TextEncoder/TextDecoder for UTF-8 encoding/decoding (available globally)",
+		"encoding": "TextEncoder/TextDecoder for UTF-8 encoding/decoding, TextEncoderStream/TextDecoderStream with readable/writable sides for pipeThrough() and transform(chunk)/flush() for chunked text, structuredClone, JSON5.parse for relaxed JSON, Intl.NumberFormat and Intl.DateTimeFormat for common locales (available globally); toBase64/fromBase64/toBase64Url/fromBase64Url/toHex/fromHex byte helpers (require('encoding'))",
 		"url":      "URL parsing and URLSearchParams manipulation (available globally)",
+		"chart":    "Line and bar chart rendering to SVG or PNG bytes (const chart = require('chart'))",
+		"pdf":      "Minimal PDF document generation with create, text, render (const pdf = require('pdf'))",
+		"ndjson":   "Newline-delimited JSON with parse(text) to an array, stringify(values) and parseStream(stream) for ReadableStream-like sources such as streamed request bodies (const ndjson = require('ndjson'))",
+		"worker":   "Worker(code) runs code in a separate VM; postMessage/onmessage pass JSON-serializable data both ways, terminate() stops it (available globally)",
+		"html":     "HTML parsing into a read-only DOM-like tree with querySelector, querySelectorAll, getElementsByTagName, textContent (const html = require('html'))",
+		"wasm":     "WebAssembly.instantiate, compile and validate for running WebAssembly modules without imports (available globally)",
 	}
 
 	// Add enabled modules with descriptions
@@ -415,6 +873,9 @@ func buildToolDescription(enabledModules []string) string {
 	description.WriteString("• Modern JavaScript features supported (const/let, arrow functions, destructuring, etc.)\n")
 	description.WriteString("• HTTP servers automatically run in background and don't block execution\n")
 	description.WriteString("• Async/await and Promises are fully supported\n")
+	description.WriteString("• runtime.deadline() returns the milliseconds left before the execution timeout\n")
+	description.WriteString("• runtime.onExit(fn) registers cleanup to run when the runtime shuts down\n")
+	description.WriteString("• Each call starts with fresh globals unless calls share a sessionId\n")
 
 	return description.String()
 }
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/stretchr/testify/assert"
@@ -210,3 +211,209 @@ func TestExecuteJS_HTTPServerWithCallback(t *testing.T) {
 	assert.Contains(t, text, "http available:")
 	assert.Contains(t, text, "Result: callback test completed")
 }
+
+func TestExecuteJS_RuntimeDeadline(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules:   []string{},
+		ExecutionTimeout: 10 * time.Second,
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const first = runtime.deadline();
+			const start = Date.now();
+			while (Date.now() - start < 20) {}
+			const second = runtime.deadline();
+			[first > 0, first <= 10000, second < first].join(" ");
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: true true true")
+}
+
+func TestExecuteJS_ConsoleAsResult(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules:  []string{},
+		ConsoleAsResult: true,
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			console.log("first");
+			console.log("answer: 42");
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Equal(t, "first\nanswer: 42\n", result.Content[0].(mcp.TextContent).Text)
+	structured := result.StructuredContent.(executionResult)
+	assert.Equal(t, "first\nanswer: 42", structured.Result)
+	assert.Equal(t, "console", structured.ResultSource)
+
+	// A returned value is still the result
+	request.Params.Arguments = map[string]any{"code": `console.log("working"); ({ answer: 42 })`}
+	result, err = handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	structured = result.StructuredContent.(executionResult)
+	assert.Equal(t, "working\n", structured.Output)
+	assert.Equal(t, map[string]any{"answer": int64(42)}, structured.Result)
+	assert.Equal(t, "return", structured.ResultSource)
+}
+
+func TestExecuteJS_StructuredResultWithoutConsoleAsResult(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{EnabledModules: []string{}})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{"code": `console.log("only output");`}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	structured := result.StructuredContent.(executionResult)
+	assert.Equal(t, "only output\n", structured.Output)
+	assert.Nil(t, structured.Result)
+	assert.Empty(t, structured.ResultSource)
+}
+
+func TestExecuteJS_MaxConcurrentExecutions(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules:          []string{},
+		MaxConcurrentExecutions: 2,
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `const end = Date.now() + 300; while (Date.now() < end) {} "slept";`,
+	}
+
+	results := make(chan *mcp.CallToolResult, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			result, err := handler.handleExecuteJS(context.Background(), request)
+			assert.NoError(t, err)
+			results <- result
+		}()
+	}
+
+	completed, busy := 0, 0
+	for i := 0; i < 5; i++ {
+		result := <-results
+		text := result.Content[0].(mcp.TextContent).Text
+		if result.IsError {
+			assert.Contains(t, text, "Server busy")
+			busy++
+		} else {
+			assert.Contains(t, text, "Result: slept")
+			completed++
+		}
+	}
+	assert.Equal(t, 2, completed)
+	assert.Equal(t, 3, busy)
+
+	// The slots are free again once the executions finished
+	request.Params.Arguments = map[string]any{"code": `1 + 1`}
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: 2")
+}
+
+func TestExecuteJS_CPUBudgetInterruptsRunawayLoop(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules:   []string{"timers"},
+		ExecutionTimeout: 30 * time.Second,
+		CPUBudget:        200 * time.Millisecond,
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{"code": `while (true) {}`}
+
+	start := time.Now()
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "CPU budget exceeded")
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// Waiting for timers doesn't count against the budget
+	request.Params.Arguments = map[string]any{
+		"code": `setTimeout(() => console.log("waited"), 400);`,
+	}
+	result, err = handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "waited")
+}
+
+func TestExecuteJS_DeterministicMode(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{},
+		Deterministic:  true,
+	})
+
+	run := func(seed float64) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "executeJS"
+		request.Params.Arguments = map[string]any{
+			"code": `
+				console.log([Math.random(), Math.random(), Math.random()].join(","));
+				console.log(Date.now(), new Date().toISOString());
+			`,
+			"seed": seed,
+		}
+		result, err := handler.handleExecuteJS(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	first := run(42)
+	assert.Equal(t, first, run(42))
+	assert.NotEqual(t, first, run(7))
+	assert.Contains(t, first, "1704067200000 2024-01-01T00:00:00.000Z")
+}
+
+func TestExecuteJS_ErrorCategories(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules:   []string{"timers"},
+		ExecutionTimeout: 200 * time.Millisecond,
+	})
+
+	tests := []struct {
+		name     string
+		code     string
+		category string
+	}{
+		{"syntax error", `const = 1;`, "syntax"},
+		{"thrown error", `throw new Error("boom");`, "runtime"},
+		{"runtime SyntaxError", `JSON.parse("{");`, "runtime"},
+		{"timeout", `setTimeout(() => {}, 60000);`, "timeout"},
+		{"module not enabled", `require("crypto");`, "module_not_enabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Name = "executeJS"
+			request.Params.Arguments = map[string]any{"code": tt.code}
+
+			result, err := handler.handleExecuteJS(context.Background(), request)
+			require.NoError(t, err)
+			assert.True(t, result.IsError)
+			failure, ok := result.StructuredContent.(executionFailure)
+			require.True(t, ok)
+			assert.Equal(t, tt.category, failure.Category)
+			assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Category: "+tt.category)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSession is a client session that buffers the notifications sent to it
+type testSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testSession) Initialize()       {}
+func (s *testSession) Initialized() bool { return true }
+func (s *testSession) SessionID() string { return "test-session" }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+func TestGenerator_StreamsProgress(t *testing.T) {
+	srv, err := NewJSServer()
+	require.NoError(t, err)
+	session := &testSession{notifications: make(chan mcp.JSONRPCNotification, 10)}
+	require.NoError(t, srv.RegisterSession(context.Background(), session))
+	ctx := srv.WithContext(context.Background(), session)
+
+	code := `
+		function* main() {
+			yield "chunk 1";
+			yield "chunk 2";
+			yield "chunk 3";
+			return "finished";
+		}
+		main;
+	`
+	message, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]any{
+			"name":      "executeJS",
+			"arguments": map[string]any{"code": code},
+			"_meta":     map[string]any{"progressToken": "stream-1"},
+		},
+	})
+	require.NoError(t, err)
+
+	response, ok := srv.HandleMessage(ctx, message).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	result, ok := response.Result.(mcp.CallToolResult)
+	require.True(t, ok)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "chunk 1\nchunk 2\nchunk 3\n")
+	assert.Contains(t, text, "Result: finished")
+
+	require.Len(t, session.notifications, 3)
+	for i, want := range []string{"chunk 1", "chunk 2", "chunk 3"} {
+		notification := <-session.notifications
+		assert.Equal(t, "notifications/progress", notification.Method)
+		assert.Equal(t, "stream-1", notification.Params.AdditionalFields["progressToken"])
+		assert.Equal(t, i+1, notification.Params.AdditionalFields["progress"])
+		assert.Equal(t, want, notification.Params.AdditionalFields["message"])
+	}
+}
+
+func TestConsole_StreamsProgressDuringExecution(t *testing.T) {
+	srv, err := NewJSServer()
+	require.NoError(t, err)
+	session := &testSession{notifications: make(chan mcp.JSONRPCNotification, 10)}
+	require.NoError(t, srv.RegisterSession(context.Background(), session))
+	ctx := srv.WithContext(context.Background(), session)
+
+	code := `
+		(async () => {
+			for (let i = 1; i <= 3; i++) {
+				console.log("step " + i);
+				await new Promise(resolve => setTimeout(resolve, 10));
+			}
+			await new Promise(resolve => setTimeout(resolve, 500));
+			console.log("done");
+		})();
+	`
+	message, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]any{
+			"name":      "executeJS",
+			"arguments": map[string]any{"code": code},
+			"_meta":     map[string]any{"progressToken": "log-1"},
+		},
+	})
+	require.NoError(t, err)
+
+	responses := make(chan mcp.JSONRPCMessage, 1)
+	go func() { responses <- srv.HandleMessage(ctx, message) }()
+
+	// The steps arrive while the script still waits before finishing
+	for i, want := range []string{"step 1", "step 2", "step 3"} {
+		select {
+		case notification := <-session.notifications:
+			assert.Equal(t, "notifications/progress", notification.Method)
+			assert.Equal(t, "log-1", notification.Params.AdditionalFields["progressToken"])
+			assert.Equal(t, i+1, notification.Params.AdditionalFields["progress"])
+			assert.Equal(t, want, notification.Params.AdditionalFields["message"])
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no progress notification for %q", want)
+		}
+	}
+	assert.Empty(t, responses, "execution finished before its output was streamed")
+
+	response, ok := (<-responses).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	result, ok := response.Result.(mcp.CallToolResult)
+	require.True(t, ok)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "step 1\nstep 2\nstep 3\ndone\n")
+	notification := <-session.notifications
+	assert.Equal(t, "done", notification.Params.AdditionalFields["message"])
+}
+
+func TestGenerator_StreamsThroughEventLoop(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			let ticks = 0;
+			setTimeout(() => { ticks++; }, 0);
+			(function* () {
+				yield "first";
+				yield new Promise(resolve => setTimeout(() => resolve("awaited, ticks=" + ticks), 10));
+				return 42;
+			});
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "first\nawaited, ticks=1\n")
+	assert.Contains(t, text, "Result: 42")
+}
+
+func TestGenerator_TimeoutKeepsStreamedOutput(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules:   []string{"timers"},
+		ExecutionTimeout: 200 * time.Millisecond,
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			(function* () {
+				yield "before the stall";
+				yield new Promise(() => setTimeout(() => {}, 60000));
+			});
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "timeout")
+	assert.Contains(t, text, "before the stall")
+}
+
+func TestGenerator_OnlyGeneratorsStream(t *testing.T) {
+	handler := NewJSHandler()
+
+	// A generator object streams like a generator function
+	text := runCode(t, handler, `(function* () { yield "from generator"; return "done"; })()`)
+	assert.Contains(t, text, "from generator\n")
+	assert.Contains(t, text, "Result: done")
+
+	// Other iterators are results, not streams
+	text = runCode(t, handler, `[1, 2, 3].values()`)
+	assert.NotContains(t, text, "1\n2\n3")
+
+	// A function without a constructor property is a plain result
+	text = runCode(t, handler, `const f = function () {}; Object.setPrototypeOf(f, null); f`)
+	assert.NotContains(t, text, "error")
+}
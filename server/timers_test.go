@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimers_IntervalSkipsMissedTicks(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			let calls = 0;
+			let afterClear = 0;
+			let cleared = false;
+			const id = setInterval(() => {
+				if (cleared) afterClear++;
+				calls++;
+				const start = Date.now();
+				while (Date.now() - start < 30) {}
+			}, 1);
+			setTimeout(() => {
+				clearInterval(id);
+				cleared = true;
+			}, 200);
+			setTimeout(() => {
+				console.log("bounded:", calls <= 10);
+				console.log("after clear:", afterClear);
+			}, 300);
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "bounded: true")
+	assert.Contains(t, text, "after clear: 0")
+}
+
+func TestTimers_PerformanceNow(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const a = performance.now();
+			const b = performance.now();
+			console.log("monotonic:", b >= a);
+			console.log("small delta:", b - a < 50);
+			console.log("relative to start:", a >= 0 && a < 60000);
+			console.log("origin near Date.now:", Math.abs(performance.timeOrigin + a - Date.now()) < 1000);
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "monotonic: true")
+	assert.Contains(t, text, "small delta: true")
+	assert.Contains(t, text, "relative to start: true")
+	assert.Contains(t, text, "origin near Date.now: true")
+}
+
+func TestTimers_MaxActiveTimers(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"timers"},
+		MaxTimers:      3,
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const ids = [];
+			for (let i = 0; i < 3; i++) {
+				ids.push(setTimeout(() => {}, 10));
+			}
+			let error;
+			try {
+				setTimeout(() => {}, 10);
+			} catch (e) {
+				error = e.code + " " + e.message;
+			}
+			clearTimeout(ids[0]);
+			const id = setTimeout(() => {}, 1);
+			[error, id > 0].join("|");
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: ERR_QUOTA_EXCEEDED setTimeout: too many active timers (limit 3)|true")
+}
+
+func TestTimers_NextTickOrdering(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const order = [];
+			setTimeout(() => order.push("timeout"), 0);
+			process.nextTick(() => {
+				order.push("tick 1");
+				process.nextTick((label) => order.push(label), "tick 3");
+			});
+			Promise.resolve().then(() => order.push("promise"));
+			process.nextTick(() => order.push("tick 2"));
+			setTimeout(() => console.log(order.join(", ")), 20);
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "tick 1, tick 2, tick 3, promise, timeout")
+}
+
+func TestTimers_NextTickAfterEarlierPromise(t *testing.T) {
+	handler := NewJSHandler()
+
+	// Reactions queued before the first nextTick of a turn run first, a
+	// documented difference from Node; later ones still run after the ticks
+	text := runCode(t, handler, `
+		const order = [];
+		Promise.resolve().then(() => order.push("earlier promise"));
+		process.nextTick(() => order.push("tick"));
+		Promise.resolve().then(() => order.push("later promise"));
+		setTimeout(() => console.log(order.join(", ")), 0);
+	`)
+	assert.Contains(t, text, "earlier promise, tick, later promise")
+}
+
+func TestTimers_IdleTimeoutStopsLingeringInterval(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules:   []string{"timers"},
+		ExecutionTimeout: 10 * time.Second,
+		IdleTimeout:      200 * time.Millisecond,
+	})
+
+	start := time.Now()
+	text := runCode(t, handler, `
+		let ticks = 0;
+		setInterval(() => { ticks++; }, 20);
+		setTimeout(() => console.log("timeout fired"), 100);
+		"started";
+	`)
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.Contains(t, text, "timeout fired")
+	assert.Contains(t, text, "Result: started")
+	assert.NotContains(t, text, "timeout\n")
+
+	// Intervals aren't idle while other work is still pending
+	text = runCode(t, handler, `
+		const id = setInterval(() => {}, 20);
+		setTimeout(() => { clearInterval(id); console.log("cleared by the script"); }, 500);
+	`)
+	assert.Contains(t, text, "cleared by the script")
+}
+
+func TestTimers_AbortSignalClearsTimeout(t *testing.T) {
+	handler := NewJSHandler()
+
+	start := time.Now()
+	text := runCode(t, handler, `
+		const controller = new AbortController();
+		setTimeout(() => console.log("aborted timeout fired"), 2000, { signal: controller.signal });
+		setTimeout(() => console.log("pre-aborted timeout fired"), 10, { signal: AbortSignal.abort() });
+		setTimeout((options) => console.log("plain options:", options.retries), 10, { retries: 3 });
+		setTimeout(() => { controller.abort(); console.log("aborted"); }, 20);
+	`)
+	// The aborted timeout no longer keeps the execution waiting
+	assert.Less(t, time.Since(start), 1500*time.Millisecond)
+	assert.Contains(t, text, "aborted")
+	assert.Contains(t, text, "plain options: 3")
+	assert.NotContains(t, text, "timeout fired")
+}
+
+func TestTimers_StoppedTimersRemoveAbortListener(t *testing.T) {
+	handler := NewJSHandler()
+
+	text := runCode(t, handler, `
+		const listeners = new Set();
+		const signal = {
+			aborted: false,
+			addEventListener: (type, fn) => listeners.add(fn),
+			removeEventListener: (type, fn) => listeners.delete(fn),
+			abort() { this.aborted = true; for (const fn of [...listeners]) fn(); },
+		};
+		clearTimeout(setTimeout(() => {}, 1000, { signal }));
+		clearInterval(setInterval(() => {}, 1000, { signal }));
+		setTimeout(() => {}, 1, { signal });
+		setInterval(() => {}, 1000, { signal });
+		setTimeout(() => signal.abort(), 10);
+		setTimeout(() => console.log("listeners:", listeners.size), 50);
+	`)
+	assert.Contains(t, text, "listeners: 0")
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURL_SearchParamsUpdateHref(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const u = new URL("https://example.com/path?a=1#top");
+			u.searchParams.append("b", "2");
+			console.log("append:", u.href, u.search);
+			u.searchParams.set("a", "3");
+			console.log("set:", u.toString());
+			u.searchParams.delete("a");
+			u.searchParams.delete("b");
+			console.log("delete:", u.href, JSON.stringify(u.search));
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "append: https://example.com/path?a=1&b=2#top ?a=1&b=2")
+	assert.Contains(t, text, "set: https://example.com/path?a=3&b=2#top")
+	assert.Contains(t, text, `delete: https://example.com/path#top ""`)
+}
+
+func TestURL_SearchParamsKeepOrder(t *testing.T) {
+	handler := NewJSHandler()
+
+	text := runCode(t, handler, `
+		const u = new URL("http://x.com/?z=1&a=2&z=3");
+		u.searchParams.append("m", "3");
+		console.log("append:", u.search);
+		u.searchParams.set("z", "9");
+		console.log("set:", u.search);
+		const pairs = new URLSearchParams([["z", "1"], ["a", "2"], ["z", "x y"]]);
+		console.log("pairs:", pairs.toString(), JSON.stringify(pairs.keys()), JSON.stringify(pairs.values()));
+		console.log("empty:", JSON.stringify(new URLSearchParams("a=&b").get("a")));
+	`)
+	assert.Contains(t, text, "append: ?z=1&a=2&z=3&m=3\n")
+	assert.Contains(t, text, "set: ?z=9&a=2&m=3\n")
+	assert.Contains(t, text, `pairs: z=1&a=2&z=x+y ["z","a","z"] ["1","2","x y"]`)
+	assert.Contains(t, text, `empty: ""`)
+}
+
+func TestURLSearchParams_FromObjectAndPairs(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const fromObject = new URLSearchParams({ a: 1, b: "two words" });
+			console.log("object:", fromObject.toString(), fromObject.get("a"));
+			const fromPairs = new URLSearchParams([["a", "1"], ["a", "2"], ["c", 3]]);
+			console.log("pairs:", fromPairs.toString(), JSON.stringify(fromPairs.getAll("a")));
+			try {
+				new URLSearchParams([["a"]]);
+			} catch (e) {
+				console.log("invalid:", e.name, e.code);
+			}
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "object: a=1&b=two+words 1")
+	assert.Contains(t, text, `pairs: a=1&a=2&c=3 ["1","2"]`)
+	assert.Contains(t, text, "invalid: TypeError ERR_INVALID_ARG")
+}
+
+func TestURL_InternationalDomainName(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const u = new URL("https://bücher.example:8443/straße?q=1");
+			console.log("hostname:", u.hostname);
+			console.log("host:", u.host);
+			console.log("href:", u.href);
+			console.log("unicode:", u.unicodeHostname);
+			console.log("from punycode:", new URL("https://xn--bcher-kva.example/").unicodeHostname);
+			console.log("ascii:", new URL("https://example.com/").unicodeHostname);
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "hostname: xn--bcher-kva.example\n")
+	assert.Contains(t, text, "host: xn--bcher-kva.example:8443\n")
+	assert.Contains(t, text, "href: https://xn--bcher-kva.example:8443/stra%C3%9Fe?q=1\n")
+	assert.Contains(t, text, "unicode: bücher.example\n")
+	assert.Contains(t, text, "from punycode: bücher.example\n")
+	assert.Contains(t, text, "ascii: example.com\n")
+}
@@ -0,0 +1,59 @@
+package vm
+
+import (
+	"github.com/grafana/sobek"
+)
+
+// Error codes set as the code property of errors thrown by modules, so
+// scripts can branch on them in catch blocks
+const (
+	CodeInvalidArgument = "ERR_INVALID_ARGUMENT" // Missing or malformed argument
+	CodeNotSupported    = "ERR_NOT_SUPPORTED"    // Unsupported algorithm, format or method
+	CodeOperationFailed = "ERR_OPERATION_FAILED" // The underlying Go operation failed
+	CodeNetwork         = "ERR_NETWORK"          // A network request failed
+	CodeAborted         = "ERR_ABORTED"          // The operation was aborted
+	CodeTimeout         = "ERR_TIMEOUT"          // The operation timed out
+	CodeQuotaExceeded   = "ERR_QUOTA_EXCEEDED"   // A size or usage limit was exceeded
+	CodeDataClone       = "ERR_DATA_CLONE"       // A value could not be cloned
+	CodeModuleDisabled  = "ERR_MODULE_DISABLED"  // require() named a module that is not enabled
+)
+
+// NewError creates an Error for a failed Go operation in module, carrying
+// the code and module properties shared by all module errors
+func NewError(rt *sobek.Runtime, module, code string, err error) *sobek.Object {
+	return newModuleError(rt, "Error", module, code, err.Error())
+}
+
+// NewTypeError creates a TypeError for invalid input to module
+func NewTypeError(rt *sobek.Runtime, module, code, message string) *sobek.Object {
+	return newModuleError(rt, "TypeError", module, code, message)
+}
+
+// NewRangeError creates a RangeError for an out of range value passed to module
+func NewRangeError(rt *sobek.Runtime, module, code, message string) *sobek.Object {
+	return newModuleError(rt, "RangeError", module, code, message)
+}
+
+// NewSyntaxError creates a SyntaxError for malformed text passed to module
+func NewSyntaxError(rt *sobek.Runtime, module, code, message string) *sobek.Object {
+	return newModuleError(rt, "SyntaxError", module, code, message)
+}
+
+// NewNamedError creates an Error with a DOMException-style name such as
+// AbortError
+func NewNamedError(rt *sobek.Runtime, module, code, name, message string) *sobek.Object {
+	obj := newModuleError(rt, "Error", module, code, message)
+	obj.Set("name", name)
+	return obj
+}
+
+// newModuleError constructs an error with the given global constructor
+func newModuleError(rt *sobek.Runtime, constructor, module, code, message string) *sobek.Object {
+	obj, err := rt.New(rt.Get(constructor), rt.ToValue(message))
+	if err != nil {
+		panic(err)
+	}
+	obj.Set("code", code)
+	obj.Set("module", module)
+	return obj
+}
@@ -1,7 +1,10 @@
 package vm
 
 import (
+	"context"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/grafana/sobek"
 	"github.com/mark3labs/codebench-mcp/internal/logger"
@@ -13,7 +16,18 @@ type EventLoop struct {
 	cleanup []func()       // job of cleanup
 	enqueue uint           // Count of job in the event loop
 	pending uint           // Count of pending async operations (timers, etc.)
+	stopped bool           // Set once Stop is called; the loop never waits again
+	running bool           // Set while Start is running
+	stopErr error          // Error passed to the first Stop call
 	cond    *sync.Cond     // Condition variable for synchronization
+
+	// Cancel functions of the pending operations that repeat, e.g. intervals
+	repeating map[uint64]func()
+	nextID    uint64
+
+	// Time spent running jobs since Start, read from other goroutines
+	busy     atomic.Int64 // Nanoseconds of the jobs that finished
+	jobStart atomic.Int64 // Unix nanoseconds the running jobs started, 0 when idle
 }
 
 // NewEventLoop creates a new EventLoop instance
@@ -27,9 +41,31 @@ func NewEventLoop() *EventLoop {
 // Start the event loop and execute the provided function
 func (e *EventLoop) Start(task func() error) (err error) {
 	e.cond.L.Lock()
-	e.queue = []func() error{task}
+	e.running = true
+	if e.stopped {
+		// A stopped loop never runs new tasks, it only reports why it stopped
+		stopErr := e.stopErr
+		e.queue = []func() error{func() error { return stopErr }}
+	} else {
+		e.queue = []func() error{task}
+	}
 	e.cond.L.Unlock()
-	
+	e.busy.Store(0)
+
+	// A job that panics ends the loop: it is marked stopped and no longer
+	// running, so Close still runs the cleanup jobs, and the panic goes on to
+	// the caller
+	defer func() {
+		if r := recover(); r != nil {
+			e.cond.L.Lock()
+			e.running = false
+			e.stopped = true
+			e.cond.L.Unlock()
+			e.jobStart.Store(0)
+			panic(r)
+		}
+	}()
+
 	for {
 		e.cond.L.Lock()
 
@@ -38,6 +74,8 @@ func (e *EventLoop) Start(task func() error) (err error) {
 			e.queue = make([]func() error, 0, len(queue))
 			e.cond.L.Unlock()
 
+			start := time.Now()
+			e.jobStart.Store(start.UnixNano())
 			for _, job := range queue {
 				if err2 := job(); err2 != nil {
 					if err != nil {
@@ -47,15 +85,18 @@ func (e *EventLoop) Start(task func() error) (err error) {
 					}
 				}
 			}
+			e.jobStart.Store(0)
+			e.busy.Add(int64(time.Since(start)))
 			continue
 		}
 
-		if e.enqueue > 0 || e.pending > 0 {
+		if !e.stopped && (e.enqueue > 0 || e.pending > 0) {
 			e.cond.Wait()
 			e.cond.L.Unlock()
 			continue
 		}
 
+		e.running = false
 		if len(e.cleanup) > 0 {
 			cleanup := e.cleanup
 			e.cleanup = e.cleanup[:0]
@@ -76,37 +117,80 @@ func (e *EventLoop) Start(task func() error) (err error) {
 type Enqueue func(func() error)
 
 // EnqueueJob return a function Enqueue to add a job to the job queue.
+// Reservations taken after Stop are inert and their jobs are dropped.
 func (e *EventLoop) EnqueueJob() Enqueue {
 	e.cond.L.Lock()
 	called := false
-	e.enqueue++
+	if !e.stopped {
+		e.enqueue++
+	}
 	e.cond.L.Unlock()
 	return func(job func() error) {
 		e.cond.L.Lock()
 		defer e.cond.L.Unlock()
-		switch {
-		case called:
+		if called {
 			panic("Enqueue already called")
-		case e.enqueue == 0:
-			return // Eventloop stopped
 		}
-		e.queue = append(e.queue, job) // Add the job to the queue
 		called = true
+		if e.stopped {
+			return // Eventloop stopped, Stop already released this reservation
+		}
+		e.queue = append(e.queue, job) // Add the job to the queue
 		e.enqueue--
 		e.cond.Signal() // Signal the condition variable
 	}
 }
 
+// Post adds a job to the queue without holding the loop open for it.
+// The job only runs if the loop is still running when it is posted.
+func (e *EventLoop) Post(job func() error) {
+	e.cond.L.Lock()
+	defer e.cond.L.Unlock()
+	if e.stopped {
+		return
+	}
+	e.queue = append(e.queue, job)
+	e.cond.Signal()
+}
+
 // Stop the eventloop with the provided error
+// Queued jobs are discarded, outstanding reservations and pending operations
+// are released, and Start returns err after running the cleanup jobs.
 func (e *EventLoop) Stop(err error) {
 	e.cond.L.Lock()
 	defer e.cond.L.Unlock()
+	if e.stopped {
+		return // Keep the first error
+	}
+	e.stopped = true
+	e.stopErr = err
 	// clean the queue
 	e.queue = append(e.queue[:0], func() error { return err })
 	e.enqueue = 0
+	e.pending = 0
+	e.repeating = nil
 	e.cond.Signal()
 }
 
+// Close stops the loop with err and runs the outstanding cleanup jobs, unless
+// Start is still running and will run them itself once it returns
+func (e *EventLoop) Close(err error) {
+	e.Stop(err)
+
+	e.cond.L.Lock()
+	if e.running {
+		e.cond.L.Unlock()
+		return
+	}
+	cleanup := e.cleanup
+	e.cleanup = nil
+	e.cond.L.Unlock()
+
+	for _, clean := range cleanup {
+		clean()
+	}
+}
+
 // Cleanup add a function to execute when run finish.
 func (e *EventLoop) Cleanup(job ...func()) {
 	e.cond.L.Lock()
@@ -133,10 +217,18 @@ func (je joinError) Error() string {
 	return result
 }
 
+// Unwrap returns the joined errors so errors.Is and errors.As can inspect them
+func (je joinError) Unwrap() []error {
+	return je
+}
+
 // AddPending increments the pending operation counter
 func (e *EventLoop) AddPending() {
 	e.cond.L.Lock()
 	defer e.cond.L.Unlock()
+	if e.stopped {
+		return // Nothing will wait for it
+	}
 	e.pending++
 	logger.Debug("Added pending operation", "pending", e.pending)
 }
@@ -152,6 +244,89 @@ func (e *EventLoop) RemovePending() {
 	e.cond.Signal()
 }
 
+// Busy returns how long the loop has spent running jobs since Start, rather
+// than waiting for timers or other async operations
+func (e *EventLoop) Busy() time.Duration {
+	busy := e.busy.Load()
+	if start := e.jobStart.Load(); start != 0 {
+		busy += time.Now().UnixNano() - start
+	}
+	return time.Duration(busy)
+}
+
+// AddRepeating adds a pending operation that repeats until cancelled, such as
+// an interval. The loop calls cancel, on its own goroutine, when it stops
+// such operations once it only waits for them. The returned function removes
+// the operation, like RemovePending.
+func (e *EventLoop) AddRepeating(cancel func()) (remove func()) {
+	e.cond.L.Lock()
+	defer e.cond.L.Unlock()
+	if e.stopped {
+		return func() {} // Nothing will wait for it
+	}
+	e.pending++
+	if e.repeating == nil {
+		e.repeating = make(map[uint64]func())
+	}
+	e.nextID++
+	id := e.nextID
+	e.repeating[id] = cancel
+	return func() {
+		e.cond.L.Lock()
+		defer e.cond.L.Unlock()
+		if _, ok := e.repeating[id]; !ok {
+			return
+		}
+		delete(e.repeating, id)
+		if e.pending > 0 {
+			e.pending--
+		}
+		e.cond.Signal()
+	}
+}
+
+// OnlyRepeating reports whether the loop is only waiting for repeating
+// operations: nothing is queued, and every pending operation and enqueue
+// reservation belongs to one, as each holds one reservation between ticks
+func (e *EventLoop) OnlyRepeating() bool {
+	e.cond.L.Lock()
+	defer e.cond.L.Unlock()
+	repeating := uint(len(e.repeating))
+	return !e.stopped && repeating > 0 && len(e.queue) == 0 &&
+		e.pending == repeating && e.enqueue <= repeating
+}
+
+// CancelRepeating cancels the repeating operations on the loop's goroutine,
+// letting the loop finish once their last ticks have run
+func (e *EventLoop) CancelRepeating() {
+	e.Post(func() error {
+		e.cond.L.Lock()
+		cancels := make([]func(), 0, len(e.repeating))
+		for _, cancel := range e.repeating {
+			cancels = append(cancels, cancel)
+		}
+		e.cond.L.Unlock()
+		for _, cancel := range cancels {
+			cancel()
+		}
+		return nil
+	})
+}
+
+// Enqueued returns the number of outstanding EnqueueJob reservations
+func (e *EventLoop) Enqueued() uint {
+	e.cond.L.Lock()
+	defer e.cond.L.Unlock()
+	return e.enqueue
+}
+
+// Pending returns the number of pending async operations
+func (e *EventLoop) Pending() uint {
+	e.cond.L.Lock()
+	defer e.cond.L.Unlock()
+	return e.pending
+}
+
 // Helper functions for runtime integration
 
 var symbolVM = sobek.NewSymbol("Symbol.__vm__")
@@ -166,6 +341,17 @@ func EnqueueJob(rt *sobek.Runtime) Enqueue {
 	return getVMFromRuntime(rt).eventLoop.EnqueueJob()
 }
 
+// Loop returns the event loop of the given runtime's VM. Unlike the runtime,
+// it may be used from any goroutine.
+func Loop(rt *sobek.Runtime) *EventLoop {
+	return getVMFromRuntime(rt).eventLoop
+}
+
+// Post adds a job for the given runtime without holding its event loop open
+func Post(rt *sobek.Runtime, job func() error) {
+	getVMFromRuntime(rt).eventLoop.Post(job)
+}
+
 // Cleanup adds cleanup functions for the given runtime
 func Cleanup(rt *sobek.Runtime, job ...func()) {
 	getVMFromRuntime(rt).eventLoop.Cleanup(job...)
@@ -176,11 +362,25 @@ func AddPending(rt *sobek.Runtime) {
 	getVMFromRuntime(rt).eventLoop.AddPending()
 }
 
+// AddRepeating adds a repeating pending operation for the given runtime, see
+// EventLoop.AddRepeating
+func AddRepeating(rt *sobek.Runtime, cancel func()) (remove func()) {
+	return getVMFromRuntime(rt).eventLoop.AddRepeating(cancel)
+}
+
 // RemovePending removes a pending operation for the given runtime
 func RemovePending(rt *sobek.Runtime) {
 	getVMFromRuntime(rt).eventLoop.RemovePending()
 }
 
+// Context returns the context the VM for the given runtime was created with
+func Context(rt *sobek.Runtime) context.Context {
+	if ctx := getVMFromRuntime(rt).ctx; ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
 // getVMFromRuntime extracts the VM instance from the runtime
 func getVMFromRuntime(rt *sobek.Runtime) *VM {
 	value := rt.GlobalObject().GetSymbol(symbolVM)
@@ -63,7 +63,7 @@ func (l *ModuleLoader) EnableRequire(rt *sobek.Runtime, enabledModules map[strin
 			// Check if module is enabled
 			if !module.IsEnabled(enabledModules) {
 				logger.Debug("Module not enabled", "name", moduleName)
-				panic(rt.NewTypeError(fmt.Sprintf("Module '%s' is not enabled", moduleName)))
+				panic(NewTypeError(rt, "require", CodeModuleDisabled, fmt.Sprintf("Module '%s' is not enabled", moduleName)))
 			}
 			
 			// Create the module object
@@ -2,6 +2,11 @@ package vm
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
 
 	"github.com/grafana/sobek"
 	"github.com/mark3labs/codebench-mcp/internal/logger"
@@ -51,6 +56,8 @@ func (m *VMManager) CreateVM(ctx context.Context) (*VM, error) {
 		manager:   m,
 		ctx:       ctx,
 		eventLoop: eventLoop,
+		// Captured before any script can replace it, for EstimateSize
+		describe: rt.Get("Object").ToObject(rt).Get("getOwnPropertyDescriptor"),
 	}
 
 	// Store VM reference in runtime for event loop access
@@ -77,10 +84,43 @@ func (m *VMManager) CreateVM(ctx context.Context) (*VM, error) {
 	m.loader.SetupGlobals(rt, m.enabledModules)
 	logger.Debug("Global objects setup completed")
 
+	// Let scripts check how much of the execution time is left
+	vm.setupRuntimeGlobal()
+
+	// Expose event loop diagnostics to scripts in debug mode
+	if logger.DebugEnabled {
+		vm.setupDiagnostics()
+		logger.Debug("Event loop diagnostics enabled")
+	}
+
 	logger.Debug("VM creation completed")
 	return vm, nil
 }
 
+// Warmup pre-initializes the runtime and enabled modules so the first
+// execution doesn't pay one-time setup costs
+func (m *VMManager) Warmup() error {
+	start := time.Now()
+
+	// Compiling a throwaway program initializes the parser and builtins
+	if _, err := sobek.Compile("", "(() => 0)()", false); err != nil {
+		return err
+	}
+	_ = sobek.New()
+
+	var errs []error
+	for _, module := range m.registry.GetEnabled(m.enabledModules) {
+		if warmer, ok := module.(Warmer); ok {
+			if err := warmer.Warmup(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", module.Name(), err))
+			}
+		}
+	}
+
+	logger.Debug("Warmup completed", "duration", time.Since(start))
+	return errors.Join(errs...)
+}
+
 // GetEnabledModules returns the list of enabled module names
 func (m *VMManager) GetEnabledModules() []string {
 	var enabled []string
@@ -93,39 +133,259 @@ func (m *VMManager) GetEnabledModules() []string {
 
 // VM wraps a Sobek runtime with event loop support
 type VM struct {
-	runtime   *sobek.Runtime
-	manager   *VMManager
-	ctx       context.Context
-	eventLoop *EventLoop
+	runtime     *sobek.Runtime
+	manager     *VMManager
+	ctx         context.Context
+	eventLoop   *EventLoop
+	timings     Timings
+	onYield     func(chunk sobek.Value)
+	describe    sobek.Value // The original Object.getOwnPropertyDescriptor
+	exitHooks   []sobek.Callable
+	closeMu     sync.Mutex
+	closers     []func() // Registered with OnClose
+	cpuBudget   time.Duration // Running time allowed per run, 0 for no limit
+	idleTimeout time.Duration // How long a run may only wait for intervals, 0 for no limit
+}
+
+// Timings is the phase breakdown of the last RunString call
+type Timings struct {
+	Compile time.Duration // Parsing and compiling the source
+	Run     time.Duration // Synchronous execution of the compiled program
+	Drain   time.Duration // Waiting for the event loop to finish async work
 }
 
 // RunString executes JavaScript code in the VM with event loop support
 // This matches the standard pattern where RunString always uses the event loop
 func (vm *VM) RunString(code string) (ret sobek.Value, err error) {
+	start := time.Now()
+	vm.timings = Timings{}
 	err = vm.runWithEventLoop(func() error {
-		ret, err = vm.runtime.RunString(code)
+		program, err := sobek.Compile("", code, false)
+		compiled := time.Now()
+		vm.timings.Compile = compiled.Sub(start)
+		if err != nil {
+			return err
+		}
+		ret, err = vm.runtime.RunProgram(program)
+		vm.timings.Run = time.Since(compiled)
+		if err == nil && vm.onYield != nil {
+			err = vm.stream(ret, func(v sobek.Value) { ret = v })
+		}
 		return err
 	})
+	if drain := time.Since(start) - vm.timings.Compile - vm.timings.Run; drain > 0 {
+		vm.timings.Drain = drain
+	}
+	logger.Debug("Execution timings", "compile", vm.timings.Compile, "run", vm.timings.Run, "drain", vm.timings.Drain)
 	return
 }
 
+// Run executes task on the VM's event loop, returning once the loop has no
+// more work, like RunString does for a script
+func (vm *VM) Run(task func() error) error {
+	return vm.runWithEventLoop(task)
+}
+
+// ErrCPUBudgetExceeded interrupts a run that spent longer than its CPU budget
+// running JavaScript
+var ErrCPUBudgetExceeded = errors.New("CPU budget exceeded")
+
+// SetCPUBudget limits the time each run may spend running JavaScript, not
+// counting time spent waiting for timers or other async operations. A run
+// over budget is interrupted with ErrCPUBudgetExceeded, so a runaway loop
+// fails long before the context's deadline. Zero removes the limit.
+func (vm *VM) SetCPUBudget(budget time.Duration) {
+	vm.cpuBudget = budget
+}
+
+// SetIdleTimeout stops the repeating operations of a run, such as intervals
+// never cleared, once the loop has waited for nothing else for timeout, so
+// the run finishes instead of lasting until the context's deadline. Zero
+// disables it.
+func (vm *VM) SetIdleTimeout(timeout time.Duration) {
+	vm.idleTimeout = timeout
+}
+
+// Timings returns the phase breakdown of the last RunString call
+func (vm *VM) Timings() Timings {
+	return vm.timings
+}
+
 // runWithEventLoop executes a task in the event loop (similar to standard Run method)
 func (vm *VM) runWithEventLoop(task func() error) error {
 	// Clear any previous interrupt
 	vm.runtime.ClearInterrupt()
 	
-	// Set up context cancellation to interrupt the runtime if needed
-	if vm.ctx != nil {
+	// Set up context cancellation to interrupt the runtime if needed, for as
+	// long as this run lasts
+	if ctx := vm.ctx; ctx != nil {
+		var mu sync.Mutex
+		finished := false
+		done := make(chan struct{})
+		defer func() {
+			mu.Lock()
+			finished = true
+			mu.Unlock()
+			close(done)
+		}()
 		go func() {
-			<-vm.ctx.Done()
-			vm.runtime.Interrupt(vm.ctx.Err())
-			vm.eventLoop.Stop(vm.ctx.Err())
+			select {
+			case <-ctx.Done():
+				// The context may end just as the run finishes, which must not
+				// stop the loop of a VM that is reused
+				mu.Lock()
+				defer mu.Unlock()
+				if !finished {
+					vm.runtime.Interrupt(ctx.Err())
+					vm.eventLoop.Stop(ctx.Err())
+				}
+			case <-done:
+			}
 		}()
 	}
 	
+	// Check the time spent running against the CPU budget while the loop runs
+	if budget := vm.cpuBudget; budget > 0 {
+		var mu sync.Mutex
+		finished := false
+		done := make(chan struct{})
+		defer func() {
+			mu.Lock()
+			finished = true
+			mu.Unlock()
+			close(done)
+		}()
+		go func() {
+			ticker := time.NewTicker(min(budget, 10*time.Millisecond))
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					if vm.eventLoop.Busy() <= budget {
+						continue
+					}
+					mu.Lock()
+					if !finished {
+						vm.runtime.Interrupt(ErrCPUBudgetExceeded)
+						vm.eventLoop.Stop(ErrCPUBudgetExceeded)
+					}
+					mu.Unlock()
+					return
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	// Stop lingering intervals once they are all the loop waits for
+	if timeout := vm.idleTimeout; timeout > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			ticker := time.NewTicker(min(timeout/4, 50*time.Millisecond))
+			defer ticker.Stop()
+			var idleSince time.Time
+			for {
+				select {
+				case now := <-ticker.C:
+					if !vm.eventLoop.OnlyRepeating() {
+						idleSince = time.Time{}
+						continue
+					}
+					if idleSince.IsZero() {
+						idleSince = now
+						continue
+					}
+					if now.Sub(idleSince) >= timeout {
+						logger.Debug("Stopping repeating operations of idle run", "idle", now.Sub(idleSince))
+						vm.eventLoop.CancelRepeating()
+						return
+					}
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
 	return vm.eventLoop.Start(task)
 }
 
+// setupDiagnostics exposes the event loop counters as the __eventloop global
+func (vm *VM) setupDiagnostics() {
+	rt := vm.runtime
+	diag := rt.NewObject()
+	_ = diag.DefineAccessorProperty("enqueue", rt.ToValue(func(sobek.FunctionCall) sobek.Value {
+		return rt.ToValue(vm.eventLoop.Enqueued())
+	}), nil, sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+	_ = diag.DefineAccessorProperty("pending", rt.ToValue(func(sobek.FunctionCall) sobek.Value {
+		return rt.ToValue(vm.eventLoop.Pending())
+	}), nil, sobek.FLAG_FALSE, sobek.FLAG_TRUE)
+	rt.Set("__eventloop", diag)
+}
+
+// setupRuntimeGlobal exposes the runtime global, whose deadline() returns the
+// milliseconds left before the VM context expires, or Infinity without one,
+// and whose onExit(fn) registers fn to run when the VM is closed
+func (vm *VM) setupRuntimeGlobal() {
+	rt := vm.runtime
+	obj := rt.NewObject()
+	_ = obj.Set("deadline", func(sobek.FunctionCall) sobek.Value {
+		if vm.ctx == nil {
+			return rt.ToValue(math.Inf(1))
+		}
+		deadline, ok := vm.ctx.Deadline()
+		if !ok {
+			return rt.ToValue(math.Inf(1))
+		}
+		remaining := time.Until(deadline)
+		if remaining < 0 {
+			remaining = 0
+		}
+		return rt.ToValue(float64(remaining) / float64(time.Millisecond))
+	})
+	_ = obj.Set("onExit", func(call sobek.FunctionCall) sobek.Value {
+		fn, ok := sobek.AssertFunction(call.Argument(0))
+		if !ok {
+			panic(NewTypeError(rt, "runtime", CodeInvalidArgument, "runtime.onExit expects a function"))
+		}
+		vm.exitHooks = append(vm.exitHooks, fn)
+		return sobek.Undefined()
+	})
+	rt.Set("runtime", obj)
+}
+
+// runExitHooks calls the functions registered with runtime.onExit, in the
+// order they were registered. It runs as an event loop cleanup job, once the
+// loop no longer runs anything else on the runtime.
+func (vm *VM) runExitHooks() {
+	hooks := vm.exitHooks
+	vm.exitHooks = nil
+	if len(hooks) == 0 {
+		return
+	}
+	// A VM stopped by its context is still interrupted
+	vm.runtime.ClearInterrupt()
+	for _, hook := range hooks {
+		if _, err := hook(sobek.Undefined()); err != nil {
+			logger.Debug("Exit hook failed", "error", err)
+		}
+	}
+}
+
+// SetContext replaces the context the VM was created with, for reusing the VM
+// in a later execution. It must not be called while the VM is running.
+func (vm *VM) SetContext(ctx context.Context) {
+	vm.ctx = ctx
+}
+
+// Post runs job on the VM's event loop if it is running, without keeping the
+// loop open for it
+func (vm *VM) Post(job func() error) {
+	vm.eventLoop.Post(job)
+}
+
 // SetGlobal sets a global variable in the VM
 func (vm *VM) SetGlobal(name string, value interface{}) {
 	vm.runtime.Set(name, value)
@@ -136,8 +396,35 @@ func (vm *VM) Runtime() *sobek.Runtime {
 	return vm.runtime
 }
 
-// Close cleans up the VM and its modules
+// OnClose adds functions to run when the VM of the given runtime is closed.
+// Unlike Cleanup jobs, which run whenever the event loop finishes a run,
+// they run once, so a persistent VM keeps what they release until then.
+func OnClose(rt *sobek.Runtime, fn ...func()) {
+	vm := getVMFromRuntime(rt)
+	vm.closeMu.Lock()
+	defer vm.closeMu.Unlock()
+	vm.closers = append(vm.closers, fn...)
+}
+
+// ErrClosed stops the event loop of a closed VM
+var ErrClosed = errors.New("vm closed")
+
+// Close cleans up the VM and its modules, running the exit hooks registered
+// by scripts and stopping the event loop and any timers or other operations
+// still outstanding
 func (vm *VM) Close() error {
+	// Exit hooks run on the loop's goroutine if it is still running, or here
+	vm.eventLoop.Cleanup(vm.runExitHooks)
+	vm.eventLoop.Close(ErrClosed)
+
+	vm.closeMu.Lock()
+	closers := vm.closers
+	vm.closers = nil
+	vm.closeMu.Unlock()
+	for _, closer := range closers {
+		closer()
+	}
+
 	// Cleanup all modules
 	enabledModules := vm.manager.registry.GetEnabled(vm.manager.enabledModules)
 	for _, module := range enabledModules {
@@ -5,7 +5,9 @@ import (
 	"github.com/mark3labs/codebench-mcp/internal/logger"
 )
 
-// Module interface defines how modules integrate with the VM
+// Module interface defines how modules integrate with the VM. Cleanup runs
+// whenever any VM closes, including a worker's, so it must not clear state
+// other VMs still use; per-VM state belongs on the runtime instead.
 type Module interface {
 	Name() string
 	Setup(runtime *sobek.Runtime, manager *VMManager) error
@@ -13,6 +15,12 @@ type Module interface {
 	IsEnabled(enabledModules map[string]bool) bool
 }
 
+// Warmer interface for modules with expensive state worth initializing
+// at server start instead of on the first execution
+type Warmer interface {
+	Warmup() error
+}
+
 // ModuleRegistry manages available modules
 type ModuleRegistry struct {
 	modules map[string]Module
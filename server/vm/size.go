@@ -0,0 +1,89 @@
+package vm
+
+import (
+	"reflect"
+
+	"github.com/grafana/sobek"
+)
+
+// Limits on how much of the object graph EstimateSize walks
+const (
+	maxSizeObjects    = 100000
+	maxSizeProperties = 1000000
+)
+
+// Rough per-value costs used by EstimateSize, in bytes
+const (
+	objectOverhead   = 64
+	propertyOverhead = 16
+	primitiveSize    = 8
+)
+
+var (
+	proxyType       = reflect.TypeOf(sobek.Proxy{})
+	arrayBufferType = reflect.TypeOf(sobek.ArrayBuffer{})
+)
+
+// EstimateSize approximates the bytes held by the values reachable from the
+// global object, built-ins included. Accessor properties and proxies are
+// skipped so no script code runs. It must not be called while the VM is
+// running a script, only before, after or from a job on its event loop.
+func (vm *VM) EstimateSize() int64 {
+	rt := vm.runtime
+	describe, ok := sobek.AssertFunction(vm.describe)
+	if !ok {
+		return 0
+	}
+
+	var size int64
+	properties := 0
+	seen := make(map[*sobek.Object]bool)
+	queue := []*sobek.Object{rt.GlobalObject()}
+	for len(queue) > 0 && len(seen) < maxSizeObjects && properties < maxSizeProperties {
+		obj := queue[0]
+		queue = queue[1:]
+		if seen[obj] {
+			continue
+		}
+		seen[obj] = true
+		size += objectOverhead
+
+		switch obj.ExportType() {
+		case proxyType:
+			continue
+		case arrayBufferType:
+			if buf, ok := obj.Export().(sobek.ArrayBuffer); ok {
+				size += int64(len(buf.Bytes()))
+			}
+		}
+		if proto := obj.Prototype(); proto != nil {
+			queue = append(queue, proto)
+		}
+
+		for _, key := range obj.GetOwnPropertyNames() {
+			properties++
+			size += propertyOverhead + int64(len(key))
+
+			desc, err := describe(sobek.Undefined(), obj, rt.ToValue(key))
+			if err != nil || sobek.IsUndefined(desc) {
+				continue
+			}
+			value := desc.ToObject(rt).Get("value")
+			if value == nil {
+				// An accessor, whose getter is not run
+				continue
+			}
+			switch {
+			case sobek.IsString(value):
+				size += int64(len(value.String()))
+			default:
+				if child, ok := value.(*sobek.Object); ok {
+					queue = append(queue, child)
+				} else {
+					size += primitiveSize
+				}
+			}
+		}
+	}
+	return size
+}
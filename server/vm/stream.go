@@ -0,0 +1,114 @@
+package vm
+
+import (
+	"errors"
+
+	"github.com/grafana/sobek"
+)
+
+// SetYieldHandler makes RunString stream scripts whose completion value is a
+// generator function or a generator: each yielded value is passed to fn as it
+// is produced, one event loop turn per step, and RunString returns the
+// generator's return value. Yielded promises are awaited first. Other
+// iterators, such as [1, 2].values(), are returned as they are.
+func (vm *VM) SetYieldHandler(fn func(chunk sobek.Value)) {
+	vm.onYield = fn
+}
+
+// stream iterates value if it is a generator, calling setResult with the
+// iterator's return value once it is done
+func (vm *VM) stream(value sobek.Value, setResult func(sobek.Value)) error {
+	iter, ok, err := vm.iterator(value)
+	if err != nil || !ok {
+		return err
+	}
+	setResult(sobek.Undefined())
+
+	next, _ := sobek.AssertFunction(iter.Get("next"))
+	var step func() error
+	step = func() error {
+		res, err := next(iter)
+		if err != nil {
+			return err
+		}
+		result := res.ToObject(vm.runtime)
+		if result.Get("done").ToBoolean() {
+			setResult(result.Get("value"))
+			return nil
+		}
+		return vm.await(result.Get("value"), func(chunk sobek.Value) error {
+			vm.onYield(chunk)
+			// Let timers and other queued work run between steps
+			vm.eventLoop.EnqueueJob()(step)
+			return nil
+		})
+	}
+	return step()
+}
+
+// iterator returns the generator for a generator function or generator
+func (vm *VM) iterator(value sobek.Value) (*sobek.Object, bool, error) {
+	obj, ok := value.(*sobek.Object)
+	if !ok {
+		return nil, false, nil
+	}
+	if fn, ok := sobek.AssertFunction(obj); ok {
+		if toStringTag(obj) != "GeneratorFunction" {
+			return nil, false, nil
+		}
+		res, err := fn(sobek.Undefined())
+		if err != nil {
+			return nil, false, err
+		}
+		obj = res.ToObject(vm.runtime)
+	}
+	if toStringTag(obj) != "Generator" {
+		return nil, false, nil
+	}
+	if _, ok := sobek.AssertFunction(obj.Get("next")); !ok {
+		return nil, false, nil
+	}
+	return obj, true, nil
+}
+
+// toStringTag returns the Symbol.toStringTag of obj, inherited from its
+// prototype, or "" if it has none
+func toStringTag(obj *sobek.Object) string {
+	tag := obj.GetSymbol(sobek.SymToStringTag)
+	if tag == nil || sobek.IsUndefined(tag) {
+		return ""
+	}
+	return tag.String()
+}
+
+// await calls fn with value, or with its settled value if it is a thenable
+func (vm *VM) await(value sobek.Value, fn func(sobek.Value) error) error {
+	obj, ok := value.(*sobek.Object)
+	if !ok {
+		return fn(value)
+	}
+	then, ok := sobek.AssertFunction(obj.Get("then"))
+	if !ok {
+		return fn(value)
+	}
+
+	enqueue := vm.eventLoop.EnqueueJob()
+	_, err := then(obj,
+		vm.runtime.ToValue(func(call sobek.FunctionCall) sobek.Value {
+			settled := call.Argument(0)
+			enqueue(func() error { return fn(settled) })
+			return sobek.Undefined()
+		}),
+		vm.runtime.ToValue(func(call sobek.FunctionCall) sobek.Value {
+			reason := call.Argument(0)
+			enqueue(func() error {
+				if err, ok := reason.Export().(error); ok {
+					return err
+				}
+				return errors.New(reason.String())
+			})
+			return sobek.Undefined()
+		}),
+	)
+	return err
+}
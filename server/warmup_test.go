@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// firstCallCode touches the modules that do one-time initialization
+const firstCallCode = `
+	const crypto = require('crypto');
+	crypto.sha256('hello').hex();
+	require('chart').bar({ bars: [{ label: 'A', value: 1 }, { label: 'B', value: 2 }] }).length;
+`
+
+func benchmarkFirstCall(b *testing.B, disableWarmup bool) {
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{"code": firstCallCode}
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		handler := NewJSHandlerWithConfig(ModuleConfig{DisableWarmup: disableWarmup})
+		b.StartTimer()
+
+		result, err := handler.handleExecuteJS(context.Background(), request)
+		if err != nil || result.IsError {
+			b.Fatalf("execution failed: %v %v", err, result)
+		}
+	}
+}
+
+func BenchmarkFirstCall_WithoutWarmup(b *testing.B) {
+	benchmarkFirstCall(b, true)
+}
+
+func BenchmarkFirstCall_WithWarmup(b *testing.B) {
+	benchmarkFirstCall(b, false)
+}
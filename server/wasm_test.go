@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addWASM is (module (func (export "add") (param i32 i32) (result i32)
+// local.get 0 local.get 1 i32.add))
+const addWASM = `new Uint8Array([
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+	0x01, 0x07, 0x01, 0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7f,
+	0x03, 0x02, 0x01, 0x00,
+	0x07, 0x07, 0x01, 0x03, 0x61, 0x64, 0x64, 0x00, 0x00,
+	0x0a, 0x09, 0x01, 0x07, 0x00, 0x20, 0x00, 0x20, 0x01, 0x6a, 0x0b,
+])`
+
+func TestWASM_InstantiateAndCallExport(t *testing.T) {
+	handler := NewJSHandlerWithConfig(ModuleConfig{
+		EnabledModules: []string{"wasm"},
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			(async () => {
+				const bytes = ` + addWASM + `;
+				console.log("valid:", WebAssembly.validate(bytes), WebAssembly.validate(new Uint8Array([1, 2, 3])));
+
+				const { module, instance } = await WebAssembly.instantiate(bytes);
+				console.log("add:", instance.exports.add(2, 3));
+				console.log("wraps:", instance.exports.add(0x7fffffff, 1));
+
+				const again = await WebAssembly.instantiate(module);
+				console.log("again:", again.exports.add(40, 2));
+
+				try {
+					await WebAssembly.instantiate(new Uint8Array([1, 2, 3]));
+				} catch (e) {
+					console.log("invalid:", e.code);
+				}
+			})();
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.False(t, result.IsError, text)
+	assert.Contains(t, text, "valid: true false")
+	assert.Contains(t, text, "add: 5")
+	assert.Contains(t, text, "wraps: -2147483648")
+	assert.Contains(t, text, "again: 42")
+	assert.Contains(t, text, "invalid: ERR_OPERATION_FAILED")
+}
+
+func TestWASM_NotEnabledByDefault(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `typeof WebAssembly`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Result: undefined")
+}
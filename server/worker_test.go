@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorker_DoublesNumber(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const w = new Worker("onmessage = (e) => postMessage(e.data * 2);");
+			w.onmessage = (e) => {
+				console.log("doubled:", e.data);
+				w.terminate();
+			};
+			w.postMessage(21);
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.False(t, result.IsError, text)
+	assert.Contains(t, text, "doubled: 42")
+}
+
+func TestWorker_MessagesConsoleAndErrors(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `
+			const w = new Worker(` + "`" + `
+				const seen = [];
+				self.onmessage = (e) => {
+					if (e.data === "fail") throw new Error("bad message");
+					seen.push(e.data.n);
+					console.log("worker got", e.data.n);
+					setTimeout(() => postMessage({ seen }), 10);
+				};
+			` + "`" + `);
+			w.onmessage = (e) => console.log("seen:", JSON.stringify(e.data.seen));
+			w.onerror = (e) => console.log("error:", e.message);
+			w.postMessage({ n: 1 });
+			w.postMessage({ n: 2 });
+			w.postMessage("fail");
+			try {
+				w.postMessage(() => 1);
+			} catch (e) {
+				console.log("clone:", e.name, e.code);
+			}
+			// The worker is idle once the messages are handled, so the run
+			// finishes without terminate()
+		`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.False(t, result.IsError, text)
+	assert.Contains(t, text, "clone: DataCloneError ERR_DATA_CLONE")
+	assert.Contains(t, text, "worker got 1")
+	assert.Contains(t, text, "worker got 2")
+	assert.Contains(t, text, "seen: [1]")
+	assert.Contains(t, text, "seen: [1,2]")
+	assert.Contains(t, text, "error: Error: bad message")
+}
+
+func TestWorker_UncaughtErrorFailsRun(t *testing.T) {
+	handler := NewJSHandler()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "executeJS"
+	request.Params.Arguments = map[string]any{
+		"code": `new Worker("throw new Error('worker broke')");`,
+	}
+
+	result, err := handler.handleExecuteJS(context.Background(), request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "worker: Error: worker broke")
+}
+
+func TestWorker_TerminateKeepsParentKV(t *testing.T) {
+	handler := NewJSHandler()
+
+	text := runCode(t, handler, `
+		kv.set("a", 1);
+		const w = new Worker("kv.set('worker', true); postMessage('ready');");
+		w.onmessage = () => {
+			w.terminate();
+			setTimeout(() => console.log("after terminate:", kv.get("a"), kv.has("worker")), 10);
+		};
+	`)
+	assert.Contains(t, text, "after terminate: 1 false")
+}